@@ -1,4 +1,3 @@
-//go:generate stringer -type Currency
 package asset
 
 // Code generated by asset
@@ -17,8 +16,8 @@ const (
 
 type Currency int
 
-var validCurrencies = []Currency{
+var currencyNames = []string{
 {{- range $k, $v := .}}
-{{$v}},
+"{{$v}}",
 {{- end}}
 }
\ No newline at end of file
@@ -1,4 +1,3 @@
-//go:generate stringer -type Currency
 package asset
 
 // Code generated by asset
@@ -36,32 +35,32 @@ const (
 
 type Currency int
 
-var validCurrencies = []Currency{
-	KFEE,
-	XDAO,
-	XNMC,
-	XXDG,
-	DASH,
-	EOS,
-	XXBT,
-	XXLM,
-	XREP,
-	BCH,
-	ZJPY,
-	XXMR,
-	ZCAD,
-	ZGBP,
-	XETH,
-	XMLN,
-	GNO,
-	XICN,
-	XLTC,
-	ZUSD,
-	USDT,
-	XETC,
-	XXRP,
-	XXVN,
-	XZEC,
-	ZEUR,
-	ZKRW,
+var currencyNames = []string{
+	"KFEE",
+	"XDAO",
+	"XNMC",
+	"XXDG",
+	"DASH",
+	"EOS",
+	"XXBT",
+	"XXLM",
+	"XREP",
+	"BCH",
+	"ZJPY",
+	"XXMR",
+	"ZCAD",
+	"ZGBP",
+	"XETH",
+	"XMLN",
+	"GNO",
+	"XICN",
+	"XLTC",
+	"ZUSD",
+	"USDT",
+	"XETC",
+	"XXRP",
+	"XXVN",
+	"XZEC",
+	"ZEUR",
+	"ZKRW",
 }
@@ -0,0 +1,127 @@
+package asset
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Unknown is an asset which is not known to the registry. It is outside the
+// range of the generated constants, so their values do not change.
+const Unknown Currency = -1
+
+// Info describes an asset known to the registry.
+type Info struct {
+	Name            string // Kraken name, e.g. XXBT.
+	Altname         string // Alternate name, e.g. XBT.
+	Decimals        int    // Scaling decimal places for record keeping.
+	DisplayDecimals int    // Scaling decimal places for output display.
+}
+
+// registry holds every known asset, indexed by Currency. It is seeded with the
+// generated constants and extended by Register.
+var registry = struct {
+	sync.RWMutex
+	assets []Info
+	byName map[string]Currency // By Kraken name.
+	lookup map[string]Currency // By Kraken name and altname.
+}{
+	byName: make(map[string]Currency),
+	lookup: make(map[string]Currency),
+}
+
+func init() {
+	for _, name := range currencyNames {
+		Register(Info{Name: name, Altname: seedAltname(name)})
+	}
+}
+
+// seedAltname returns the alternate name of a generated asset, which drops the
+// X or Z prefix of legacy four letter names, e.g. XBT for XXBT and USD for ZUSD.
+func seedAltname(name string) string {
+	if len(name) == 4 && (name[0] == 'X' || name[0] == 'Z') {
+		return name[1:]
+	}
+
+	return name
+}
+
+// Register adds an asset to the registry, or updates the asset with the same
+// Kraken name, and returns it. Fields left empty keep their registered values.
+//
+// Assets listed by Kraken after this package was generated are given new
+// Currency values, which are only valid for the life of the process.
+// Registering an asset without a name returns Unknown.
+func Register(info Info) Currency {
+	if info.Name == "" {
+		return Unknown
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	currency, ok := registry.byName[info.Name]
+	if !ok {
+		currency = Currency(len(registry.assets))
+		registry.assets = append(registry.assets, Info{Name: info.Name})
+		registry.byName[info.Name] = currency
+	}
+
+	existing := &registry.assets[currency]
+	if info.Altname != "" {
+		existing.Altname = info.Altname
+	}
+	if info.Decimals != 0 {
+		existing.Decimals = info.Decimals
+	}
+	if info.DisplayDecimals != 0 {
+		existing.DisplayDecimals = info.DisplayDecimals
+	}
+
+	for _, name := range []string{existing.Name, existing.Altname} {
+		if _, taken := registry.lookup[name]; name != "" && (!taken || name == existing.Name) {
+			registry.lookup[name] = currency
+		}
+	}
+
+	return currency
+}
+
+// Lookup returns the asset with the given Kraken name or altname, or Unknown
+// if there is none.
+func Lookup(name string) (Currency, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	currency, ok := registry.lookup[name]
+	if !ok {
+		return Unknown, false
+	}
+
+	return currency, true
+}
+
+// Info returns the registered information about the asset.
+func (i Currency) Info() (Info, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if i < 0 || int(i) >= len(registry.assets) {
+		return Info{}, false
+	}
+
+	return registry.assets[i], true
+}
+
+// String returns the Kraken name of the asset, or an empty string for Unknown.
+func (i Currency) String() string {
+	if i == Unknown {
+		return ""
+	}
+
+	info, ok := i.Info()
+	if !ok {
+		return fmt.Sprintf("Currency(%d)", i)
+	}
+
+	return info.Name
+}
@@ -0,0 +1,46 @@
+package asset
+
+import "strings"
+
+// symbolAliases maps the altnames of assets which differ from their common
+// tickers to those tickers.
+var symbolAliases = map[string]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
+// Symbol returns the common ticker of the asset, e.g. BTC for XXBT, DOGE for
+// XXDG and USD for ZUSD.
+func (i Currency) Symbol() string {
+	info, ok := i.Info()
+	if !ok {
+		return i.String()
+	}
+
+	name := info.Altname
+	if name == "" {
+		name = info.Name
+	}
+
+	if alias, ok := symbolAliases[name]; ok {
+		return alias
+	}
+
+	return name
+}
+
+// FromSymbol returns the asset with the given common ticker, e.g. XXBT for
+// BTC. Kraken names and altnames are also resolved. Symbols are not case
+// sensitive.
+func FromSymbol(symbol string) (Currency, bool) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	for altname, alias := range symbolAliases {
+		if symbol == alias {
+			symbol = altname
+			break
+		}
+	}
+
+	return Lookup(symbol)
+}
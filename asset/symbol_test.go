@@ -0,0 +1,49 @@
+package asset
+
+import "testing"
+
+func TestCurrency_Symbol(t *testing.T) {
+	cases := []struct {
+		currency Currency
+		symbol   string
+	}{
+		{currency: XXBT, symbol: "BTC"},
+		{currency: XXDG, symbol: "DOGE"},
+		{currency: ZUSD, symbol: "USD"},
+		{currency: XETH, symbol: "ETH"},
+		{currency: DASH, symbol: "DASH"},
+		{currency: USDT, symbol: "USDT"},
+		{currency: EOS, symbol: "EOS"},
+	}
+	for _, c := range cases {
+		assert(c.symbol, c.currency.Symbol(), t)
+
+		currency, ok := FromSymbol(c.symbol)
+		assert(true, ok, t)
+		assert(c.currency, currency, t)
+	}
+}
+
+func TestFromSymbol(t *testing.T) {
+	for _, symbol := range []string{"XXBT", "XBT", "BTC", "btc", " Btc "} {
+		currency, ok := FromSymbol(symbol)
+		assert(true, ok, t)
+		assert(XXBT, currency, t)
+	}
+
+	currency, ok := FromSymbol("FOO")
+	assert(false, ok, t)
+	assert(Unknown, currency, t)
+	assert("Currency(100000)", Currency(100000).Symbol(), t)
+}
+
+func TestUnknown(t *testing.T) {
+	_, ok := Unknown.Info()
+	assert(false, ok, t)
+	assert("", Unknown.String(), t)
+	assert(Unknown, Register(Info{}), t)
+
+	// The generated values are unchanged.
+	var zero Currency
+	assert(KFEE, zero, t)
+}
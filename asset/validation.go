@@ -1,19 +1,13 @@
 package asset
 
 func Valid(currency string) bool {
-	for _, v := range validCurrencies {
-		if v.String() == currency {
-			return true
-		}
-	}
-	return false
+	_, ok := Lookup(currency)
+	return ok
 }
 
 func Find(currency string) *Currency {
-	for _, v := range validCurrencies {
-		if v.String() == currency {
-			return &v
-		}
+	if v, ok := Lookup(currency); ok {
+		return &v
 	}
 	return nil
 }
@@ -28,6 +28,7 @@ type AssetPairsResponse map[pairs.AssetPair]AssetPairData
 // AssetPairData contains data about a tradeable asset pair from Kraken.
 type AssetPairData struct {
 	Altname           string      `json:"altname"`
+	WSName            string      `json:"wsname"`
 	AclassBase        string      `json:"aclass_base"`
 	Base              string      `json:"base"`
 	AclassQuote       string      `json:"aclass_quote"`
@@ -43,4 +44,17 @@ type AssetPairData struct {
 	FeeVolumeCurrency string      `json:"fee_volume_currency"`
 	MarginCall        int         `json:"margin_call"`
 	MarginStop        int         `json:"margin_stop"`
+	OrderMin          Decimal     `json:"ordermin"`
+	CostMin           Decimal     `json:"costmin"`
+}
+
+// FormatPrice formats a price with the number of decimal places of the pair.
+func (a AssetPairData) FormatPrice(price Decimal) string {
+	return price.StringFixed(int32(a.PairDecimals))
+}
+
+// FormatVolume formats a volume with the number of decimal places of the
+// pair's lots.
+func (a AssetPairData) FormatVolume(volume Decimal) string {
+	return volume.StringFixed(int32(a.LotDecimals))
 }
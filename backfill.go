@@ -0,0 +1,215 @@
+package gokraken
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// OhlcBackfillRequest represents a request to build OHLC candles from the
+// trades history of an asset pair.
+type OhlcBackfillRequest struct {
+	Pair pairs.AssetPair
+
+	// Interval is the width of each candle. Any whole number of seconds is
+	// accepted, not just the intervals served by the OHLC endpoint.
+	Interval time.Duration
+
+	// Since is the trades cursor to start from, such as TradesResponse.Last,
+	// OhlcBackfillIterator.Cursor or a Unix timestamp. Zero starts from the
+	// first trade of the pair.
+	Since int64
+
+	// Until stops the backfill at the first candle starting at or after it.
+	// Zero backfills up to the latest trade.
+	Until time.Time
+}
+
+// OhlcBackfillIterator builds OHLC candles, oldest first, by paging through
+// the trades history of an asset pair.
+//
+// A candle is only returned once a later trade shows that it is complete, so
+// the candle holding the latest trade is not returned. Intervals with no trades
+// produce no candle.
+type OhlcBackfillIterator struct {
+	ctx    context.Context
+	market *Market
+	req    OhlcBackfillRequest
+
+	since   int64    // Cursor of the next page of trades.
+	trades  []Trade  // Trades fetched but not yet aggregated.
+	current *candle  // Candle being built.
+	ohlc    OhlcData // Last candle returned.
+	cursor  int64    // Cursor to resume from after the last candle.
+	until   bool     // Whether a trade after Until was reached.
+	done    bool     // Whether there are no more trades.
+	err     error
+}
+
+// OhlcBackfill returns an iterator which builds OHLC candles from the trades
+// of the pair, starting at the cursor of the request. This reaches beyond the
+// last 720 candles returned by Ohlc. Each call to Kraken goes through the
+// client's rate limiter, if it has one.
+func (m *Market) OhlcBackfill(ctx context.Context, backfillReq OhlcBackfillRequest) *OhlcBackfillIterator {
+	it := &OhlcBackfillIterator{
+		ctx:    ctx,
+		market: m,
+		req:    backfillReq,
+		since:  backfillReq.Since,
+		cursor: backfillReq.Since,
+	}
+
+	if backfillReq.Interval < time.Second || backfillReq.Interval%time.Second != 0 {
+		it.err = fmt.Errorf("invalid ohlc interval %s: must be a whole number of seconds", backfillReq.Interval)
+	}
+
+	return it
+}
+
+// Next advances to the next candle. It returns false when there are no more
+// complete candles or an error occurred.
+func (it *OhlcBackfillIterator) Next() bool {
+	for it.err == nil {
+		for len(it.trades) > 0 {
+			trade := it.trades[0]
+			start := it.start(trade.Timestamp)
+
+			if !it.req.Until.IsZero() && !start.Before(it.req.Until) {
+				it.trades, it.until, it.done = nil, true, true
+				break
+			}
+
+			if it.current != nil && start.After(it.current.ohlc.Timestamp) {
+				return it.emit()
+			}
+
+			if it.current == nil {
+				it.current = newCandle(start)
+			}
+			it.current.add(trade)
+			it.trades = it.trades[1:]
+		}
+
+		if it.done {
+			if it.until && it.current != nil {
+				return it.emit()
+			}
+
+			return false
+		}
+
+		it.fill()
+	}
+
+	return false
+}
+
+// start returns the start of the candle holding a trade at the given time.
+// Candles are aligned to the Unix epoch.
+func (it *OhlcBackfillIterator) start(t time.Time) time.Time {
+	ns := t.UnixNano()
+	return time.Unix(0, ns-ns%int64(it.req.Interval))
+}
+
+// emit returns the candle being built.
+func (it *OhlcBackfillIterator) emit() bool {
+	it.ohlc = it.current.data()
+	it.current = nil
+
+	// Resuming just before the end of the candle refetches the trades of the
+	// next candle only.
+	it.cursor = it.ohlc.Timestamp.Add(it.req.Interval).UnixNano() - 1
+	return true
+}
+
+// fill fetches the next page of trades.
+func (it *OhlcBackfillIterator) fill() {
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return
+	}
+
+	res, err := it.market.Trades(it.ctx, TradesRequest{
+		Pair:  it.req.Pair,
+		Since: it.since,
+	})
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	if len(res.Trades) == 0 || res.Last == it.since {
+		it.done = true
+		return
+	}
+
+	it.trades = res.Trades
+	it.since = res.Last
+}
+
+// Ohlc returns the current candle.
+func (it *OhlcBackfillIterator) Ohlc() OhlcData {
+	return it.ohlc
+}
+
+// Cursor returns the trades cursor to resume from, so that the candle after
+// the current one is the first built. It can be stored and passed as the
+// Since of a later request.
+func (it *OhlcBackfillIterator) Cursor() int64 {
+	return it.cursor
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *OhlcBackfillIterator) Err() error {
+	return it.err
+}
+
+// vwapPlaces is the number of decimal places the VWAP of a candle keeps beyond
+// those of its prices, matching the lot decimals of Kraken volumes.
+const vwapPlaces = 8
+
+// candle aggregates trades into a set of OHLC data.
+type candle struct {
+	ohlc     OhlcData
+	notional Decimal // Sum of price * volume.
+	places   int32   // Decimal places of the most precise price.
+}
+
+// newCandle returns an empty candle starting at the given time.
+func newCandle(start time.Time) *candle {
+	return &candle{ohlc: OhlcData{Timestamp: start}}
+}
+
+// add adds a trade to the candle.
+func (c *candle) add(trade Trade) {
+	if c.ohlc.Count == 0 {
+		c.ohlc.Open, c.ohlc.High, c.ohlc.Low = trade.Price, trade.Price, trade.Price
+	}
+	if trade.Price.Cmp(c.ohlc.High) > 0 {
+		c.ohlc.High = trade.Price
+	}
+	if trade.Price.Cmp(c.ohlc.Low) < 0 {
+		c.ohlc.Low = trade.Price
+	}
+
+	c.ohlc.Close = trade.Price
+	c.ohlc.Volume = c.ohlc.Volume.Add(trade.Volume)
+	c.ohlc.Count++
+	c.notional = c.notional.Add(trade.Price.Mul(trade.Volume))
+
+	if places := trade.Price.Scale(); places > c.places {
+		c.places = places
+	}
+}
+
+// data returns the OHLC data of the candle.
+func (c *candle) data() OhlcData {
+	ohlc := c.ohlc
+	if !ohlc.Volume.IsZero() {
+		ohlc.Vwap = c.notional.Div(ohlc.Volume, c.places+vwapPlaces)
+	}
+
+	return ohlc
+}
@@ -0,0 +1,181 @@
+package gokraken
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// backfillStart is the start of the first candle in the backfill tests,
+// aligned to 3 minutes.
+const backfillStart = 1519999920
+
+// backfillTrades are the trades of BCHEUR served in the backfill tests. Each
+// trade is a price, volume and Unix time offset from backfillStart.
+var backfillTrades = [][3]string{
+	{"100", "1", "10"},
+	{"110", "2", "50"},
+	{"90", "1", "170"},
+	{"95.5", "0.5", "200"},
+	{"96", "1", "400"},
+	{"97", "1", "900"},
+}
+
+var backfillCandles = []OhlcData{
+	{
+		Timestamp: time.Unix(backfillStart, 0),
+		Open:      dec("100"),
+		High:      dec("110"),
+		Low:       dec("90"),
+		Close:     dec("90"),
+		Vwap:      dec("102.5"),
+		Volume:    dec("4"),
+		Count:     3,
+	},
+	{
+		Timestamp: time.Unix(backfillStart+180, 0),
+		Open:      dec("95.5"),
+		High:      dec("95.5"),
+		Low:       dec("95.5"),
+		Close:     dec("95.5"),
+		Vwap:      dec("95.5"),
+		Volume:    dec("0.5"),
+		Count:     1,
+	},
+	{
+		Timestamp: time.Unix(backfillStart+360, 0),
+		Open:      dec("96"),
+		High:      dec("96"),
+		Low:       dec("96"),
+		Close:     dec("96"),
+		Vwap:      dec("96"),
+		Volume:    dec("1"),
+		Count:     1,
+	},
+}
+
+func TestMarket_OhlcBackfill(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      OhlcBackfillRequest
+		expected []OhlcData
+		cursors  []int64
+		calls    int
+	}{
+		{
+			// The candle of the latest trade is not complete yet.
+			name:     "complete candles",
+			req:      OhlcBackfillRequest{Pair: pairs.BCHEUR, Interval: 3 * time.Minute},
+			expected: backfillCandles,
+			cursors:  []int64{int64(backfillStart+180)*int64(time.Second) - 1, int64(backfillStart+360)*int64(time.Second) - 1, int64(backfillStart+540)*int64(time.Second) - 1},
+			calls:    4,
+		},
+		{
+			name: "resume from cursor",
+			req: OhlcBackfillRequest{
+				Pair:     pairs.BCHEUR,
+				Interval: 3 * time.Minute,
+				Since:    int64(backfillStart+360)*int64(time.Second) - 1,
+			},
+			expected: backfillCandles[2:],
+			cursors:  []int64{int64(backfillStart+540)*int64(time.Second) - 1},
+			calls:    2,
+		},
+		{
+			name: "until",
+			req: OhlcBackfillRequest{
+				Pair:     pairs.BCHEUR,
+				Interval: 6 * time.Minute,
+				Until:    time.Unix(backfillStart+360, 0),
+			},
+			expected: []OhlcData{
+				{
+					Timestamp: time.Unix(backfillStart, 0),
+					Open:      dec("100"),
+					High:      dec("110"),
+					Low:       dec("90"),
+					Close:     dec("95.5"),
+					Vwap:      dec("101.722222222"),
+					Volume:    dec("4.5"),
+					Count:     4,
+				},
+			},
+			cursors: []int64{int64(backfillStart+360)*int64(time.Second) - 1},
+			calls:   3,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// The trades are served in pages of two.
+			var calls int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				body, _ := url.ParseQuery(string(b))
+				since, _ := strconv.ParseInt(body.Get("since"), 10, 64)
+
+				page := make([]interface{}, 0)
+				last := since
+				for _, trade := range backfillTrades {
+					ofs, _ := strconv.ParseInt(trade[2], 10, 64)
+					ns := (backfillStart + ofs) * int64(time.Second)
+					if ns <= since || len(page) == 2 {
+						continue
+					}
+
+					page = append(page, []interface{}{trade[0], trade[1], float64(ns) / 1e9, "b", "l", ""})
+					last = ns
+				}
+
+				calls++
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"error":  []string{},
+					"result": map[string]interface{}{"BCHEUR": page, "last": strconv.FormatInt(last, 10)},
+				})
+			}))
+
+			defer ts.Close()
+
+			k := New()
+			k.BaseURL = ts.URL
+
+			var candles []OhlcData
+			var cursors []int64
+			it := k.Market.OhlcBackfill(context.Background(), c.req)
+			for it.Next() {
+				candles = append(candles, it.Ohlc())
+				cursors = append(cursors, it.Cursor())
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.expected, candles, t)
+			assert(c.cursors, cursors, t)
+			assert(c.calls, calls, t)
+		})
+	}
+}
+
+func TestMarket_OhlcBackfillInterval(t *testing.T) {
+	k := New()
+
+	it := k.Market.OhlcBackfill(context.Background(), OhlcBackfillRequest{
+		Pair:     pairs.BCHEUR,
+		Interval: 1500 * time.Millisecond,
+	})
+
+	assert(false, it.Next(), t)
+	assert("invalid ohlc interval 1.5s: must be a whole number of seconds", it.Err().Error(), t)
+}
@@ -12,18 +12,18 @@ const (
 
 // BalanceResponse represents the response from the Balance endpoint of the
 // Kraken API.
-type BalanceResponse map[asset.Currency]float64
+type BalanceResponse map[asset.Currency]Decimal
 
 // TradeBalanceResponse represents the response from the TradeBalance endpoint of the
 // Kraken API.
 type TradeBalanceResponse struct {
-	EquivalentBalance float64 `json:"eb"`
-	TradeBalance      float64 `json:"tb"`
-	MarginAmount      float64 `json:"m"`
-	UnrealizedNet     float64 `json:"n"`
-	Cost              float64 `json:"c"`
-	Valuation         float64 `json:"v"`
-	Equity            float64 `json:"e"`
-	FreeMargin        float64 `json:"mf"`
-	MarginLevel       float64 `json:"ml"`
+	EquivalentBalance Decimal `json:"eb"`
+	TradeBalance      Decimal `json:"tb"`
+	MarginAmount      Decimal `json:"m"`
+	UnrealizedNet     Decimal `json:"n"`
+	Cost              Decimal `json:"c"`
+	Valuation         Decimal `json:"v"`
+	Equity            Decimal `json:"e"`
+	FreeMargin        Decimal `json:"mf"`
+	MarginLevel       Decimal `json:"ml"`
 }
@@ -0,0 +1,335 @@
+package gokraken
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// priceRule describes the forms a price of an order type may take.
+type priceRule struct {
+	absolute  bool   // Whether an absolute price is allowed.
+	relations string // Relations to the last traded price which are allowed.
+}
+
+var (
+	// anyPrice allows absolute and relative prices.
+	anyPrice = &priceRule{absolute: true, relations: relationAbove + relationBelow + relationAuto}
+
+	// trailingOffset allows an offset above the last traded price, which
+	// Kraken applies in the direction of the order.
+	trailingOffset = &priceRule{relations: relationAbove}
+
+	// trailingLimitOffset allows an offset of the limit price above or below
+	// the trigger price.
+	trailingLimitOffset = &priceRule{relations: relationAbove + relationBelow}
+
+	// orderPriceRules are the rules for the price and secondary price of each
+	// order type. A nil rule means the order type does not use the price.
+	orderPriceRules = map[OrderType][2]*priceRule{
+		OrderTypeMarket:            {nil, nil},
+		OrderTypeLimit:             {anyPrice, nil},
+		OrderTypeStopLoss:          {anyPrice, nil},
+		OrderTypeTakeProfit:        {anyPrice, nil},
+		OrderTypeStopLossLimit:     {anyPrice, anyPrice},
+		OrderTypeTakeProfitLimit:   {anyPrice, anyPrice},
+		OrderTypeTrailingStop:      {trailingOffset, nil},
+		OrderTypeTrailingStopLimit: {trailingOffset, trailingLimitOffset},
+		OrderTypeSettlePosition:    {nil, nil},
+	}
+)
+
+// OrderBuilder builds a UserOrder, setting the prices of the order as its
+// order type expects them. Invalid prices and impossible combinations of
+// options, such as a post only market order, are reported by Build.
+//
+//	order, err := gokraken.StopLossLimitOrder(pairs.XXBTZUSD, gokraken.TradeSell, volume,
+//		gokraken.PriceMinus(gokraken.MustParseDecimal("2")).Percent(),
+//		gokraken.PriceMinus(gokraken.MustParseDecimal("2.5")).Percent(),
+//	).ExpireIn(time.Hour).Build()
+type OrderBuilder struct {
+	order UserOrder
+	errs  ValidationError
+
+	// Whether the start and expiry times are relative to the time the order
+	// is placed.
+	startRelative  bool
+	expireRelative bool
+}
+
+// newOrderBuilder returns a builder of an order of the given type, with its
+// price and secondary price.
+func newOrderBuilder(pair pairs.AssetPair, side TradeBuySell, orderType OrderType, volume Decimal, price, price2 OrderPrice) *OrderBuilder {
+	b := &OrderBuilder{
+		order: UserOrder{
+			Pair:      pair,
+			Type:      side,
+			OrderType: orderType,
+			Volume:    volume,
+		},
+	}
+
+	if side != TradeBuy && side != TradeSell {
+		b.fail("Type", fmt.Sprintf("invalid side %q", side))
+	}
+
+	// Settling a position with a zero volume settles all of it.
+	if volume.Sign() < 0 || (volume.IsZero() && orderType != OrderTypeSettlePosition) {
+		b.fail("Volume", "must be greater than zero")
+	}
+
+	rules := orderPriceRules[orderType]
+	b.setPrice("Price", orderType, rules[0], price, &b.order.Price)
+	b.setPrice("Price2", orderType, rules[1], price2, &b.order.Price2)
+
+	return b
+}
+
+// MarketOrder returns a builder of an order filled at the best prices
+// available.
+func MarketOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeMarket, volume, OrderPrice{}, OrderPrice{})
+}
+
+// LimitOrder returns a builder of an order filled at the given price or
+// better.
+func LimitOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal, price OrderPrice) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeLimit, volume, price, OrderPrice{})
+}
+
+// StopLossOrder returns a builder of a market order placed when the price
+// moves against the order to the trigger price.
+func StopLossOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal, trigger OrderPrice) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeStopLoss, volume, trigger, OrderPrice{})
+}
+
+// TakeProfitOrder returns a builder of a market order placed when the price
+// moves in favour of the order to the trigger price.
+func TakeProfitOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal, trigger OrderPrice) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeTakeProfit, volume, trigger, OrderPrice{})
+}
+
+// StopLossLimitOrder returns a builder of a limit order at the limit price,
+// placed when the price moves against the order to the trigger price.
+func StopLossLimitOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal, trigger, limit OrderPrice) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeStopLossLimit, volume, trigger, limit)
+}
+
+// TakeProfitLimitOrder returns a builder of a limit order at the limit price,
+// placed when the price moves in favour of the order to the trigger price.
+func TakeProfitLimitOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal, trigger, limit OrderPrice) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeTakeProfitLimit, volume, trigger, limit)
+}
+
+// TrailingStopOrder returns a builder of a market order placed when the price
+// moves against the order by the offset from its best price since the order
+// was placed. The offset must be a PricePlus, which Kraken applies in the
+// direction of the order.
+func TrailingStopOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal, offset OrderPrice) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeTrailingStop, volume, offset, OrderPrice{})
+}
+
+// TrailingStopLimitOrder returns a builder of a trailing stop which places a
+// limit order at the limit offset from the trigger price. The offset must be
+// a PricePlus and the limit offset a PricePlus or PriceMinus.
+func TrailingStopLimitOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal, offset, limitOffset OrderPrice) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeTrailingStopLimit, volume, offset, limitOffset)
+}
+
+// SettlePositionOrder returns a builder of an order which settles an open
+// position of the given volume, or all of it if the volume is zero. The
+// leverage of the position must be set with WithLeverage.
+func SettlePositionOrder(pair pairs.AssetPair, side TradeBuySell, volume Decimal) *OrderBuilder {
+	return newOrderBuilder(pair, side, OrderTypeSettlePosition, volume, OrderPrice{}, OrderPrice{})
+}
+
+// WithCloseOrder adds a conditional close order of the given type, which is
+// placed when the order is filled. Unused prices are left unset.
+func (b *OrderBuilder) WithCloseOrder(orderType OrderType, price, price2 OrderPrice) *OrderBuilder {
+	rules, ok := orderPriceRules[orderType]
+	if !ok || orderType == OrderTypeMarket || orderType == OrderTypeSettlePosition {
+		b.fail("CloseOrderType", fmt.Sprintf("%s orders cannot close an order", orderType))
+		return b
+	}
+
+	b.order.CloseOrderType = orderType
+	b.setPrice("ClosedOrderPrice", orderType, rules[0], price, &b.order.ClosedOrderPrice)
+	b.setPrice("ClosedOrderPrice2", orderType, rules[1], price2, &b.order.ClosedOrderPrice2)
+
+	return b
+}
+
+// PostOnly makes a limit order post only, so that it is cancelled rather than
+// filled immediately as a taker.
+func (b *OrderBuilder) PostOnly() *OrderBuilder {
+	return b.withFlag(OrderFlagPost)
+}
+
+// VolumeInQuote makes the volume of the order in the quote currency of the
+// pair. It is not available for leveraged orders.
+func (b *OrderBuilder) VolumeInQuote() *OrderBuilder {
+	return b.withFlag(OrderFlagViqc)
+}
+
+// FeeInBase prefers fees in the base currency of the pair.
+func (b *OrderBuilder) FeeInBase() *OrderBuilder {
+	return b.withFlag(OrderFlagFcib)
+}
+
+// FeeInQuote prefers fees in the quote currency of the pair.
+func (b *OrderBuilder) FeeInQuote() *OrderBuilder {
+	return b.withFlag(OrderFlagFciq)
+}
+
+// WithLeverage sets the leverage of the order, such as "2" or "2:1".
+func (b *OrderBuilder) WithLeverage(leverage string) *OrderBuilder {
+	b.order.Leverage = leverage
+	return b
+}
+
+// WithUserRef sets the user reference id of the order.
+func (b *OrderBuilder) WithUserRef(userRef int) *OrderBuilder {
+	b.order.UserRef = userRef
+	return b
+}
+
+// StartAt schedules the order to start at the given time.
+func (b *OrderBuilder) StartAt(t time.Time) *OrderBuilder {
+	b.startRelative = false
+	b.order.StartTm = strconv.FormatInt(t.Unix(), 10)
+	return b
+}
+
+// StartIn schedules the order to start after the given whole number of
+// seconds.
+func (b *OrderBuilder) StartIn(d time.Duration) *OrderBuilder {
+	b.startRelative = true
+	b.order.StartTm = b.offset("StartTm", d)
+	return b
+}
+
+// ExpireAt makes the order expire at the given time.
+func (b *OrderBuilder) ExpireAt(t time.Time) *OrderBuilder {
+	b.expireRelative = false
+	b.order.ExpireTm = strconv.FormatInt(t.Unix(), 10)
+	return b
+}
+
+// ExpireIn makes the order expire after the given whole number of seconds.
+func (b *OrderBuilder) ExpireIn(d time.Duration) *OrderBuilder {
+	b.expireRelative = true
+	b.order.ExpireTm = b.offset("ExpireTm", d)
+	return b
+}
+
+// Validate makes Kraken only validate the order, without placing it.
+func (b *OrderBuilder) Validate() *OrderBuilder {
+	b.order.Validate = true
+	return b
+}
+
+// Build returns the order. If the order is invalid, the error is a
+// ValidationError listing every problem found.
+func (b *OrderBuilder) Build() (UserOrder, error) {
+	errs := append(ValidationError(nil), b.errs...)
+	order := b.order
+	order.OFlags = append([]OrderFlag(nil), b.order.OFlags...)
+
+	if b.hasFlag(OrderFlagPost) && order.OrderType != OrderTypeLimit {
+		errs = append(errs, FieldError{Field: "OFlags", Message: fmt.Sprintf("%s orders cannot be post only", order.OrderType)})
+	}
+
+	if b.hasFlag(OrderFlagFcib) && b.hasFlag(OrderFlagFciq) {
+		errs = append(errs, FieldError{Field: "OFlags", Message: "fees cannot be preferred in both the base and quote currency"})
+	}
+
+	leveraged := order.Leverage != "" && order.Leverage != "none"
+	if leveraged && b.hasFlag(OrderFlagViqc) {
+		errs = append(errs, FieldError{Field: "OFlags", Message: "volume in quote currency is not available for leveraged orders"})
+	}
+
+	if order.OrderType == OrderTypeSettlePosition && !leveraged {
+		errs = append(errs, FieldError{Field: "Leverage", Message: "settle-position orders must have the leverage of the position"})
+	}
+
+	// Times of the same form can be compared without knowing the current time.
+	scheduled := order.StartTm != "" && order.ExpireTm != "" && b.startRelative == b.expireRelative
+	if scheduled && !b.after(order.ExpireTm, order.StartTm) {
+		errs = append(errs, FieldError{Field: "ExpireTm", Message: "must be after StartTm"})
+	}
+
+	if len(errs) > 0 {
+		return order, errs
+	}
+
+	return order, nil
+}
+
+// setPrice sets a price of the order if it is allowed by the rule of the order
+// type.
+func (b *OrderBuilder) setPrice(field string, orderType OrderType, rule *priceRule, price OrderPrice, dst *OrderPrice) {
+	switch {
+	case rule == nil && price.IsZero():
+	case rule == nil:
+		b.fail(field, fmt.Sprintf("not used by %s orders", orderType))
+	case price.IsZero():
+		b.fail(field, fmt.Sprintf("required by %s orders", orderType))
+	case price.amount.Sign() < 0:
+		b.fail(field, fmt.Sprintf("%s must not be negative", price.amount))
+	case !price.IsRelative() && price.IsPercent():
+		b.fail(field, fmt.Sprintf("%s must be relative to the last traded price to be a percentage", price))
+	case !price.IsRelative() && !rule.absolute:
+		b.fail(field, fmt.Sprintf("%s orders must have a price relative to the last traded price, not %s", orderType, price))
+	case price.IsRelative() && !strings.Contains(rule.relations, price.relation):
+		prefixes := strings.Join(strings.Split(rule.relations, ""), " or ")
+		b.fail(field, fmt.Sprintf("%s orders must have a price with a %s prefix, not %s", orderType, prefixes, price))
+	default:
+		*dst = price
+	}
+}
+
+// offset formats a duration as a number of seconds from now.
+func (b *OrderBuilder) offset(field string, d time.Duration) string {
+	if d < time.Second || d%time.Second != 0 {
+		b.fail(field, fmt.Sprintf("%s must be a whole number of seconds", d))
+	}
+
+	return "+" + strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// after returns whether the first of two times in the same form is after the
+// second.
+func (b *OrderBuilder) after(t1, t2 string) bool {
+	n1, _ := strconv.ParseInt(strings.TrimPrefix(t1, "+"), 10, 64)
+	n2, _ := strconv.ParseInt(strings.TrimPrefix(t2, "+"), 10, 64)
+
+	return n1 > n2
+}
+
+// withFlag adds an order flag, unless the order already has it.
+func (b *OrderBuilder) withFlag(flag OrderFlag) *OrderBuilder {
+	if !b.hasFlag(flag) {
+		b.order.OFlags = append(b.order.OFlags, flag)
+	}
+
+	return b
+}
+
+// hasFlag returns whether the order has an order flag.
+func (b *OrderBuilder) hasFlag(flag OrderFlag) bool {
+	for _, f := range b.order.OFlags {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fail records an invalid field of the order.
+func (b *OrderBuilder) fail(field, message string) {
+	b.errs = append(b.errs, FieldError{Field: field, Message: message})
+}
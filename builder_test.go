@@ -0,0 +1,177 @@
+package gokraken
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+func TestOrderBuilder_Build(t *testing.T) {
+	start := time.Unix(1617000000, 0)
+
+	cases := []struct {
+		name     string
+		builder  *OrderBuilder
+		expected url.Values
+	}{
+		{
+			name:    "market",
+			builder: MarketOrder(pairs.XXBTZUSD, TradeBuy, dec("0.5")).FeeInQuote().WithUserRef(42),
+			expected: url.Values{
+				"pair": {"XXBTZUSD"}, "type": {"buy"}, "ordertype": {"market"}, "volume": {"0.5"},
+				"oflags": {"fciq"}, "userref": {"42"},
+			},
+		},
+		{
+			name:    "post only limit",
+			builder: LimitOrder(pairs.XXBTZUSD, TradeSell, dec("0.5"), PriceAt(dec("30000.5"))).PostOnly().PostOnly().ExpireIn(time.Hour),
+			expected: url.Values{
+				"pair": {"XXBTZUSD"}, "type": {"sell"}, "ordertype": {"limit"}, "volume": {"0.5"},
+				"price": {"30000.5"}, "oflags": {"post"}, "expiretm": {"+3600"},
+			},
+		},
+		{
+			name: "stop loss limit",
+			builder: StopLossLimitOrder(pairs.XXBTZUSD, TradeSell, dec("1"), PriceMinus(dec("2")).Percent(), PriceMinus(dec("2.5")).Percent()).
+				StartAt(start).ExpireAt(start.Add(time.Minute)),
+			expected: url.Values{
+				"pair": {"XXBTZUSD"}, "type": {"sell"}, "ordertype": {"stop-loss-limit"}, "volume": {"1"},
+				"price": {"-2%"}, "price2": {"-2.5%"}, "starttm": {"1617000000"}, "expiretm": {"1617000060"},
+			},
+		},
+		{
+			name:    "trailing stop limit",
+			builder: TrailingStopLimitOrder(pairs.XXBTZUSD, TradeBuy, dec("1"), PricePlus(dec("100")), PriceMinus(dec("5"))),
+			expected: url.Values{
+				"pair": {"XXBTZUSD"}, "type": {"buy"}, "ordertype": {"trailing-stop-limit"}, "volume": {"1"},
+				"price": {"+100"}, "price2": {"-5"},
+			},
+		},
+		{
+			name: "limit with close order",
+			builder: LimitOrder(pairs.XXBTZUSD, TradeBuy, dec("1"), PriceOffset(dec("10"))).
+				WithCloseOrder(OrderTypeStopLossLimit, PriceAt(dec("29000")), PriceAt(dec("28900"))).
+				Validate(),
+			expected: url.Values{
+				"pair": {"XXBTZUSD"}, "type": {"buy"}, "ordertype": {"limit"}, "volume": {"1"},
+				"price": {"#10"}, "close[ordertype]": {"stop-loss-limit"}, "close[price]": {"29000"},
+				"close[price2]": {"28900"}, "validate": {"true"},
+			},
+		},
+		{
+			name:    "settle all of a position",
+			builder: SettlePositionOrder(pairs.XXBTZUSD, TradeSell, dec("0")).WithLeverage("2"),
+			expected: url.Values{
+				"pair": {"XXBTZUSD"}, "type": {"sell"}, "ordertype": {"settle-position"}, "volume": {"0"},
+				"leverage": {"2"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			order, err := c.builder.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.expected, order.Values(), t)
+		})
+	}
+}
+
+func TestOrderBuilder_BuildInvalid(t *testing.T) {
+	cases := []struct {
+		name     string
+		builder  *OrderBuilder
+		expected ValidationError
+	}{
+		{
+			name:     "no volume",
+			builder:  MarketOrder(pairs.XXBTZUSD, TradeBuy, dec("0")),
+			expected: ValidationError{{Field: "Volume", Message: "must be greater than zero"}},
+		},
+		{
+			name:     "invalid side",
+			builder:  MarketOrder(pairs.XXBTZUSD, "hold", dec("1")),
+			expected: ValidationError{{Field: "Type", Message: `invalid side "hold"`}},
+		},
+		{
+			name:     "no price",
+			builder:  LimitOrder(pairs.XXBTZUSD, TradeBuy, dec("1"), OrderPrice{}),
+			expected: ValidationError{{Field: "Price", Message: "required by limit orders"}},
+		},
+		{
+			name:     "absolute percentage",
+			builder:  LimitOrder(pairs.XXBTZUSD, TradeBuy, dec("1"), PriceAt(dec("2")).Percent()),
+			expected: ValidationError{{Field: "Price", Message: "2% must be relative to the last traded price to be a percentage"}},
+		},
+		{
+			name:     "negative price",
+			builder:  StopLossOrder(pairs.XXBTZUSD, TradeSell, dec("1"), PriceMinus(dec("-5"))),
+			expected: ValidationError{{Field: "Price", Message: "-5 must not be negative"}},
+		},
+		{
+			name:     "absolute trailing stop",
+			builder:  TrailingStopOrder(pairs.XXBTZUSD, TradeSell, dec("1"), PriceAt(dec("29000"))),
+			expected: ValidationError{{Field: "Price", Message: "trailing-stop orders must have a price relative to the last traded price, not 29000"}},
+		},
+		{
+			name:    "trailing stop below",
+			builder: TrailingStopLimitOrder(pairs.XXBTZUSD, TradeSell, dec("1"), PriceMinus(dec("100")), PriceOffset(dec("5"))),
+			expected: ValidationError{
+				{Field: "Price", Message: "trailing-stop-limit orders must have a price with a + prefix, not -100"},
+				{Field: "Price2", Message: "trailing-stop-limit orders must have a price with a + or - prefix, not #5"},
+			},
+		},
+		{
+			name:     "post only market",
+			builder:  MarketOrder(pairs.XXBTZUSD, TradeBuy, dec("1")).PostOnly(),
+			expected: ValidationError{{Field: "OFlags", Message: "market orders cannot be post only"}},
+		},
+		{
+			name:     "fees in both currencies",
+			builder:  MarketOrder(pairs.XXBTZUSD, TradeBuy, dec("1")).FeeInBase().FeeInQuote(),
+			expected: ValidationError{{Field: "OFlags", Message: "fees cannot be preferred in both the base and quote currency"}},
+		},
+		{
+			name:     "leveraged volume in quote",
+			builder:  MarketOrder(pairs.XXBTZUSD, TradeBuy, dec("100")).VolumeInQuote().WithLeverage("2"),
+			expected: ValidationError{{Field: "OFlags", Message: "volume in quote currency is not available for leveraged orders"}},
+		},
+		{
+			name:     "settle position without leverage",
+			builder:  SettlePositionOrder(pairs.XXBTZUSD, TradeSell, dec("1")),
+			expected: ValidationError{{Field: "Leverage", Message: "settle-position orders must have the leverage of the position"}},
+		},
+		{
+			name:     "market close order",
+			builder:  LimitOrder(pairs.XXBTZUSD, TradeBuy, dec("1"), PriceAt(dec("30000"))).WithCloseOrder(OrderTypeMarket, OrderPrice{}, OrderPrice{}),
+			expected: ValidationError{{Field: "CloseOrderType", Message: "market orders cannot close an order"}},
+		},
+		{
+			name:     "unused close price",
+			builder:  LimitOrder(pairs.XXBTZUSD, TradeBuy, dec("1"), PriceAt(dec("30000"))).WithCloseOrder(OrderTypeLimit, PriceAt(dec("31000")), PriceAt(dec("31500"))),
+			expected: ValidationError{{Field: "ClosedOrderPrice2", Message: "not used by limit orders"}},
+		},
+		{
+			name:     "fractional expiry",
+			builder:  MarketOrder(pairs.XXBTZUSD, TradeBuy, dec("1")).ExpireIn(1500 * time.Millisecond),
+			expected: ValidationError{{Field: "ExpireTm", Message: "1.5s must be a whole number of seconds"}},
+		},
+		{
+			name:     "expires before start",
+			builder:  MarketOrder(pairs.XXBTZUSD, TradeBuy, dec("1")).StartIn(time.Hour).ExpireIn(time.Minute),
+			expected: ValidationError{{Field: "ExpireTm", Message: "must be after StartTm"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.builder.Build()
+			assert(c.expected, err, t)
+		})
+	}
+}
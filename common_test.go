@@ -1,14 +1,11 @@
 package gokraken
 
-import (
-	"reflect"
-	"testing"
-)
+import "github.com/danmrichards/gokraken/internal/testutil"
 
 // Test helper for asserting values are equal.
-func assert(expected, actual interface{}, t *testing.T) {
-	t.Helper()
-	if !reflect.DeepEqual(expected, actual) {
-		t.Fatalf("%s: expected: %#[2]v (%[2]T), but got %#[3]v (%[3]T)", t.Name(), expected, actual)
-	}
+var assert = testutil.Assert
+
+// Test helper for creating decimal values.
+func dec(s string) Decimal {
+	return MustParseDecimal(s)
 }
@@ -0,0 +1,154 @@
+package gokraken
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// missedBufferSize is the size of the buffer of missed refreshes.
+const missedBufferSize = 16
+
+// MissedRefresh reports a failed refresh of a dead man's switch.
+type MissedRefresh struct {
+	Time        time.Time // Time the refresh failed.
+	Err         error     // Error which failed the refresh.
+	TriggerTime time.Time // Time at which orders will be cancelled unless a later refresh succeeds.
+}
+
+// DeadMansSwitch keeps a CancelAllOrdersAfter timer running while the process
+// is healthy, refreshing it at an interval shorter than its timeout. If the
+// process crashes or loses its connection to Kraken, the timer runs out and
+// Kraken cancels all open orders.
+type DeadMansSwitch struct {
+	trading  *Trading
+	timeout  time.Duration
+	interval time.Duration
+
+	missed chan MissedRefresh
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	mu      sync.Mutex
+	trigger time.Time
+}
+
+// StartDeadMansSwitch arms a CancelAllOrdersAfter timer with the given timeout
+// and starts a goroutine which refreshes it at the given interval, or a
+// quarter of the timeout if the interval is zero.
+//
+// The goroutine stops when the context is done, leaving the timer to run out,
+// or when the switch is stopped, which disables the timer.
+func (t *Trading) StartDeadMansSwitch(ctx context.Context, timeout, interval time.Duration) (*DeadMansSwitch, error) {
+	if interval == 0 {
+		interval = timeout / 4
+	}
+
+	if timeout < time.Second || interval <= 0 || interval >= timeout {
+		return nil, fmt.Errorf("invalid dead man's switch: interval %s must be shorter than timeout %s", interval, timeout)
+	}
+
+	res, err := t.CancelAllOrdersAfter(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DeadMansSwitch{
+		trading:  t,
+		timeout:  timeout,
+		interval: interval,
+		missed:   make(chan MissedRefresh, missedBufferSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		trigger:  res.TriggerTime,
+	}
+
+	go d.run(ctx)
+
+	return d, nil
+}
+
+// run refreshes the timer until the context is done or the switch is stopped.
+func (d *DeadMansSwitch) run(ctx context.Context) {
+	defer close(d.done)
+	defer close(d.missed)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+
+		d.refresh(ctx)
+	}
+}
+
+// refresh extends the timer. A refresh which takes longer than the interval
+// is abandoned, so that the next one is not delayed.
+func (d *DeadMansSwitch) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, d.interval)
+	defer cancel()
+
+	res, err := d.trading.CancelAllOrdersAfter(ctx, d.timeout)
+	if err != nil {
+		// Drop the report rather than block if the reader has fallen behind.
+		select {
+		case d.missed <- MissedRefresh{Time: time.Now(), Err: err, TriggerTime: d.TriggerTime()}:
+		default:
+		}
+		return
+	}
+
+	d.mu.Lock()
+	d.trigger = res.TriggerTime
+	d.mu.Unlock()
+}
+
+// Missed returns a channel of the refreshes which failed. It is closed when
+// the switch stops. Reports are dropped if the channel is not read.
+func (d *DeadMansSwitch) Missed() <-chan MissedRefresh {
+	return d.missed
+}
+
+// Done returns a channel which is closed when the switch stops refreshing the
+// timer.
+func (d *DeadMansSwitch) Done() <-chan struct{} {
+	return d.done
+}
+
+// TriggerTime returns the time at which Kraken will cancel all open orders,
+// as set by the last successful refresh.
+func (d *DeadMansSwitch) TriggerTime() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.trigger
+}
+
+// Stop stops refreshing the timer and disables it, so that open orders are
+// left in place.
+func (d *DeadMansSwitch) Stop(ctx context.Context) error {
+	d.once.Do(func() {
+		close(d.stop)
+	})
+	<-d.done
+
+	res, err := d.trading.CancelAllOrdersAfter(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.trigger = res.TriggerTime
+	d.mu.Unlock()
+
+	return nil
+}
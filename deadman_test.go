@@ -0,0 +1,122 @@
+package gokraken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrading_DeadMansSwitch(t *testing.T) {
+	// The second and third refreshes fail.
+	var mu sync.Mutex
+	var timeouts []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ := url.ParseQuery(string(b))
+
+		mu.Lock()
+		timeouts = append(timeouts, body.Get("timeout"))
+		call := len(timeouts)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch {
+		case call == 2 || call == 3:
+			w.Write([]byte(`{"error":["EService:Unavailable"]}`))
+		case body.Get("timeout") == "0":
+			w.Write([]byte(`{"error":[],"result":{"currentTime":"2021-03-24T17:41:56Z","triggerTime":"0"}}`))
+		default:
+			trigger := time.Date(2021, 3, 24, 17, 42, call, 0, time.UTC).Format(time.RFC3339)
+			fmt.Fprintf(w, `{"error":[],"result":{"currentTime":"2021-03-24T17:41:56Z","triggerTime":"%s"}}`, trigger)
+		}
+	}))
+
+	defer ts.Close()
+
+	calls := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), timeouts...)
+	}
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	d, err := k.Trading.StartDeadMansSwitch(context.Background(), time.Minute, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(time.Date(2021, 3, 24, 17, 42, 1, 0, time.UTC), d.TriggerTime(), t)
+
+	// Failed refreshes report the trigger time of the last successful one.
+	for i := 0; i < 2; i++ {
+		missed := <-d.Missed()
+		assert(true, errors.Is(missed.Err, ErrServiceUnavailable), t)
+		assert(time.Date(2021, 3, 24, 17, 42, 1, 0, time.UTC), missed.TriggerTime, t)
+	}
+
+	for len(calls()) < 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err = d.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	made := calls()
+	assert("60", made[0], t)
+	assert("0", made[len(made)-1], t)
+	assert(time.Time{}, d.TriggerTime(), t)
+
+	_, open := <-d.Missed()
+	assert(false, open, t)
+}
+
+func TestTrading_DeadMansSwitchContext(t *testing.T) {
+	var timeouts []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ := url.ParseQuery(string(b))
+		timeouts = append(timeouts, body.Get("timeout"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"currentTime":"2021-03-24T17:41:56Z","triggerTime":"2021-03-24T17:42:01Z"}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d, err := k.Trading.StartDeadMansSwitch(ctx, time.Minute, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancelling the context stops refreshing, leaving the timer armed.
+	cancel()
+	<-d.Done()
+
+	assert([]string{"60"}, timeouts, t)
+	assert(time.Date(2021, 3, 24, 17, 42, 1, 0, time.UTC), d.TriggerTime(), t)
+}
+
+func TestTrading_DeadMansSwitchInterval(t *testing.T) {
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+
+	_, err := k.Trading.StartDeadMansSwitch(context.Background(), time.Minute, time.Minute)
+	assert("invalid dead man's switch: interval 1m0s must be shorter than timeout 1m0s", err.Error(), t)
+}
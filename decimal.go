@@ -0,0 +1,362 @@
+package gokraken
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxParseScale bounds the exponent and number of decimal places of parsed
+// decimals, so that untrusted input cannot make them allocate huge numbers.
+const maxParseScale = 64
+
+var (
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+// Decimal is an exact decimal number, used for prices, volumes and balances.
+//
+// Kraken sends these values as strings with up to 10 decimal places, which
+// cannot be represented exactly by a float64. The zero value is 0. Decimals
+// are immutable; arithmetic methods return a new value.
+type Decimal struct {
+	unscaled *big.Int // Value without the decimal point, nil for zero.
+	scale    int32    // Number of decimal places.
+}
+
+// NewDecimal returns the decimal value * 10^exp, e.g. NewDecimal(123, -2)
+// is 1.23.
+func NewDecimal(value int64, exp int32) Decimal {
+	if exp >= 0 {
+		unscaled := new(big.Int).Mul(big.NewInt(value), pow10(exp))
+		return newDecimal(unscaled, 0)
+	}
+
+	return newDecimal(big.NewInt(value), -exp)
+}
+
+// NewDecimalFromFloat returns the decimal with the shortest representation
+// that converts back to f.
+func NewDecimalFromFloat(f float64) Decimal {
+	d, _ := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+// ParseDecimals parses strings into decimals, such as the fields of the arrays
+// returned by Kraken. It returns an error if there are fewer strings than
+// destinations.
+func ParseDecimals(strs []string, dsts ...*Decimal) (err error) {
+	if len(strs) < len(dsts) {
+		return fmt.Errorf("expected %d values, got %d", len(dsts), len(strs))
+	}
+
+	for i, dst := range dsts {
+		if *dst, err = ParseDecimal(strs[i]); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ParseDecimal parses a decimal number such as "1.23", "-0.5" or "1e-8". The
+// exponent and the number of decimal places after applying it must be within
+// ±64.
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+
+	var exp int64
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(str[i+1:], 10, 32); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		str = str[:i]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+
+	digits := intPart + fracPart
+	if digits == "" || digits == "-" || digits == "+" || strings.ContainsAny(digits[1:], "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	scale := int64(len(fracPart)) - exp
+	if exp < -maxParseScale || exp > maxParseScale || scale < -maxParseScale || scale > maxParseScale {
+		return Decimal{}, fmt.Errorf("decimal %q is out of range", s)
+	}
+
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(int32(-scale)))
+		scale = 0
+	}
+
+	return newDecimal(unscaled, int32(scale)), nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a valid
+// decimal. It simplifies initialisation of constant values.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// newDecimal returns a normalised decimal, without trailing zeros after the
+// decimal point, so that equal values have equal representations.
+func newDecimal(unscaled *big.Int, scale int32) Decimal {
+	if unscaled.Sign() == 0 {
+		return Decimal{}
+	}
+
+	q, r := new(big.Int), new(big.Int)
+	for scale > 0 {
+		q.QuoRem(unscaled, bigTen, r)
+		if r.Sign() != 0 {
+			break
+		}
+
+		unscaled = new(big.Int).Set(q)
+		scale--
+	}
+
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// pow10 returns 10^n.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// value returns the unscaled value of d.
+func (d Decimal) value() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+
+	return d.unscaled
+}
+
+// rescale returns the unscaled value of d with the given number of decimal
+// places, which must not be less than the scale of d.
+func (d Decimal) rescale(scale int32) *big.Int {
+	return new(big.Int).Mul(d.value(), pow10(scale-d.scale))
+}
+
+// align returns the unscaled values of d and d2 with a common scale.
+func (d Decimal) align(d2 Decimal) (a, b *big.Int, scale int32) {
+	scale = d.scale
+	if d2.scale > scale {
+		scale = d2.scale
+	}
+
+	return d.rescale(scale), d2.rescale(scale), scale
+}
+
+// Add returns d + d2.
+func (d Decimal) Add(d2 Decimal) Decimal {
+	a, b, scale := d.align(d2)
+	return newDecimal(a.Add(a, b), scale)
+}
+
+// Sub returns d - d2.
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	a, b, scale := d.align(d2)
+	return newDecimal(a.Sub(a, b), scale)
+}
+
+// Mul returns d * d2.
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return newDecimal(new(big.Int).Mul(d.value(), d2.value()), d.scale+d2.scale)
+}
+
+// Div returns d / d2 rounded half away from zero to the given number of
+// decimal places. It panics if d2 is zero.
+func (d Decimal) Div(d2 Decimal, places int32) Decimal {
+	if d2.IsZero() {
+		panic("gokraken: decimal division by zero")
+	}
+
+	// d / d2 * 10^places = (a * 10^-sa) / (b * 10^-sb) * 10^places.
+	a, b := new(big.Int).Set(d.value()), new(big.Int).Set(d2.value())
+	if exp := places + d2.scale - d.scale; exp >= 0 {
+		a.Mul(a, pow10(exp))
+	} else {
+		b.Mul(b, pow10(-exp))
+	}
+
+	return newDecimal(quoRound(a, b), places)
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return newDecimal(new(big.Int).Neg(d.value()), d.scale)
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	return newDecimal(new(big.Int).Abs(d.value()), d.scale)
+}
+
+// Round returns d rounded half away from zero to the given number of decimal
+// places.
+func (d Decimal) Round(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+
+	if d.scale <= places {
+		return d
+	}
+
+	return newDecimal(quoRound(d.value(), pow10(d.scale-places)), places)
+}
+
+// Truncate returns d rounded towards zero to the given number of decimal
+// places.
+func (d Decimal) Truncate(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+
+	if d.scale <= places {
+		return d
+	}
+
+	return newDecimal(new(big.Int).Quo(d.value(), pow10(d.scale-places)), places)
+}
+
+// quoRound returns a / b rounded half away from zero.
+func quoRound(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+
+	// Round away from zero if |2r| >= |b|.
+	r.Abs(r).Lsh(r, 1)
+	if r.CmpAbs(b) >= 0 {
+		if a.Sign() == b.Sign() {
+			q.Add(q, bigOne)
+		} else {
+			q.Sub(q, bigOne)
+		}
+	}
+
+	return q
+}
+
+// Cmp compares d and d2 and returns -1 if d < d2, 0 if they are equal and +1
+// if d > d2.
+func (d Decimal) Cmp(d2 Decimal) int {
+	a, b, _ := d.align(d2)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and d2 are equal.
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+// Sign returns -1 if d < 0, 0 if d is zero and +1 if d > 0.
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+// IsZero reports whether d is zero.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Scale returns the number of decimal places of d, ignoring trailing zeros.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns d with as many decimal places as required, e.g. "1.23".
+func (d Decimal) String() string {
+	return d.format(d.scale)
+}
+
+// StringFixed returns d rounded to the given number of decimal places,
+// padding with zeros if required, e.g. "1.2300" for 4 places.
+func (d Decimal) StringFixed(places int32) string {
+	if places < 0 {
+		places = 0
+	}
+
+	return d.Round(places).format(places)
+}
+
+// format formats d with the given number of decimal places, which must not be
+// less than its scale.
+func (d Decimal) format(places int32) string {
+	digits := new(big.Int).Abs(d.rescale(places)).String()
+
+	if pad := int(places) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	var buf bytes.Buffer
+	if d.Sign() < 0 {
+		buf.WriteByte('-')
+	}
+
+	point := len(digits) - int(places)
+	buf.WriteString(digits[:point])
+	if places > 0 {
+		buf.WriteByte('.')
+		buf.WriteString(digits[point:])
+	}
+
+	return buf.String()
+}
+
+// MarshalJSON encodes d as a JSON string, as Kraken does.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes a decimal from a JSON string or number. Null and
+// empty strings decode as zero.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	if s == "" {
+		*d = Decimal{}
+		return nil
+	}
+
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,129 @@
+package gokraken
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+		scale    int32
+	}{
+		{input: "0", expected: "0", scale: 0},
+		{input: "1.23", expected: "1.23", scale: 2},
+		{input: "5541.30000", expected: "5541.3", scale: 1},
+		{input: "0.00000500", expected: "0.000005", scale: 6},
+		{input: "-0.5", expected: "-0.5", scale: 1},
+		{input: "+12", expected: "12", scale: 0},
+		{input: ".5", expected: "0.5", scale: 1},
+		{input: "1e-8", expected: "0.00000001", scale: 8},
+		{input: "1.5E3", expected: "1500", scale: 0},
+		{input: "1e64", expected: "1" + strings.Repeat("0", 64), scale: 0},
+		{input: "123456789012345678901234567890.123456789", expected: "123456789012345678901234567890.123456789", scale: 9},
+	}
+	for _, c := range cases {
+		d, err := ParseDecimal(c.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(c.expected, d.String(), t)
+		assert(c.scale, d.Scale(), t)
+	}
+
+	for _, input := range []string{"", ".", "-", "abc", "1.2.3", "1-2", "1e", "1e2000000000", "1e-65", "0.5e-64", "1e65"} {
+		if _, err := ParseDecimal(input); err == nil {
+			t.Fatalf("%s: expected error for %q", t.Name(), input)
+		}
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a := MustParseDecimal("0.1")
+	b := MustParseDecimal("0.2")
+
+	assert(MustParseDecimal("0.3"), a.Add(b), t)
+	assert(MustParseDecimal("-0.1"), a.Sub(b), t)
+	assert(MustParseDecimal("0.02"), a.Mul(b), t)
+	assert(MustParseDecimal("0.5"), a.Div(b, 8), t)
+	assert(MustParseDecimal("0.33333333"), a.Div(MustParseDecimal("0.3"), 8), t)
+	assert(MustParseDecimal("-0.66666667"), MustParseDecimal("-2").Div(MustParseDecimal("3"), 8), t)
+	assert(MustParseDecimal("0.1"), b.Neg().Add(MustParseDecimal("0.3")), t)
+	assert(MustParseDecimal("0.2"), b.Neg().Abs(), t)
+	assert(Decimal{}, a.Sub(a), t)
+	assert(NewDecimal(123, -2), MustParseDecimal("1.23"), t)
+	assert(NewDecimal(5, 3), MustParseDecimal("5000"), t)
+	assert(NewDecimalFromFloat(0.1), a, t)
+}
+
+func TestDecimal_Round(t *testing.T) {
+	cases := []struct {
+		input    string
+		places   int32
+		round    string
+		truncate string
+		fixed    string
+	}{
+		{input: "1.2345", places: 2, round: "1.23", truncate: "1.23", fixed: "1.23"},
+		{input: "1.235", places: 2, round: "1.24", truncate: "1.23", fixed: "1.24"},
+		{input: "-1.235", places: 2, round: "-1.24", truncate: "-1.23", fixed: "-1.24"},
+		{input: "0.123456789", places: 8, round: "0.12345679", truncate: "0.12345678", fixed: "0.12345679"},
+		{input: "1.5", places: 0, round: "2", truncate: "1", fixed: "2"},
+		{input: "1.2", places: 4, round: "1.2", truncate: "1.2", fixed: "1.2000"},
+		{input: "0.004", places: 2, round: "0", truncate: "0", fixed: "0.00"},
+	}
+	for _, c := range cases {
+		d := MustParseDecimal(c.input)
+
+		assert(c.round, d.Round(c.places).String(), t)
+		assert(c.truncate, d.Truncate(c.places).String(), t)
+		assert(c.fixed, d.StringFixed(c.places), t)
+	}
+}
+
+func TestDecimal_Cmp(t *testing.T) {
+	a := MustParseDecimal("1.10")
+	b := MustParseDecimal("1.1")
+	c := MustParseDecimal("1.09")
+
+	assert(0, a.Cmp(b), t)
+	assert(1, a.Cmp(c), t)
+	assert(-1, c.Cmp(a), t)
+	assert(true, a.Equal(b), t)
+	assert(true, Decimal{}.IsZero(), t)
+	assert(-1, c.Neg().Sign(), t)
+	assert(1.1, a.Float64(), t)
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	var v struct {
+		String Decimal `json:"string"`
+		Number Decimal `json:"number"`
+		Empty  Decimal `json:"empty"`
+		Null   Decimal `json:"null"`
+	}
+
+	err := json.Unmarshal([]byte(`{"string":"0.12345678","number":1.5,"empty":"","null":null}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(MustParseDecimal("0.12345678"), v.String, t)
+	assert(MustParseDecimal("1.5"), v.Number, t)
+	assert(Decimal{}, v.Empty, t)
+	assert(Decimal{}, v.Null, t)
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(`{"string":"0.12345678","number":"1.5","empty":"0","null":"0"}`, string(b), t)
+
+	if err = json.Unmarshal([]byte(`{"string":"abc"}`), &v); err == nil {
+		t.Fatalf("%s: expected error", t.Name())
+	}
+}
@@ -18,8 +18,8 @@ type DepositMethodsResponse map[string]DepositMethod
 // DepositMethod represents a Kraken deposit method.
 type DepositMethod struct {
 	Method          string  `json:"method"`
-	Limit           float64 `json:"limit"`
-	Fee             float64 `json:"fee"`
+	Limit           Decimal `json:"limit"`
+	Fee             Decimal `json:"fee"`
 	AddressSetupFee bool    `json:"address-setup-fee"`
 }
 
@@ -43,8 +43,8 @@ type DepositStatusResponse struct {
 	RefID      string            `json:"refid"`
 	TxID       string            `json:"txid"`
 	Info       string            `json:"info"`
-	Amount     float64           `json:"amount"`
-	Fee        float64           `json:"fee"`
+	Amount     Decimal           `json:"amount"`
+	Fee        Decimal           `json:"fee"`
 	Time       int64             `json:"time"`
 	Status     string            `json:"status"`
 	StatusProp DepositStatusProp `json:"status-prop"`
@@ -2,7 +2,6 @@ package gokraken
 
 import (
 	"encoding/json"
-	"strconv"
 	"time"
 
 	"github.com/danmrichards/gokraken/pairs"
@@ -22,8 +21,8 @@ type Depth struct {
 
 // DepthItem is either the asks or bids for an assert pair order book entry.
 type DepthItem struct {
-	Price     float64
-	Volume    float64
+	Price     Decimal
+	Volume    Decimal
 	Timestamp time.Time
 }
 
@@ -52,12 +51,12 @@ func (d *DepthItem) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	d.Price, err = strconv.ParseFloat(aux.price, 64)
+	d.Price, err = ParseDecimal(aux.price)
 	if err != nil {
 		return err
 	}
 
-	d.Volume, err = strconv.ParseFloat(aux.volume, 64)
+	d.Volume, err = ParseDecimal(aux.volume)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,171 @@
+package gokraken
+
+import "strings"
+
+const (
+	// SeverityError is the severity prefix for Kraken API errors.
+	SeverityError APISeverity = "E"
+
+	// SeverityWarning is the severity prefix for Kraken API warnings.
+	SeverityWarning APISeverity = "W"
+)
+
+var (
+	// ErrInvalidArguments is returned when a request has invalid arguments.
+	ErrInvalidArguments = &APIError{Category: "General", Message: "Invalid arguments"}
+
+	// ErrTemporaryLockout is returned when the API key is temporarily locked
+	// out after exceeding the rate limits.
+	ErrTemporaryLockout = &APIError{Category: "General", Message: "Temporary lockout"}
+
+	// ErrPermissionDenied is returned when the API key does not have the
+	// permissions required by an endpoint.
+	ErrPermissionDenied = &APIError{Category: "General", Message: "Permission denied"}
+
+	// ErrUnknownMethod is returned when the requested endpoint does not exist.
+	ErrUnknownMethod = &APIError{Category: "General", Message: "Unknown method"}
+
+	// ErrInvalidKey is returned when the API key is invalid.
+	ErrInvalidKey = &APIError{Category: "API", Message: "Invalid key"}
+
+	// ErrInvalidSignature is returned when the request signature is invalid.
+	ErrInvalidSignature = &APIError{Category: "API", Message: "Invalid signature"}
+
+	// ErrInvalidNonce is returned when the request nonce is not greater than
+	// the last nonce used with the API key.
+	ErrInvalidNonce = &APIError{Category: "API", Message: "Invalid nonce"}
+
+	// ErrRateLimitExceeded is returned when the API call counter is exceeded.
+	ErrRateLimitExceeded = &APIError{Category: "API", Message: "Rate limit exceeded"}
+
+	// ErrFeatureDisabled is returned when an endpoint is disabled.
+	ErrFeatureDisabled = &APIError{Category: "API", Message: "Feature disabled"}
+
+	// ErrUnknownAssetPair is returned when a request references an unknown
+	// asset pair.
+	ErrUnknownAssetPair = &APIError{Category: "Query", Message: "Unknown asset pair"}
+
+	// ErrUnknownAsset is returned when a request references an unknown asset.
+	ErrUnknownAsset = &APIError{Category: "Query", Message: "Unknown asset"}
+
+	// ErrInsufficientFunds is returned when the account does not have enough
+	// funds to place an order.
+	ErrInsufficientFunds = &APIError{Category: "Order", Message: "Insufficient funds"}
+
+	// ErrUnknownOrder is returned when a request references an unknown order.
+	ErrUnknownOrder = &APIError{Category: "Order", Message: "Unknown order"}
+
+	// ErrOrderRateLimitExceeded is returned when the matching engine rate limit
+	// is exceeded.
+	ErrOrderRateLimitExceeded = &APIError{Category: "Order", Message: "Rate limit exceeded"}
+
+	// ErrOrdersLimitExceeded is returned when the maximum number of open orders
+	// is exceeded.
+	ErrOrdersLimitExceeded = &APIError{Category: "Order", Message: "Orders limit exceeded"}
+
+	// ErrPositionsLimitExceeded is returned when the maximum number of open
+	// positions is exceeded.
+	ErrPositionsLimitExceeded = &APIError{Category: "Order", Message: "Positions limit exceeded"}
+
+	// ErrMarginAllowanceExceeded is returned when an order would exceed the
+	// margin allowance.
+	ErrMarginAllowanceExceeded = &APIError{Category: "Order", Message: "Margin allowance exceeded"}
+
+	// ErrCannotOpenPosition is returned when a position cannot be opened.
+	ErrCannotOpenPosition = &APIError{Category: "Order", Message: "Cannot open position"}
+
+	// ErrInvalidRequest is returned when a trade request is invalid.
+	ErrInvalidRequest = &APIError{Category: "Trade", Message: "Invalid request"}
+
+	// ErrServiceUnavailable is returned when the Kraken API is unavailable.
+	ErrServiceUnavailable = &APIError{Category: "Service", Message: "Unavailable"}
+
+	// ErrServiceBusy is returned when the Kraken API is too busy to process
+	// the request.
+	ErrServiceBusy = &APIError{Category: "Service", Message: "Busy"}
+
+	// ErrUnknownWithdrawKey is returned when a withdrawal references an
+	// unknown withdrawal key.
+	ErrUnknownWithdrawKey = &APIError{Category: "Funding", Message: "Unknown withdraw key"}
+)
+
+// APISeverity indicates whether a Kraken API message is an error or a warning.
+type APISeverity string
+
+// APIError represents an error or warning message returned by the Kraken API.
+//
+// Kraken formats these messages as <severity><category>:<message>, optionally
+// followed by :<detail>, e.g. "EOrder:Insufficient funds" or
+// "EGeneral:Invalid arguments:volume".
+type APIError struct {
+	Severity APISeverity
+	Category string
+	Message  string
+	Detail   string
+}
+
+// ParseAPIError parses a raw Kraken API error message.
+func ParseAPIError(raw string) *APIError {
+	e := &APIError{}
+
+	if strings.HasPrefix(raw, string(SeverityWarning)) {
+		e.Severity = SeverityWarning
+	} else {
+		e.Severity = SeverityError
+	}
+
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) < 2 {
+		e.Message = raw
+		return e
+	}
+
+	e.Category = strings.TrimPrefix(parts[0], string(e.Severity))
+	e.Message = parts[1]
+	if len(parts) == 3 {
+		e.Detail = parts[2]
+	}
+
+	return e
+}
+
+// Error returns the error message in the format Kraken sent it.
+func (e *APIError) Error() string {
+	severity := e.Severity
+	if severity == "" {
+		severity = SeverityError
+	}
+
+	if e.Category == "" {
+		return e.Message
+	}
+
+	msg := string(severity) + e.Category + ":" + e.Message
+	if e.Detail != "" {
+		msg += ":" + e.Detail
+	}
+
+	return msg
+}
+
+// Is reports whether the error matches target. Errors match when they share a
+// category and message, which allows sentinel errors such as ErrInvalidNonce
+// to be used with errors.Is. A target with a detail only matches errors with
+// the same detail.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+
+	if t.Detail != "" && t.Detail != e.Detail {
+		return false
+	}
+
+	return e.Category == t.Category && e.Message == t.Message
+}
+
+// Warning reports whether the message is a warning rather than an error.
+func (e *APIError) Warning() bool {
+	return e.Severity == SeverityWarning
+}
@@ -0,0 +1,105 @@
+package gokraken
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseAPIError(t *testing.T) {
+	cases := []struct {
+		name     string
+		raw      string
+		expected *APIError
+	}{
+		{
+			name: "error",
+			raw:  "EOrder:Insufficient funds",
+			expected: &APIError{
+				Severity: SeverityError,
+				Category: "Order",
+				Message:  "Insufficient funds",
+			},
+		},
+		{
+			name: "warning",
+			raw:  "WGeneral:Unknown field",
+			expected: &APIError{
+				Severity: SeverityWarning,
+				Category: "General",
+				Message:  "Unknown field",
+			},
+		},
+		{
+			name: "detail",
+			raw:  "EGeneral:Invalid arguments:volume",
+			expected: &APIError{
+				Severity: SeverityError,
+				Category: "General",
+				Message:  "Invalid arguments",
+				Detail:   "volume",
+			},
+		},
+		{
+			name: "unstructured",
+			raw:  "something went wrong",
+			expected: &APIError{
+				Severity: SeverityError,
+				Message:  "something went wrong",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := ParseAPIError(c.raw)
+
+			assert(c.expected, e, t)
+			assert(c.raw, e.Error(), t)
+		})
+	}
+}
+
+func TestAPIError_Is(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		target   error
+		expected bool
+	}{
+		{
+			name:     "match",
+			err:      ParseAPIError("EAPI:Invalid nonce"),
+			target:   ErrInvalidNonce,
+			expected: true,
+		},
+		{
+			name:     "wrapped",
+			err:      fmt.Errorf("add order: %w", ParseAPIError("EOrder:Insufficient funds")),
+			target:   ErrInsufficientFunds,
+			expected: true,
+		},
+		{
+			name:     "detail ignored by sentinel",
+			err:      ParseAPIError("EGeneral:Invalid arguments:volume"),
+			target:   ErrInvalidArguments,
+			expected: true,
+		},
+		{
+			name:     "same message different category",
+			err:      ParseAPIError("EOrder:Rate limit exceeded"),
+			target:   ErrRateLimitExceeded,
+			expected: false,
+		},
+		{
+			name:     "different message",
+			err:      ParseAPIError("EOrder:Unknown order"),
+			target:   ErrInsufficientFunds,
+			expected: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert(c.expected, errors.Is(c.err, c.target), t)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Package export writes the account activity of a Kraken user as CSV or JSON
+// lines, for accounting.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/asset"
+)
+
+const (
+	// FormatCSV writes a header row followed by a row per record.
+	FormatCSV Format = "csv"
+
+	// FormatJSONL writes a JSON object per line, with the fields in the same
+	// order as the CSV columns.
+	FormatJSONL Format = "jsonl"
+)
+
+// Format is the output format of an export.
+type Format string
+
+// Filter restricts the records of an export. The zero value exports every
+// record.
+type Filter struct {
+	Type   gokraken.LedgerType // Type of ledger entries. Ignored for trades and orders.
+	Assets []asset.Currency    // Assets of ledger entries, or of either side of the pair of trades and orders.
+	Start  time.Time           // Export records after this time.
+	End    time.Time           // Export records up to and including this time.
+}
+
+// request returns the start and end of the filter as used by requests.
+func (f Filter) request() (start, end *time.Time) {
+	if !f.Start.IsZero() {
+		start = &f.Start
+	}
+	if !f.End.IsZero() {
+		end = &f.End
+	}
+
+	return
+}
+
+// includesTime returns whether the filter includes a record at the given time.
+func (f Filter) includesTime(t time.Time) bool {
+	if !f.Start.IsZero() && !t.After(f.Start) {
+		return false
+	}
+
+	return f.End.IsZero() || !t.After(f.End)
+}
+
+// includesAsset returns whether the filter includes records of an asset.
+func (f Filter) includesAsset(currency asset.Currency) bool {
+	if len(f.Assets) == 0 {
+		return true
+	}
+
+	for _, a := range f.Assets {
+		if a == currency {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Exporter exports account activity from the Kraken API. Records are written
+// as they are fetched, newest first.
+//
+// All values are written as strings, so that prices and amounts keep every
+// decimal place. Assets and pairs are written as common ticker symbols, such
+// as BTC and BTC/USD, alongside their Kraken names.
+type Exporter struct {
+	UserData *gokraken.UserData
+	Format   Format
+
+	// Location is the time zone of the timestamps written. Defaults to UTC.
+	Location *time.Location
+}
+
+// New returns a new Exporter which writes account activity in the given format.
+func New(userData *gokraken.UserData, format Format) *Exporter {
+	return &Exporter{
+		UserData: userData,
+		Format:   format,
+	}
+}
+
+// formatTime formats a timestamp in the location of the exporter, or returns
+// an empty string for unset timestamps.
+func (e *Exporter) formatTime(t time.Time) string {
+	if t.IsZero() || t.Unix() == 0 {
+		return ""
+	}
+
+	loc := e.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// rowWriter writes rows of fields with a fixed set of columns.
+type rowWriter interface {
+	write(row []string) error
+	flush() error
+}
+
+// flushRows flushes the rows written to rw, so that rows already counted are
+// written even if the export failed. An error flushing is returned in err,
+// unless it already holds an error.
+func flushRows(rw rowWriter, err *error) {
+	if flushErr := rw.flush(); *err == nil {
+		*err = flushErr
+	}
+}
+
+// newRowWriter returns a writer of rows in the format of the exporter.
+func (e *Exporter) newRowWriter(w io.Writer, columns []string) (rowWriter, error) {
+	if e.Format == FormatJSONL {
+		return &jsonlWriter{w: w, columns: columns}, nil
+	}
+
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.write(columns); err != nil {
+		return nil, err
+	}
+
+	return cw, nil
+}
+
+// csvWriter writes rows as CSV.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// write writes a row as a CSV record.
+func (c *csvWriter) write(row []string) error {
+	return c.w.Write(row)
+}
+
+// flush writes any buffered records and returns the first error writing.
+func (c *csvWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlWriter writes rows as JSON objects, one per line, keyed by column.
+type jsonlWriter struct {
+	w       io.Writer
+	columns []string
+	buf     bytes.Buffer
+}
+
+// write writes a row as a JSON object of string values.
+func (j *jsonlWriter) write(row []string) error {
+	j.buf.Reset()
+	j.buf.WriteByte('{')
+	for i, column := range j.columns {
+		if i > 0 {
+			j.buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(column)
+		value, _ := json.Marshal(row[i])
+		j.buf.Write(key)
+		j.buf.WriteByte(':')
+		j.buf.Write(value)
+	}
+	j.buf.WriteString("}\n")
+
+	_, err := j.w.Write(j.buf.Bytes())
+	return err
+}
+
+// flush does nothing, as rows are written unbuffered.
+func (j *jsonlWriter) flush() error {
+	return nil
+}
@@ -0,0 +1,157 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/asset"
+	"github.com/danmrichards/gokraken/internal/testutil"
+)
+
+// Test helper for asserting values are equal.
+var assert = testutil.Assert
+
+func TestExporter(t *testing.T) {
+	cases := []struct {
+		name     string
+		response string
+		format   Format
+		location string
+		filter   Filter
+		export   func(e *Exporter, ctx context.Context, w io.Writer, f Filter) (int, error)
+		expected string
+		rows     int
+	}{
+		{
+			name: "ledgers",
+			response: `{"ledger":{
+				"L1":{"refid":"R1","time":1520035200,"type":"trade","aclass":"currency","asset":"XXBT","amount":"0.10000000","fee":"0.00001","balance":"1.1"},
+				"L2":{"refid":"R2","time":1520035100,"type":"trade","aclass":"currency","asset":"ZEUR","amount":"-950.5","fee":"1.52","balance":"100"},
+				"L3":{"refid":"R3","time":1520035000,"type":"deposit","aclass":"currency","asset":"NEWCOIN","amount":"5","fee":"0","balance":"5"}
+			},"count":3}`,
+			format: FormatCSV,
+			export: (*Exporter).Ledgers,
+			expected: `id,refid,time,type,aclass,asset,kraken_asset,amount,fee,balance
+L1,R1,2018-03-03T00:00:00Z,trade,currency,BTC,XXBT,0.1,0.00001,1.1
+L2,R2,2018-03-02T23:58:20Z,trade,currency,EUR,ZEUR,-950.5,1.52,100
+L3,R3,2018-03-02T23:56:40Z,deposit,currency,NEWCOIN,NEWCOIN,5,0,5
+`,
+			rows: 3,
+		},
+		{
+			name: "ledgers filter",
+			response: `{"ledger":{
+				"L1":{"refid":"R1","time":1520035200,"type":"trade","aclass":"currency","asset":"XXBT","amount":"0.10000000","fee":"0.00001","balance":"1.1"},
+				"L2":{"refid":"R2","time":1520035100,"type":"trade","aclass":"currency","asset":"ZEUR","amount":"-950.5","fee":"1.52","balance":"100"},
+				"L3":{"refid":"R3","time":1520035000,"type":"deposit","aclass":"currency","asset":"NEWCOIN","amount":"5","fee":"0","balance":"5"}
+			},"count":3}`,
+			format:   FormatJSONL,
+			location: "Europe/Paris",
+			filter: Filter{
+				Assets: []asset.Currency{asset.XXBT, asset.ZEUR},
+				Start:  time.Unix(1520035100, 0),
+			},
+			export: (*Exporter).Ledgers,
+			expected: `{"id":"L1","refid":"R1","time":"2018-03-03T01:00:00+01:00","type":"trade","aclass":"currency","asset":"BTC","kraken_asset":"XXBT","amount":"0.1","fee":"0.00001","balance":"1.1"}
+`,
+			rows: 1,
+		},
+		{
+			name: "trades",
+			response: `{"trades":{
+				"T1":{"ordertxid":"O1","pair":"XXBTZEUR","time":1520035200,"type":"buy","ordertype":"limit","price":"9505","cost":"950.5","fee":"1.52","vol":"0.1","margin":"0","misc":""},
+				"T2":{"ordertxid":"O2","pair":"XETHZUSD","time":1520035100,"type":"sell","ordertype":"market","price":"800","cost":"80","fee":"0.1","vol":"0.1","margin":"0","misc":"closing"}
+			},"count":2}`,
+			format: FormatCSV,
+			filter: Filter{Assets: []asset.Currency{asset.ZEUR}},
+			export: (*Exporter).Trades,
+			expected: `txid,ordertxid,time,pair,kraken_pair,base,quote,type,ordertype,price,vol,cost,fee,margin,misc
+T1,O1,2018-03-03T00:00:00Z,BTC/EUR,XXBTZEUR,BTC,EUR,buy,limit,9505,0.1,950.5,1.52,0,
+`,
+			rows: 1,
+		},
+		{
+			name: "closed orders",
+			response: `{"closed":{
+				"O1":{"refid":"","userref":42,"status":"closed","opentm":1520035100.5,"closetm":1520035200,"descr":{"pair":"XBTEUR","type":"buy","ordertype":"limit","order":"buy 0.1 XBTEUR @ limit 9505"},"vol":"0.1","vol_exec":"0.1","cost":"950.5","fee":"1.52","price":"9505","misc":"","oflags":"fciq","reason":""}
+			},"count":1}`,
+			format: FormatJSONL,
+			export: (*Exporter).ClosedOrders,
+			expected: `{"txid":"O1","refid":"","userref":"42","status":"closed","opentm":"2018-03-02T23:58:20Z","closetm":"2018-03-03T00:00:00Z","pair":"BTC/EUR","kraken_pair":"XXBTZEUR","type":"buy","ordertype":"limit","descr":"buy 0.1 XBTEUR @ limit 9505","price":"9505","vol":"0.1","vol_exec":"0.1","cost":"950.5","fee":"1.52","misc":"","oflags":"fciq","reason":""}
+`,
+			rows: 1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+
+				w.Write([]byte(`{"error":[],"result":` + c.response + `}`))
+			}))
+
+			defer ts.Close()
+
+			k := gokraken.NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+			k.BaseURL = ts.URL
+
+			e := New(k.UserData, c.format)
+			if c.location != "" {
+				var err error
+				e.Location, err = time.LoadLocation(c.location)
+				if err != nil {
+					t.Skip(err)
+				}
+			}
+
+			var buf bytes.Buffer
+			n, err := c.export(e, context.Background(), &buf, c.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.rows, n, t)
+			assert(c.expected, buf.String(), t)
+		})
+	}
+}
+
+func TestExporter_LedgersError(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		// The second page fails after the first has been written.
+		calls++
+		if calls > 1 {
+			w.Write([]byte(`{"error":["EService:Unavailable"]}`))
+			return
+		}
+
+		w.Write([]byte(`{"error":[],"result":{"ledger":{"L1":{"refid":"R1","time":1520035200,"type":"trade","aclass":"currency","asset":"XXBT","amount":"0.1","fee":"0","balance":"1.1"}},"count":2}}`))
+	}))
+	defer ts.Close()
+
+	k := gokraken.NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	var buf bytes.Buffer
+	n, err := New(k.UserData, FormatCSV).Ledgers(context.Background(), &buf, Filter{})
+	if err == nil {
+		t.Fatalf("%s: expected error", t.Name())
+	}
+
+	// The rows counted are flushed despite the error.
+	assert(1, n, t)
+	assert(`id,refid,time,type,aclass,asset,kraken_asset,amount,fee,balance
+L1,R1,2018-03-03T00:00:00Z,trade,currency,BTC,XXBT,0.1,0,1.1
+`, buf.String(), t)
+}
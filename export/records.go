@@ -0,0 +1,213 @@
+package export
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/asset"
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+var (
+	// LedgerColumns are the columns of exported ledger entries.
+	LedgerColumns = []string{
+		"id", "refid", "time", "type", "aclass", "asset", "kraken_asset",
+		"amount", "fee", "balance",
+	}
+
+	// TradeColumns are the columns of exported trades.
+	TradeColumns = []string{
+		"txid", "ordertxid", "time", "pair", "kraken_pair", "base", "quote",
+		"type", "ordertype", "price", "vol", "cost", "fee", "margin", "misc",
+	}
+
+	// OrderColumns are the columns of exported closed orders.
+	OrderColumns = []string{
+		"txid", "refid", "userref", "status", "opentm", "closetm", "pair",
+		"kraken_pair", "type", "ordertype", "descr", "price", "vol",
+		"vol_exec", "cost", "fee", "misc", "oflags", "reason",
+	}
+)
+
+// Ledgers writes every ledger entry matching the filter and returns the
+// number written.
+func (e *Exporter) Ledgers(ctx context.Context, w io.Writer, filter Filter) (n int, err error) {
+	rw, err := e.newRowWriter(w, LedgerColumns)
+	if err != nil {
+		return
+	}
+	defer flushRows(rw, &err)
+
+	start, end := filter.request()
+	it := e.UserData.LedgersIter(ctx, gokraken.LedgersRequest{
+		Assets: filter.Assets,
+		Type:   filter.Type,
+		Start:  start,
+		End:    end,
+	})
+
+	for it.Next() {
+		ledger := it.Ledger()
+
+		if !filter.includesTime(ledger.Time) {
+			continue
+		}
+
+		symbol := ledger.Asset
+		if currency, ok := asset.Lookup(ledger.Asset); ok {
+			if !filter.includesAsset(currency) {
+				continue
+			}
+			symbol = currency.Symbol()
+		} else if len(filter.Assets) > 0 {
+			continue
+		}
+
+		err = rw.write([]string{
+			it.ID(),
+			ledger.Refid,
+			e.formatTime(ledger.Time),
+			ledger.Type,
+			ledger.Aclass,
+			symbol,
+			ledger.Asset,
+			ledger.Amount.String(),
+			ledger.Fee.String(),
+			ledger.Balance.String(),
+		})
+		if err != nil {
+			return
+		}
+		n++
+	}
+
+	err = it.Err()
+	return
+}
+
+// Trades writes every trade matching the filter and returns the number
+// written.
+func (e *Exporter) Trades(ctx context.Context, w io.Writer, filter Filter) (n int, err error) {
+	rw, err := e.newRowWriter(w, TradeColumns)
+	if err != nil {
+		return
+	}
+	defer flushRows(rw, &err)
+
+	start, end := filter.request()
+	it := e.UserData.TradesHistoryIter(ctx, gokraken.TradesHistoryRequest{
+		Start: start,
+		End:   end,
+	})
+
+	for it.Next() {
+		trade := it.Trade()
+
+		pair, base, quote, ok := resolvePair(trade.Pair, filter)
+		if !ok || !filter.includesTime(trade.Time) {
+			continue
+		}
+
+		err = rw.write([]string{
+			it.TxID(),
+			trade.OrderTxid,
+			e.formatTime(trade.Time),
+			pair,
+			trade.Pair,
+			base,
+			quote,
+			string(trade.Type),
+			string(trade.OrderType),
+			trade.Price.String(),
+			trade.Vol.String(),
+			trade.Cost.String(),
+			trade.Fee.String(),
+			trade.Margin.String(),
+			strings.Join(trade.Misc, ","),
+		})
+		if err != nil {
+			return
+		}
+		n++
+	}
+
+	err = it.Err()
+	return
+}
+
+// ClosedOrders writes every closed order matching the filter and returns the
+// number written.
+func (e *Exporter) ClosedOrders(ctx context.Context, w io.Writer, filter Filter) (n int, err error) {
+	rw, err := e.newRowWriter(w, OrderColumns)
+	if err != nil {
+		return
+	}
+	defer flushRows(rw, &err)
+
+	start, end := filter.request()
+	it := e.UserData.ClosedOrdersIter(ctx, gokraken.ClosedOrdersRequest{
+		Start: start,
+		End:   end,
+	})
+
+	for it.Next() {
+		order := it.Order()
+		descr := order.Description
+
+		pair, _, _, ok := resolvePair(descr.AssetPair.String(), filter)
+		if !ok || !filter.includesTime(order.CloseTime) {
+			continue
+		}
+
+		err = rw.write([]string{
+			order.TransactionID,
+			order.ReferenceID,
+			strconv.FormatInt(order.UserRef, 10),
+			string(order.Status),
+			e.formatTime(order.OpenTime),
+			e.formatTime(order.CloseTime),
+			pair,
+			descr.AssetPair.String(),
+			string(descr.Type),
+			string(descr.OrderType),
+			descr.Order,
+			order.Price.String(),
+			order.Volume.String(),
+			order.VolumeExecuted.String(),
+			order.Cost.String(),
+			order.Fee.String(),
+			strings.Join(order.Misc, ","),
+			gokraken.JoinOrderFlags(order.OrderFlags),
+			order.Reason,
+		})
+		if err != nil {
+			return
+		}
+		n++
+	}
+
+	err = it.Err()
+	return
+}
+
+// resolvePair resolves the symbols of the pair with the given name and whether the
+// filter includes it. Unknown pairs are written with their Kraken name.
+func resolvePair(name string, filter Filter) (symbol, base, quote string, ok bool) {
+	pair, found := pairs.FromSymbol(name)
+	if !found {
+		return name, "", "", len(filter.Assets) == 0
+	}
+
+	symbol, ok = pair.Symbol(), len(filter.Assets) == 0
+	if currency, found := pair.Base(); found {
+		base, ok = currency.Symbol(), ok || filter.includesAsset(currency)
+	}
+	if currency, found := pair.Quote(); found {
+		quote, ok = currency.Symbol(), ok || filter.includesAsset(currency)
+	}
+
+	return
+}
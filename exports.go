@@ -0,0 +1,429 @@
+package gokraken
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// AddExportResource is the API resource for the Kraken API add export endpoint.
+	AddExportResource = "AddExport"
+
+	// ExportStatusResource is the API resource for the Kraken API export status endpoint.
+	ExportStatusResource = "ExportStatus"
+
+	// RetrieveExportResource is the API resource for the Kraken API retrieve export endpoint.
+	RetrieveExportResource = "RetrieveExport"
+
+	// RemoveExportResource is the API resource for the Kraken API remove export endpoint.
+	RemoveExportResource = "RemoveExport"
+
+	// ReportTrades is a report of trades.
+	ReportTrades ReportType = "trades"
+
+	// ReportLedgers is a report of ledger entries.
+	ReportLedgers ReportType = "ledgers"
+
+	// ReportFormatCSV is a report of comma separated values.
+	ReportFormatCSV ReportFormat = "CSV"
+
+	// ReportFormatTSV is a report of tab separated values.
+	ReportFormatTSV ReportFormat = "TSV"
+
+	// ReportQueued indicates that a report is waiting to be processed.
+	ReportQueued ReportStatus = "Queued"
+
+	// ReportProcessing indicates that a report is being processed.
+	ReportProcessing ReportStatus = "Processing"
+
+	// ReportProcessed indicates that a report is ready to be retrieved.
+	ReportProcessed ReportStatus = "Processed"
+
+	// RemoveExportCancel cancels a report which has not been processed.
+	RemoveExportCancel RemoveExportType = "cancel"
+
+	// RemoveExportDelete deletes a report which has been processed.
+	RemoveExportDelete RemoveExportType = "delete"
+
+	// exportTimeLayout is the layout of the times in report files.
+	exportTimeLayout = "2006-01-02 15:04:05"
+)
+
+// ReportType is the type of records in a report.
+type ReportType string
+
+// ReportFormat is the file format of a report.
+type ReportFormat string
+
+// ReportStatus is the processing status of a report.
+type ReportStatus string
+
+// RemoveExportType indicates how to remove a report.
+type RemoveExportType string
+
+// AddExportRequest represents a request to queue a report with Kraken.
+type AddExportRequest struct {
+	Report      ReportType   // Type of records to report.
+	Format      ReportFormat // File format, CSV by default.
+	Description string       // Description of the report.
+	Fields      []string     // Fields to report, all by default.
+	Start       *time.Time   // Starting timestamp.
+	End         *time.Time   // Ending timestamp.
+}
+
+// AddExportResponse represents the response from the AddExport endpoint of
+// the Kraken API.
+type AddExportResponse struct {
+	ID string `json:"id"`
+}
+
+// Export represents the status of a report.
+type Export struct {
+	ID            string       `json:"id"`
+	Description   string       `json:"descr"`
+	Format        ReportFormat `json:"format"`
+	Report        ReportType   `json:"report"`
+	Subtype       string       `json:"subtype"`
+	Status        ReportStatus `json:"status"`
+	Fields        string       `json:"fields"`
+	CreatedTime   int64        `json:"createdtm,string"`
+	ExpireTime    int64        `json:"expiretm,string"`
+	StartTime     int64        `json:"starttm,string"`
+	CompletedTime int64        `json:"completedtm,string"`
+	DataStartTime int64        `json:"datastarttm,string"`
+	DataEndTime   int64        `json:"dataendtm,string"`
+	Aclass        string       `json:"aclass"`
+	Asset         string       `json:"asset"`
+}
+
+// RemoveExportResponse represents the response from the RemoveExport endpoint
+// of the Kraken API.
+type RemoveExportResponse struct {
+	Delete bool `json:"delete"`
+	Cancel bool `json:"cancel"`
+}
+
+// AddExport queues a report of trades or ledger entries, which can be
+// retrieved once processed.
+// https://docs.kraken.com/rest/#operation/addExport
+func (u *UserData) AddExport(ctx context.Context, exportReq AddExportRequest) (res *AddExportResponse, err error) {
+	body := url.Values{
+		"report":      []string{string(exportReq.Report)},
+		"description": []string{exportReq.Description},
+	}
+
+	if exportReq.Format != "" {
+		body.Add("format", string(exportReq.Format))
+	}
+
+	if len(exportReq.Fields) > 0 {
+		body.Add("fields", strings.Join(exportReq.Fields, ","))
+	}
+
+	if exportReq.Start != nil {
+		body.Add("starttm", strconv.FormatInt(exportReq.Start.Unix(), 10))
+	}
+
+	if exportReq.End != nil {
+		body.Add("endtm", strconv.FormatInt(exportReq.End.Unix(), 10))
+	}
+
+	req, err := u.Client.DialWithAuth(ctx, http.MethodPost, AddExportResource, body)
+	if err != nil {
+		return
+	}
+
+	krakenResp, err := u.Client.Call(req)
+	if err != nil {
+		return
+	}
+
+	err = krakenResp.ExtractResult(&res)
+	return
+}
+
+// ExportStatus returns the status of the reports of the given type.
+// https://docs.kraken.com/rest/#operation/exportStatus
+func (u *UserData) ExportStatus(ctx context.Context, report ReportType) (res []Export, err error) {
+	body := url.Values{
+		"report": []string{string(report)},
+	}
+
+	req, err := u.Client.DialWithAuth(ctx, http.MethodPost, ExportStatusResource, body)
+	if err != nil {
+		return
+	}
+
+	krakenResp, err := u.Client.Call(req)
+	if err != nil {
+		return
+	}
+
+	err = krakenResp.ExtractResult(&res)
+	return
+}
+
+// WaitExport polls the status of a report at the given interval until it has
+// been processed, and returns its status. The interval must be positive.
+func (u *UserData) WaitExport(ctx context.Context, report ReportType, id string, interval time.Duration) (*Export, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid export poll interval %s", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		exports, err := u.ExportStatus(ctx, report)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for i := range exports {
+			if exports[i].ID != id {
+				continue
+			}
+
+			if exports[i].Status == ReportProcessed {
+				return &exports[i], nil
+			}
+			found = true
+		}
+
+		if !found {
+			return nil, fmt.Errorf("report %s not found", id)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetrieveExport returns the zip archive of a processed report.
+// https://docs.kraken.com/rest/#operation/retrieveExport
+func (u *UserData) RetrieveExport(ctx context.Context, id string) (res []byte, err error) {
+	body := url.Values{
+		"id": []string{id},
+	}
+
+	req, err := u.Client.DialWithAuth(ctx, http.MethodPost, RetrieveExportResource, body)
+	if err != nil {
+		return
+	}
+
+	return u.Client.CallRaw(req)
+}
+
+// RemoveExport cancels a report which has not been processed, or deletes one
+// which has.
+// https://docs.kraken.com/rest/#operation/removeExport
+func (u *UserData) RemoveExport(ctx context.Context, id string, removeType RemoveExportType) (res *RemoveExportResponse, err error) {
+	body := url.Values{
+		"id":   []string{id},
+		"type": []string{string(removeType)},
+	}
+
+	req, err := u.Client.DialWithAuth(ctx, http.MethodPost, RemoveExportResource, body)
+	if err != nil {
+		return
+	}
+
+	krakenResp, err := u.Client.Call(req)
+	if err != nil {
+		return
+	}
+
+	err = krakenResp.ExtractResult(&res)
+	return
+}
+
+// ParseLedgersExport parses the ledger entries of a ledgers report returned by
+// RetrieveExport, keyed by ledger id.
+func ParseLedgersExport(archive []byte) (res LedgersResponse, err error) {
+	res = make(LedgersResponse)
+	err = readExport(archive, func(row exportRow) (err error) {
+		ledger := Ledger{
+			Refid:  row.get("refid"),
+			Type:   row.get("type"),
+			Aclass: row.get("aclass"),
+			Asset:  row.get("asset"),
+		}
+
+		if ledger.Time, err = row.time("time"); err != nil {
+			return
+		}
+
+		if err = row.decimals(map[string]*Decimal{
+			"amount":  &ledger.Amount,
+			"fee":     &ledger.Fee,
+			"balance": &ledger.Balance,
+		}); err != nil {
+			return
+		}
+
+		res[row.get("txid")] = ledger
+		return
+	})
+
+	return
+}
+
+// ParseTradesExport parses the trades of a trades report returned by
+// RetrieveExport, keyed by transaction id.
+func ParseTradesExport(archive []byte) (res map[string]UserTrade, err error) {
+	res = make(map[string]UserTrade)
+	err = readExport(archive, func(row exportRow) (err error) {
+		trade := UserTrade{
+			OrderTxid: row.get("ordertxid"),
+			Pair:      row.get("pair"),
+			Type:      TradeBuySell(row.get("type")),
+			OrderType: OrderType(row.get("ordertype")),
+			Misc:      SplitList(row.get("misc")),
+		}
+
+		if trade.Time, err = row.time("time"); err != nil {
+			return
+		}
+
+		if err = row.decimals(map[string]*Decimal{
+			"price":  &trade.Price,
+			"cost":   &trade.Cost,
+			"fee":    &trade.Fee,
+			"vol":    &trade.Vol,
+			"margin": &trade.Margin,
+		}); err != nil {
+			return
+		}
+
+		res[row.get("txid")] = trade
+		return
+	})
+
+	return
+}
+
+// exportRow is a row of a report file, with its fields keyed by column.
+type exportRow struct {
+	line    int
+	columns map[string]int
+	fields  []string
+}
+
+// get returns the field in the given column, or an empty string if the report
+// does not have the column.
+func (r exportRow) get(column string) string {
+	if i, ok := r.columns[column]; ok && i < len(r.fields) {
+		return r.fields[i]
+	}
+
+	return ""
+}
+
+// time parses the time in the given column. Empty fields are the zero time.
+func (r exportRow) time(column string) (time.Time, error) {
+	value := r.get(column)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(exportTimeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not extract %s at line=%d", column, r.line)
+	}
+
+	return t, nil
+}
+
+// decimals parses the decimals in the given columns. Empty fields are zero.
+func (r exportRow) decimals(dst map[string]*Decimal) (err error) {
+	for column, d := range dst {
+		value := r.get(column)
+		if value == "" {
+			continue
+		}
+
+		if *d, err = ParseDecimal(value); err != nil {
+			return fmt.Errorf("could not extract %s at line=%d", column, r.line)
+		}
+	}
+
+	return
+}
+
+// readExport calls fn with each row of the report file in a zip archive.
+func readExport(archive []byte, fn func(row exportRow) error) error {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range zr.File {
+		comma := ','
+		switch strings.ToLower(path.Ext(file.Name)) {
+		case ".csv":
+		case ".tsv":
+			comma = '\t'
+		default:
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		return readExportFile(rc, comma, fn)
+	}
+
+	return fmt.Errorf("no report file in archive")
+}
+
+// readExportFile calls fn with each row of a report file, after its header.
+func readExportFile(r io.Reader, comma rune, fn func(row exportRow) error) error {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))] = i
+	}
+
+	if _, ok := columns["txid"]; !ok {
+		return fmt.Errorf("report has no txid column")
+	}
+
+	for line := 2; ; line++ {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = fn(exportRow{line: line, columns: columns, fields: fields}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package gokraken
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// zipReport returns a zip archive holding a report file.
+func zipReport(name, content string, t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestUserData_AddExport(t *testing.T) {
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"id":"TCJA"}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	start := time.Unix(1514764800, 0)
+	res, err := k.UserData.AddExport(context.Background(), AddExportRequest{
+		Report:      ReportLedgers,
+		Format:      ReportFormatTSV,
+		Description: "2018 ledgers",
+		Fields:      []string{"refid", "time", "amount"},
+		Start:       &start,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(&AddExportResponse{ID: "TCJA"}, res, t)
+	assert("ledgers", body.Get("report"), t)
+	assert("TSV", body.Get("format"), t)
+	assert("2018 ledgers", body.Get("description"), t)
+	assert("refid,time,amount", body.Get("fields"), t)
+	assert("1514764800", body.Get("starttm"), t)
+	_, ok := body["endtm"]
+	assert(false, ok, t)
+}
+
+func TestUserData_WaitExport(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		status := "Processing"
+		if calls > 1 {
+			status = "Processed"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":[{"id":"TCJA","descr":"2018 ledgers","format":"CSV","report":"ledgers","subtype":"all","status":"` + status + `","flags":"0","fields":"all","createdtm":"1616669085","expiretm":"1617878685","starttm":"1616669093","completedtm":"1616669093","datastarttm":"1614556800","dataendtm":"1616669085","aclass":"forex","asset":"all"}]}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.UserData.WaitExport(context.Background(), ReportLedgers, "TCJA", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(&Export{
+		ID:            "TCJA",
+		Description:   "2018 ledgers",
+		Format:        ReportFormatCSV,
+		Report:        ReportLedgers,
+		Subtype:       "all",
+		Status:        ReportProcessed,
+		Fields:        "all",
+		CreatedTime:   1616669085,
+		ExpireTime:    1617878685,
+		StartTime:     1616669093,
+		CompletedTime: 1616669093,
+		DataStartTime: 1614556800,
+		DataEndTime:   1616669085,
+		Aclass:        "forex",
+		Asset:         "all",
+	}, res, t)
+	assert(2, calls, t)
+
+	_, err = k.UserData.WaitExport(context.Background(), ReportLedgers, "NONE", time.Millisecond)
+	assert("report NONE not found", err.Error(), t)
+	_, err = k.UserData.WaitExport(context.Background(), ReportLedgers, "TCJA", 0)
+	assert("invalid export poll interval 0s", err.Error(), t)
+	assert(3, calls, t)
+}
+
+func TestUserData_RetrieveExport(t *testing.T) {
+	report := zipReport("ledgers.csv", "\ufefftxid,refid,time,type,subtype,aclass,asset,amount,fee,balance\n"+
+		"L4UESK-KG3EQ-UFO4T5,TJKLXX-PGMUI-4NTLXU,2018-03-03 00:00:00.5342,trade,,currency,XXBT,0.1000000000,0.0000100000,1.1000000000\n"+
+		"LRUHXI-IWECY-K4JYGO,TJKLXX-PGMUI-4NTLXU,2018-03-03 00:00:00,trade,,currency,ZEUR,-950.5000,1.5200,100.0000\n", t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ := url.ParseQuery(string(b))
+
+		if body.Get("id") != "TCJA" {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+
+			w.Write([]byte(`{"error":["EGeneral:Invalid arguments"]}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write(report)
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	archive, err := k.UserData.RetrieveExport(context.Background(), "TCJA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ParseLedgersExport(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(LedgersResponse{
+		"L4UESK-KG3EQ-UFO4T5": {
+			Refid:   "TJKLXX-PGMUI-4NTLXU",
+			Time:    time.Date(2018, 3, 3, 0, 0, 0, 534200000, time.UTC),
+			Type:    "trade",
+			Aclass:  "currency",
+			Asset:   "XXBT",
+			Amount:  dec("0.1"),
+			Fee:     dec("0.00001"),
+			Balance: dec("1.1"),
+		},
+		"LRUHXI-IWECY-K4JYGO": {
+			Refid:   "TJKLXX-PGMUI-4NTLXU",
+			Time:    time.Date(2018, 3, 3, 0, 0, 0, 0, time.UTC),
+			Type:    "trade",
+			Aclass:  "currency",
+			Asset:   "ZEUR",
+			Amount:  dec("-950.5"),
+			Fee:     dec("1.52"),
+			Balance: dec("100"),
+		},
+	}, res, t)
+
+	_, err = k.UserData.RetrieveExport(context.Background(), "NONE")
+	assert(true, errors.Is(err, ErrInvalidArguments), t)
+}
+
+func TestParseTradesExport(t *testing.T) {
+	report := zipReport("trades.tsv", "txid\tordertxid\tpair\ttime\ttype\tordertype\tprice\tcost\tfee\tvol\tmargin\tmisc\tledgers\n"+
+		"TZX2WP-XSEOP-FP7WYR\tOQCLML-BW3P3-BUCMWZ\tXXBTZEUR\t2018-03-03 00:00:00.1234\tbuy\tlimit\t9505.0\t950.50000\t1.52000\t0.10000000\t0.00000\t\tL4UESK-KG3EQ-UFO4T5,LRUHXI-IWECY-K4JYGO\n", t)
+
+	res, err := ParseTradesExport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(map[string]UserTrade{
+		"TZX2WP-XSEOP-FP7WYR": {
+			OrderTxid: "OQCLML-BW3P3-BUCMWZ",
+			Pair:      "XXBTZEUR",
+			Time:      time.Date(2018, 3, 3, 0, 0, 0, 123400000, time.UTC),
+			Type:      TradeBuy,
+			OrderType: OrderTypeLimit,
+			Price:     dec("9505"),
+			Cost:      dec("950.5"),
+			Fee:       dec("1.52"),
+			Vol:       dec("0.1"),
+		},
+	}, res, t)
+
+	_, err = ParseTradesExport(zipReport("trades.pdf", "", t))
+	assert("no report file in archive", err.Error(), t)
+}
+
+func TestUserData_RemoveExport(t *testing.T) {
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"delete":true}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.UserData.RemoveExport(context.Background(), "TCJA", RemoveExportDelete)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(&RemoveExportResponse{Delete: true}, res, t)
+	assert("TCJA", body.Get("id"), t)
+	assert("delete", body.Get("type"), t)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"net/http"
 	"net/url"
-	"strconv"
 
 	"github.com/danmrichards/gokraken/asset"
 )
@@ -90,12 +89,12 @@ func (f *Funding) DepositStatus(ctx context.Context, aclass AssetsClass, asset a
 
 // WithdrawInfo gets withdrawal information via the Kraken api.
 // https://www.kraken.com/en-gb/help/api#get-withdrawal-info
-func (f *Funding) WithdrawInfo(ctx context.Context, aclass AssetsClass, asset asset.Currency, key string, amount float64) (res *WithdrawInfoResponse, err error) {
+func (f *Funding) WithdrawInfo(ctx context.Context, aclass AssetsClass, asset asset.Currency, key string, amount Decimal) (res *WithdrawInfoResponse, err error) {
 	body := url.Values{
 		"aclass": {string(aclass)},
 		"asset":  {asset.String()},
 		"key":    {key},
-		"amount": {strconv.FormatFloat(amount, 'f', 4, 64)},
+		"amount": {amount.String()},
 	}
 
 	req, err := f.Client.DialWithAuth(ctx, http.MethodPost, WithdrawInfoResource, body)
@@ -114,12 +113,12 @@ func (f *Funding) WithdrawInfo(ctx context.Context, aclass AssetsClass, asset as
 
 // Withdraw withdraws funds via the Kraken api.
 // https://www.kraken.com/en-gb/help/api#withdraw-funds
-func (f *Funding) Withdraw(ctx context.Context, aclass AssetsClass, asset asset.Currency, key string, amount float64) (res *WithdrawResponse, err error) {
+func (f *Funding) Withdraw(ctx context.Context, aclass AssetsClass, asset asset.Currency, key string, amount Decimal) (res *WithdrawResponse, err error) {
 	body := url.Values{
 		"aclass": {string(aclass)},
 		"asset":  {asset.String()},
 		"key":    {key},
-		"amount": {strconv.FormatFloat(amount, 'f', 4, 64)},
+		"amount": {amount.String()},
 	}
 
 	req, err := f.Client.DialWithAuth(ctx, http.MethodPost, WithdrawResource, body)
@@ -16,8 +16,8 @@ func TestFunding_DepositMethods(t *testing.T) {
 	expectedResult := DepositMethodsResponse{
 		"1234": {
 			Method:          "BACS",
-			Limit:           1.23,
-			Fee:             0.12,
+			Limit:           dec("1.23"),
+			Fee:             dec("0.12"),
 			AddressSetupFee: true,
 		},
 	}
@@ -82,8 +82,8 @@ func TestFunding_DepositStatus(t *testing.T) {
 		Asset:  currency.GBP.String(),
 		RefID:  "1234",
 		TxID:   "4321",
-		Amount: 1.23,
-		Fee:    0.12,
+		Amount: dec("1.23"),
+		Fee:    dec("0.12"),
 		Time:   1521890577,
 		Status: "ok",
 		StatusProp: DepositStatusProp{
@@ -116,8 +116,8 @@ func TestFunding_WithdrawInfo(t *testing.T) {
 
 	expectedResult := &WithdrawInfoResponse{
 		Method: "test",
-		Limit:  1.23,
-		Fee:    0.12,
+		Limit:  dec("1.23"),
+		Fee:    dec("0.12"),
 	}
 
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -132,7 +132,7 @@ func TestFunding_WithdrawInfo(t *testing.T) {
 	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
 	k.BaseURL = ts.URL
 
-	res, err := k.Funding.WithdrawInfo(context.Background(), AssetCurrency, asset.BCH, "test", 2.34)
+	res, err := k.Funding.WithdrawInfo(context.Background(), AssetCurrency, asset.BCH, "test", dec("2.34"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -159,7 +159,7 @@ func TestFunding_Withdraw(t *testing.T) {
 	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
 	k.BaseURL = ts.URL
 
-	res, err := k.Funding.Withdraw(context.Background(), AssetCurrency, asset.BCH, "test", 2.34)
+	res, err := k.Funding.Withdraw(context.Background(), AssetCurrency, asset.BCH, "test", dec("2.34"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -168,7 +168,7 @@ func TestFunding_Withdraw(t *testing.T) {
 }
 
 func TestFunding_WithdrawStatus(t *testing.T) {
-	mockResponse := []byte(`{"error":[],"result":[{"method":"test","aclass":"currency","asset":"BCH","refid":"1234","txid":"4321","info":"foo","float64":1.23,"fee":3.21,"time":1522180241,"status":"bar","status-prop":{"cancel-pending":false,"canceled":false,"cancel-denied":true,"return":false,"onhold":false}}]}`)
+	mockResponse := []byte(`{"error":[],"result":[{"method":"test","aclass":"currency","asset":"BCH","refid":"1234","txid":"4321","info":"foo","amount":"1.23","fee":3.21,"time":1522180241,"status":"bar","status-prop":{"cancel-pending":false,"canceled":false,"cancel-denied":true,"return":false,"onhold":false}}]}`)
 
 	expectedResult := WithdrawStatusResponse{
 		{
@@ -178,8 +178,8 @@ func TestFunding_WithdrawStatus(t *testing.T) {
 			RefID:  "1234",
 			TxID:   "4321",
 			Info:   "foo",
-			Amount: 1.23,
-			Fee:    3.21,
+			Amount: dec("1.23"),
+			Fee:    dec("3.21"),
 			Time:   1522180241,
 			Status: "bar",
 			StatusProp: WithdrawStatusProp{
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"time"
 )
 
 // bindJSON takes a body from a readcloser and binds it to the target interface.
@@ -20,3 +21,40 @@ func bindJSON(rc io.ReadCloser, target interface{}) error {
 
 	return json.Unmarshal(body, target)
 }
+
+// parseUnixTime parses a Unix timestamp with ParseUnixTime. Empty and zero
+// timestamps, which Kraken uses for unset times, are the zero time.
+func parseUnixTime(n json.Number) (time.Time, error) {
+	if n == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := ParseUnixTime(string(n))
+	if err != nil || t.UnixNano() == 0 {
+		return time.Time{}, err
+	}
+
+	return t, nil
+}
+
+// parseUnixTimes parses Unix timestamps into times.
+func parseUnixTimes(ns []json.Number, dsts ...*time.Time) (err error) {
+	for i, dst := range dsts {
+		if *dst, err = parseUnixTime(ns[i]); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// unixNumber formats a time as a Unix timestamp with fractional seconds, the
+// inverse of parseUnixTime. The zero time is 0, as Kraken encodes unset
+// times.
+func unixNumber(t time.Time) json.Number {
+	if t.IsZero() {
+		return "0"
+	}
+
+	return json.Number(FormatUnixTime(t))
+}
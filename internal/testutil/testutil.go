@@ -0,0 +1,16 @@
+// Package testutil provides helpers shared by the tests of the gokraken
+// packages.
+package testutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Assert fails the test if the expected and actual values are not equal.
+func Assert(expected, actual interface{}, t *testing.T) {
+	t.Helper()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("%s: expected: %#[2]v (%[2]T), but got %#[3]v (%[3]T)", t.Name(), expected, actual)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -48,6 +50,34 @@ type Kraken struct {
 	APIKey     string
 	BaseURL    string
 	HTTPClient *http.Client
+	Limiter    *RateLimiter // Optional rate limiter applied to private calls.
+	Retry      *RetryPolicy // Optional policy for retrying failed calls.
+
+	// Validator checks orders before they are added. If nil, orders are sent
+	// to Kraken as they are.
+	Validator *OrderValidator
+
+	// Nonce generates nonces for private calls. If nil, a monotonic nonce
+	// source shared by all clients in the process is used.
+	Nonce NonceSource
+
+	// NonceWindow is the nonce window configured for the API key in the
+	// Kraken account settings. Requests held by the rate limiter for at least
+	// this long are signed again with a fresh nonce before being sent, as a
+	// later request may already have used a greater nonce.
+	NonceWindow time.Duration
+
+	// OTP provides the two-factor password for private calls, if the API key
+	// requires one.
+	OTP OTPSource
+
+	// UserAgent is the user agent string applied to requests. If empty, the
+	// package level UserAgent is used.
+	UserAgent string
+
+	// Logger reports retried requests. If nil, nothing is logged.
+	Logger *log.Logger
+
 	Market     *Market
 	UserData   *UserData
 	Trading    *Trading
@@ -55,37 +85,34 @@ type Kraken struct {
 	PrivateKey string
 }
 
-// New returns a new Kraken object with a default HTTP client.
-func New() *Kraken {
+// NewClient returns a new Kraken object configured by the given options.
+func NewClient(opts ...Option) *Kraken {
 	k := &Kraken{
 		HTTPClient: &http.Client{},
 	}
+
+	for _, opt := range opts {
+		opt(k)
+	}
 	k.initServices()
 
 	return k
 }
 
+// New returns a new Kraken object with a default HTTP client.
+func New() *Kraken {
+	return NewClient()
+}
+
 // NewWithAuth returns a new Kraken object with the authentication
 // credentials required for private api endpoints.
 func NewWithAuth(apiKey, privateKey string) *Kraken {
-	k := &Kraken{
-		APIKey:     apiKey,
-		PrivateKey: privateKey,
-		HTTPClient: &http.Client{},
-	}
-	k.initServices()
-
-	return k
+	return NewClient(WithCredentials(apiKey, privateKey))
 }
 
 // NewWithHTTPClient returns a new Kraken object with a custom HTTP client.
 func NewWithHTTPClient(httpClient *http.Client) *Kraken {
-	k := &Kraken{
-		HTTPClient: httpClient,
-	}
-	k.initServices()
-
-	return k
+	return NewClient(WithHTTPClient(httpClient))
 }
 
 // Initialise services for the Kraken api client.
@@ -94,6 +121,10 @@ func (k *Kraken) initServices() {
 	k.UserData = &UserData{k}
 	k.Trading = &Trading{k}
 	k.Funding = &Funding{k}
+
+	if k.Validator != nil && k.Validator.Market == nil {
+		k.Validator.Market = k.Market
+	}
 }
 
 // GetBaseURL returns the base URI of the Kraken API.
@@ -108,8 +139,66 @@ func (k *Kraken) GetBaseURL() string {
 }
 
 // Call performs a request against the Kraken API.
+//
+// If Kraken responds with an error message it is returned as an *APIError
+// alongside the response. Warnings do not fail the call and are available
+// from the response.
+//
+// If a rate limiter is configured, calls to private resources block until the
+// limiter allows them or the request context is done.
+//
+// If a retry policy is configured, failed requests to Kraken API resources
+// are dialled again, generating a new nonce and signature, and retried.
 func (k *Kraken) Call(req *http.Request) (res *Response, err error) {
-	apiResp, err := k.HTTPClient.Do(req)
+	err = k.retry(req, func(req *http.Request) (err error) {
+		res, err = k.call(req)
+		return
+	})
+
+	return
+}
+
+// CallRaw performs a request against the Kraken API which responds with a
+// file rather than JSON, such as RetrieveExport, and returns the file. Errors
+// are handled as by Call.
+func (k *Kraken) CallRaw(req *http.Request) (body []byte, err error) {
+	err = k.retry(req, func(req *http.Request) (err error) {
+		body, err = k.callRaw(req)
+		return
+	})
+
+	return
+}
+
+// retry makes attempts at a request until one succeeds or the retry policy,
+// if any, gives up.
+func (k *Kraken) retry(req *http.Request, attempt func(req *http.Request) error) (err error) {
+	_, resource := k.splitResourceURI(req.URL.Path)
+
+	for n := 1; ; n++ {
+		err = attempt(req)
+		if k.Retry == nil || !k.Retry.retryable(req.Context(), resource, n, err) {
+			return
+		}
+
+		if !k.Retry.wait(req.Context(), n) {
+			return
+		}
+
+		k.logf("retrying %s after attempt %d failed: %s", resource, n, err)
+
+		var redialErr error
+		req, redialErr = k.redial(req)
+		if redialErr != nil {
+			err = fmt.Errorf("could not retry %s after attempt %d failed: %w", resource, n, redialErr)
+			return
+		}
+	}
+}
+
+// call performs a single attempt of a request against the Kraken API.
+func (k *Kraken) call(req *http.Request) (res *Response, err error) {
+	apiResp, err := k.send(req)
 	if err != nil {
 		return
 	}
@@ -119,9 +208,113 @@ func (k *Kraken) Call(req *http.Request) (res *Response, err error) {
 		return
 	}
 
+	err = k.responseErr(req, res)
 	return
 }
 
+// callRaw performs a single attempt of a request against the Kraken API which
+// responds with a file. Kraken responds with JSON instead if it fails.
+func (k *Kraken) callRaw(req *http.Request) (body []byte, err error) {
+	apiResp, err := k.send(req)
+	if err != nil {
+		return
+	}
+
+	if strings.HasPrefix(apiResp.Header.Get("Content-Type"), "application/json") {
+		var res *Response
+		if err = bindJSON(apiResp.Body, &res); err != nil {
+			return
+		}
+
+		if err = k.responseErr(req, res); err == nil {
+			err = errors.New("unexpected JSON response")
+		}
+		return
+	}
+
+	defer apiResp.Body.Close()
+	return ioutil.ReadAll(apiResp.Body)
+}
+
+// send sends a request to the Kraken API, once the rate limiter allows it.
+// Server error responses are returned as an *HTTPError.
+func (k *Kraken) send(req *http.Request) (apiResp *http.Response, err error) {
+	namespace, resource := k.splitResourceURI(req.URL.Path)
+
+	if k.Limiter != nil && namespace == APIPrivateNamespace {
+		var blocked time.Duration
+		blocked, err = k.Limiter.wait(req.Context(), resource)
+		if err != nil {
+			return
+		}
+
+		if blocked > 0 && blocked >= k.NonceWindow {
+			if req, err = k.redial(req); err != nil {
+				return
+			}
+		}
+	}
+
+	apiResp, err = k.HTTPClient.Do(req)
+	if err != nil {
+		return
+	}
+
+	if apiResp.StatusCode >= http.StatusInternalServerError {
+		apiResp.Body.Close()
+		err = &HTTPError{StatusCode: apiResp.StatusCode, Status: apiResp.Status}
+		return nil, err
+	}
+
+	return
+}
+
+// responseErr returns the error of a Kraken API response, telling the rate
+// limiter if a private call exceeded a rate limit.
+func (k *Kraken) responseErr(req *http.Request, res *Response) error {
+	err := res.Err()
+
+	namespace, resource := k.splitResourceURI(req.URL.Path)
+	if k.Limiter != nil && namespace == APIPrivateNamespace && (errors.Is(err, ErrRateLimitExceeded) || errors.Is(err, ErrOrderRateLimitExceeded)) {
+		k.Limiter.Exceeded(resource)
+	}
+
+	return err
+}
+
+// redial prepares a request again so that it can be retried. Private requests
+// are given a new nonce and signature.
+func (k *Kraken) redial(req *http.Request) (*http.Request, error) {
+	namespace, resource := k.splitResourceURI(req.URL.Path)
+
+	body := url.Values{}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		b, err := ioutil.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+
+		if body, err = url.ParseQuery(string(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	switch namespace {
+	case APIPublicNamespace:
+		return k.Dial(req.Context(), req.Method, resource, body)
+	case APIPrivateNamespace:
+		return k.DialWithAuth(req.Context(), req.Method, resource, body)
+	}
+
+	return nil, fmt.Errorf("cannot redial request to %s", req.URL)
+}
+
 // Dial prepares a request to send to the Kraken API.
 func (k *Kraken) Dial(ctx context.Context, method, resource string, body url.Values) (req *http.Request, err error) {
 	req, err = http.NewRequest(method, k.ResourceURL(APIPublicNamespace, resource), strings.NewReader(body.Encode()))
@@ -132,7 +325,7 @@ func (k *Kraken) Dial(ctx context.Context, method, resource string, body url.Val
 	// Apply the context to the request to allow it to be cancelled.
 	req = req.WithContext(ctx)
 
-	req.Header.Add("User-Agent", UserAgent)
+	req.Header.Add("User-Agent", k.userAgent())
 
 	return
 }
@@ -163,8 +356,28 @@ func (k *Kraken) DialWithAuth(ctx context.Context, method, resource string, body
 
 	// Create a unique nonce value for this request.
 	// https://www.kraken.com/en-gb/help/api#general-usage
-	nonce := time.Now().UnixNano()
-	body.Set(APINonceParam, strconv.FormatInt(nonce, 10))
+	nonceSource := k.Nonce
+	if nonceSource == nil {
+		nonceSource = defaultNonce
+	}
+
+	nonce, err := nonceSource.Nonce()
+	if err != nil {
+		err = fmt.Errorf("could not generate nonce: %s", err)
+		return
+	}
+	body.Set(APINonceParam, strconv.FormatUint(nonce, 10))
+
+	// Apply the two-factor password, if any.
+	if k.OTP != nil {
+		var otp string
+		otp, err = k.OTP.OTP()
+		if err != nil {
+			err = fmt.Errorf("could not generate otp: %s", err)
+			return
+		}
+		body.Set(APIOTPParam, otp)
+	}
 
 	// Generate the request.
 	req, err = http.NewRequest(method, k.ResourceURL(APIPrivateNamespace, resource), strings.NewReader(body.Encode()))
@@ -183,13 +396,29 @@ func (k *Kraken) DialWithAuth(ctx context.Context, method, resource string, body
 	}
 
 	// Apply headers.
-	req.Header.Add("User-Agent", UserAgent)
+	req.Header.Add("User-Agent", k.userAgent())
 	req.Header.Add(APIKeyHeader, k.APIKey)
 	req.Header.Add(APISignHeader, signature.Generate())
 
 	return
 }
 
+// userAgent returns the user agent string to apply to requests.
+func (k *Kraken) userAgent() string {
+	if k.UserAgent == "" {
+		return UserAgent
+	}
+
+	return k.UserAgent
+}
+
+// logf reports a message to the logger, if one is configured.
+func (k *Kraken) logf(format string, v ...interface{}) {
+	if k.Logger != nil {
+		k.Logger.Printf(format, v...)
+	}
+}
+
 // ResourceURI returns the URI path for the given api resource.
 func (k *Kraken) ResourceURI(namespace, resource string) string {
 	return fmt.Sprintf(
@@ -200,6 +429,17 @@ func (k *Kraken) ResourceURI(namespace, resource string) string {
 	)
 }
 
+// splitResourceURI returns the namespace and resource of the given URI path.
+// The base URL may itself contain a path, so these are taken from the end.
+func (k *Kraken) splitResourceURI(uri string) (namespace, resource string) {
+	parts := strings.Split(uri, "/")
+	if len(parts) < 3 {
+		return
+	}
+
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
 // ResourceURL returns a fully qualified URI for the given api resource.
 func (k *Kraken) ResourceURL(namespace, resource string) string {
 	return fmt.Sprintf(
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -129,6 +130,52 @@ func TestKraken_PrepareRequest(t *testing.T) {
 	assert(&exampleResp, res, t)
 }
 
+func TestKraken_CallError(t *testing.T) {
+	cases := []struct {
+		name          string
+		response      string
+		expectedError error
+		warnings      []*APIError
+	}{
+		{
+			name:          "error",
+			response:      `{"error":["EOrder:Insufficient funds"]}`,
+			expectedError: ErrInsufficientFunds,
+		},
+		{
+			name:     "warning",
+			response: `{"error":["WGeneral:Unknown field"],"result":{}}`,
+			warnings: []*APIError{ParseAPIError("WGeneral:Unknown field")},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+
+				w.Write([]byte(c.response))
+			}))
+
+			defer ts.Close()
+
+			k := New()
+
+			req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := k.Call(req)
+			if !errors.Is(err, c.expectedError) {
+				t.Fatalf("%s: expected error %v, got %v", t.Name(), c.expectedError, err)
+			}
+
+			assert(c.warnings, res.Warnings(), t)
+		})
+	}
+}
+
 func TestKraken_Dial(t *testing.T) {
 	cases := []struct {
 		name        string
@@ -346,6 +393,30 @@ func TestKraken_DialWithAuth(t *testing.T) {
 	}
 }
 
+func TestKraken_DialWithAuthOptions(t *testing.T) {
+	k := NewClient(
+		WithCredentials("baz2345qux", "Zm9vMTIzNGJhcg=="),
+		WithUserAgent("foo"),
+		WithOTP("123456"),
+	)
+
+	req, err := k.DialWithAuth(context.Background(), http.MethodPost, "bar", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(req.Body)
+
+	body, err := url.ParseQuery(buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert("foo", req.Header.Get("User-Agent"), t)
+	assert("123456", body.Get(APIOTPParam), t)
+}
+
 func TestKraken_ResourceURI(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -1,6 +1,7 @@
 package gokraken
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/danmrichards/gokraken/asset"
@@ -48,12 +49,45 @@ type LedgersResponse map[string]Ledger
 
 // Ledger represent a Kraken ledger entry.
 type Ledger struct {
-	Refid   string  `json:"refid"`
-	Time    int64   `json:"time"`
-	Type    string  `json:"type"`
-	Aclass  string  `json:"aclass"`
-	Asset   string  `json:"asset"`
-	Amount  float64 `json:"amount"`
-	Fee     float64 `json:"fee"`
-	Balance float64 `json:"balance"`
+	Refid   string    `json:"refid"`
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Aclass  string    `json:"aclass"`
+	Asset   string    `json:"asset"`
+	Amount  Decimal   `json:"amount"`
+	Fee     Decimal   `json:"fee"`
+	Balance Decimal   `json:"balance"`
+}
+
+// UnmarshalJSON parses the JSON-encoded data and stores the result
+// in the value pointed to by l, converting its Unix timestamp without losing
+// its fractional seconds.
+func (l *Ledger) UnmarshalJSON(data []byte) (err error) {
+	type ledger Ledger
+	aux := struct {
+		*ledger
+		Time json.Number `json:"time"`
+	}{
+		ledger: (*ledger)(l),
+	}
+
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	l.Time, err = parseUnixTime(aux.Time)
+	return
+}
+
+// MarshalJSON encodes the ledger entry in the format used by Kraken, so that it
+// round trips through UnmarshalJSON.
+func (l Ledger) MarshalJSON() ([]byte, error) {
+	type ledger Ledger
+	return json.Marshal(struct {
+		ledger
+		Time json.Number `json:"time"`
+	}{
+		ledger: ledger(l),
+		Time:   unixNumber(l.Time),
+	})
 }
@@ -0,0 +1,30 @@
+package gokraken
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLedger_MarshalJSON(t *testing.T) {
+	ledger := Ledger{
+		Refid:  "TJKLXX-PGMUI-4NTLXU",
+		Time:   time.Unix(1520633741, 562300000),
+		Type:   "trade",
+		Aclass: "currency",
+		Asset:  "XXBT",
+		Amount: dec("0.1"),
+	}
+
+	b, err := json.Marshal(ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Ledger
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(ledger, decoded, t)
+}
@@ -2,6 +2,7 @@ package gokraken
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -48,7 +49,7 @@ func (m *Market) Assets(ctx context.Context, info AssetsInfoLevel, aClass Assets
 	if len(assets) > 0 {
 		assetStrings := make([]string, len(assets))
 		for i, a := range assets {
-			assetStrings[i] = string(a)
+			assetStrings[i] = a.String()
 		}
 
 		body.Add("asset", strings.Join(assetStrings, ","))
@@ -69,9 +70,13 @@ func (m *Market) Assets(ctx context.Context, info AssetsInfoLevel, aClass Assets
 
 	assetsResponse := make(AssetsResponse)
 	for assetStr, assetData := range tmp {
-		if currency := asset.Find(assetStr); currency != nil {
-			assetsResponse[*currency] = assetData
-		}
+		currency := asset.Register(asset.Info{
+			Name:            assetStr,
+			Altname:         assetData.AltName,
+			Decimals:        assetData.Decimals,
+			DisplayDecimals: assetData.DisplayDecimals,
+		})
+		assetsResponse[currency] = assetData
 	}
 
 	res = assetsResponse
@@ -88,7 +93,7 @@ func (m *Market) AssetPairs(ctx context.Context, info AssetPairsInfoLevel, reqPa
 	if len(reqPairs) > 0 {
 		pairStrings := make([]string, len(reqPairs))
 		for i, asset := range reqPairs {
-			pairStrings[i] = string(asset)
+			pairStrings[i] = asset.String()
 		}
 
 		body.Add("pair", strings.Join(pairStrings, ","))
@@ -109,15 +114,35 @@ func (m *Market) AssetPairs(ctx context.Context, info AssetPairsInfoLevel, reqPa
 
 	assetPairsResponse := make(AssetPairsResponse)
 	for pairStr, pairData := range tmp {
-		if pair := pairs.Find(pairStr); pair != nil {
-			assetPairsResponse[*pair] = pairData
-		}
+		pair := pairs.Register(pairs.Info{
+			Name:    pairStr,
+			Altname: pairData.Altname,
+			WSName:  pairData.WSName,
+			Base:    pairData.Base,
+			Quote:   pairData.Quote,
+		})
+		assetPairsResponse[pair] = pairData
 	}
 
 	res = assetPairsResponse
 	return
 }
 
+// RefreshRegistry loads all assets and asset pairs from Kraken into the asset
+// and pairs registries, so that assets and pairs listed since the packages
+// were generated can be resolved by any of their names.
+func (m *Market) RefreshRegistry(ctx context.Context) error {
+	if _, err := m.Assets(ctx, AssetInfo, AssetCurrency); err != nil {
+		return fmt.Errorf("could not load assets: %s", err)
+	}
+
+	if _, err := m.AssetPairs(ctx, AssetPairsInfo); err != nil {
+		return fmt.Errorf("could not load asset pairs: %s", err)
+	}
+
+	return nil
+}
+
 // Ticker returns ticker information from Kraken.
 // https://www.kraken.com/en-gb/help/api#get-ticker-info
 func (m *Market) Ticker(ctx context.Context, reqPairs ...pairs.AssetPair) (res TickerResponse, err error) {
@@ -125,7 +150,7 @@ func (m *Market) Ticker(ctx context.Context, reqPairs ...pairs.AssetPair) (res T
 	if len(reqPairs) > 0 {
 		pairStrings := make([]string, len(reqPairs))
 		for i, asset := range reqPairs {
-			pairStrings[i] = string(asset)
+			pairStrings[i] = asset.String()
 		}
 
 		body = url.Values{
@@ -148,9 +173,7 @@ func (m *Market) Ticker(ctx context.Context, reqPairs ...pairs.AssetPair) (res T
 
 	tickerResponse := make(TickerResponse)
 	for pairStr, tickerData := range tmp {
-		if pair := pairs.Find(pairStr); pair != nil {
-			tickerResponse[*pair] = tickerData
-		}
+		tickerResponse[findPair(pairStr)] = tickerData
 	}
 
 	res = tickerResponse
@@ -218,45 +241,50 @@ func (m *Market) Ohlc(ctx context.Context, ohlcReq OhlcRequest) (res *OhlcRespon
 			err = fmt.Errorf("could not extract timestamp at ohlcDatum=%d", key)
 			return
 		}
-		timestamp := time.Unix(int64(timestampFloat), 0)
+		var timestamp time.Time
+		timestamp, err = parseUnixTime(json.Number(strconv.FormatFloat(timestampFloat, 'f', -1, 64)))
+		if err != nil {
+			err = fmt.Errorf("could not extract timestamp at ohlcDatum=%d", key)
+			return
+		}
 
-		var open float64
-		open, err = strconv.ParseFloat(ohlcDatum[1].(string), 64)
+		var open Decimal
+		open, err = ParseDecimal(ohlcDatum[1].(string))
 		if err != nil {
 			err = fmt.Errorf("could not extract open at ohlcDatum=%d", key)
 			return
 		}
 
-		var high float64
-		high, err = strconv.ParseFloat(ohlcDatum[2].(string), 64)
+		var high Decimal
+		high, err = ParseDecimal(ohlcDatum[2].(string))
 		if err != nil {
 			err = fmt.Errorf("could not extract high at ohlcDatum=%d", key)
 			return
 		}
 
-		var low float64
-		low, err = strconv.ParseFloat(ohlcDatum[3].(string), 64)
+		var low Decimal
+		low, err = ParseDecimal(ohlcDatum[3].(string))
 		if err != nil {
 			err = fmt.Errorf("could not extract low at ohlcDatum=%d", key)
 			return
 		}
 
-		var close float64
-		close, err = strconv.ParseFloat(ohlcDatum[4].(string), 64)
+		var close Decimal
+		close, err = ParseDecimal(ohlcDatum[4].(string))
 		if err != nil {
 			err = fmt.Errorf("could not extract close at ohlcDatum=%d", key)
 			return
 		}
 
-		var vwap float64
-		vwap, err = strconv.ParseFloat(ohlcDatum[5].(string), 64)
+		var vwap Decimal
+		vwap, err = ParseDecimal(ohlcDatum[5].(string))
 		if err != nil {
 			err = fmt.Errorf("could not extract vwap at ohlcDatum=%d", key)
 			return
 		}
 
-		var volume float64
-		volume, err = strconv.ParseFloat(ohlcDatum[6].(string), 64)
+		var volume Decimal
+		volume, err = ParseDecimal(ohlcDatum[6].(string))
 		if err != nil {
 			err = fmt.Errorf("could not extract volume at ohlcDatum=%d", key)
 			return
@@ -307,9 +335,7 @@ func (m *Market) Depth(ctx context.Context, pair pairs.AssetPair, count int) (re
 
 	depthResponse := make(DepthResponse)
 	for pairStr, depthData := range tmp {
-		if pair := pairs.Find(pairStr); pair != nil {
-			depthResponse[*pair] = depthData
-		}
+		depthResponse[findPair(pairStr)] = depthData
 	}
 
 	res = depthResponse
@@ -366,15 +392,15 @@ func (m *Market) Trades(ctx context.Context, tradeReq TradesRequest) (res *Trade
 			return
 		}
 
-		var price float64
-		price, err = strconv.ParseFloat(trade[0].(string), 64)
+		var price Decimal
+		price, err = ParseDecimal(trade[0].(string))
 		if err != nil {
 			err = fmt.Errorf("could not extract price at trade=%d", key)
 			return
 		}
 
-		var volume float64
-		volume, err = strconv.ParseFloat(trade[1].(string), 64)
+		var volume Decimal
+		volume, err = ParseDecimal(trade[1].(string))
 		if err != nil {
 			err = fmt.Errorf("could not extract volume at trade=%d", key)
 			return
@@ -385,7 +411,12 @@ func (m *Market) Trades(ctx context.Context, tradeReq TradesRequest) (res *Trade
 			err = fmt.Errorf("could not extract timestamp at trade=%d", key)
 			return
 		}
-		timestamp := time.Unix(int64(timestampFloat), 0)
+		var timestamp time.Time
+		timestamp, err = parseUnixTime(json.Number(strconv.FormatFloat(timestampFloat, 'f', -1, 64)))
+		if err != nil {
+			err = fmt.Errorf("could not extract timestamp at trade=%d", key)
+			return
+		}
 
 		var buySell TradeBuySell
 		switch trade[3].(string) {
@@ -478,7 +509,12 @@ func (m *Market) Spread(ctx context.Context, spreadReq SpreadRequest) (res *Spre
 			err = fmt.Errorf("could not extract timestamp at spreadDatum=%d", key)
 			return
 		}
-		timestamp := time.Unix(int64(timestampFloat), 0)
+		var timestamp time.Time
+		timestamp, err = parseUnixTime(json.Number(strconv.FormatFloat(timestampFloat, 'f', -1, 64)))
+		if err != nil {
+			err = fmt.Errorf("could not extract timestamp at spreadDatum=%d", key)
+			return
+		}
 
 		bidStr, ok := spreadDatum[1].(string)
 		if !ok {
@@ -486,10 +522,10 @@ func (m *Market) Spread(ctx context.Context, spreadReq SpreadRequest) (res *Spre
 			return
 		}
 
-		var bid float64
-		bid, err = strconv.ParseFloat(bidStr, 64)
+		var bid Decimal
+		bid, err = ParseDecimal(bidStr)
 		if err != nil {
-			err = fmt.Errorf("could not parse bid to float at spreadDatum=%d", key)
+			err = fmt.Errorf("could not parse bid at spreadDatum=%d", key)
 			return
 		}
 
@@ -499,10 +535,10 @@ func (m *Market) Spread(ctx context.Context, spreadReq SpreadRequest) (res *Spre
 			return
 		}
 
-		var ask float64
-		ask, err = strconv.ParseFloat(askStr, 64)
+		var ask Decimal
+		ask, err = ParseDecimal(askStr)
 		if err != nil {
-			err = fmt.Errorf("could not parse ask to float at spreadDatum=%d", key)
+			err = fmt.Errorf("could not parse ask at spreadDatum=%d", key)
 			return
 		}
 
@@ -515,3 +551,46 @@ func (m *Market) Spread(ctx context.Context, spreadReq SpreadRequest) (res *Spre
 
 	return
 }
+
+// OrderBook returns an order book for the pair with the given number of
+// levels on each side, loaded from a Depth snapshot. Depth snapshots are not
+// sequenced with streamed updates, so a book kept up to date from the
+// WebSocket API should be loaded from the snapshot of the book channel
+// instead, and resynced by resubscribing to it.
+func (m *Market) OrderBook(ctx context.Context, pair pairs.AssetPair, levels int) (book *OrderBook, err error) {
+	info, err := m.AssetPairs(ctx, AssetPairsInfo, pair)
+	if err != nil {
+		return
+	}
+
+	data, ok := info[pair]
+	if !ok {
+		err = fmt.Errorf("no asset pair data for %s", pair)
+		return
+	}
+
+	res, err := m.Depth(ctx, pair, levels)
+	if err != nil {
+		return
+	}
+
+	depth, ok := res[pair]
+	if !ok {
+		err = fmt.Errorf("no depth for %s", pair)
+		return
+	}
+
+	book = NewOrderBook(pair, levels, data.PairDecimals, data.LotDecimals)
+	book.Load(depth)
+	return
+}
+
+// findPair returns the asset pair with the given name, registering it if it
+// is not known so that results for new pairs are not dropped.
+func findPair(name string) pairs.AssetPair {
+	if pair, ok := pairs.Lookup(name); ok {
+		return pair
+	}
+
+	return pairs.Register(pairs.Info{Name: name})
+}
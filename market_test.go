@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -347,12 +348,12 @@ func TestMarket_Ohlc(t *testing.T) {
 				Data: []OhlcData{
 					{
 						Timestamp: time.Unix(1518774960, 0),
-						Open:      1196.0,
-						High:      1196.0,
-						Low:       1196.0,
-						Close:     1196.0,
-						Vwap:      0.0,
-						Volume:    0.00000000,
+						Open:      dec("1196.0"),
+						High:      dec("1196.0"),
+						Low:       dec("1196.0"),
+						Close:     dec("1196.0"),
+						Vwap:      dec("0.0"),
+						Volume:    dec("0.00000000"),
 						Count:     0,
 					},
 				},
@@ -402,15 +403,15 @@ func TestMarket_Depth(t *testing.T) {
 				pairs.BCHEUR: Depth{
 					Asks: []DepthItem{
 						{
-							Price:     1225.000000,
-							Volume:    3.729,
+							Price:     dec("1225.000000"),
+							Volume:    dec("3.729"),
 							Timestamp: time.Unix(1518899703, 0),
 						},
 					},
 					Bids: []DepthItem{
 						{
-							Price:     1222.600000,
-							Volume:    0.664,
+							Price:     dec("1222.600000"),
+							Volume:    dec("0.664"),
 							Timestamp: time.Unix(1518899718, 0),
 						},
 					},
@@ -426,25 +427,25 @@ func TestMarket_Depth(t *testing.T) {
 				pairs.BCHEUR: Depth{
 					Asks: []DepthItem{
 						{
-							Price:     1230.100000,
-							Volume:    14.673,
+							Price:     dec("1230.100000"),
+							Volume:    dec("14.673"),
 							Timestamp: time.Unix(1518900219, 0),
 						},
 						{
-							Price:     1231.300000,
-							Volume:    0.112,
+							Price:     dec("1231.300000"),
+							Volume:    dec("0.112"),
 							Timestamp: time.Unix(1518900211, 0),
 						},
 					},
 					Bids: []DepthItem{
 						{
-							Price:     1230.000000,
-							Volume:    0.486,
+							Price:     dec("1230.000000"),
+							Volume:    dec("0.486"),
 							Timestamp: time.Unix(1518900183, 0),
 						},
 						{
-							Price:     1229.800000,
-							Volume:    0.108,
+							Price:     dec("1229.800000"),
+							Volume:    dec("0.108"),
 							Timestamp: time.Unix(1518900204, 0),
 						},
 					},
@@ -494,9 +495,9 @@ func TestMarket_Trades(t *testing.T) {
 			expectedResponse: &TradesResponse{
 				Trades: []Trade{
 					{
-						Price:         700000,
-						Volume:        0.00050000,
-						Timestamp:     time.Unix(1501603433, 0),
+						Price:         dec("700000"),
+						Volume:        dec("0.00050000"),
+						Timestamp:     time.Unix(1501603433, 766900000),
 						BuySell:       TradeSell,
 						MarketLimit:   TradeLimit,
 						Miscellaneous: "",
@@ -546,8 +547,8 @@ func TestMarket_Spread(t *testing.T) {
 				Data: []SpreadData{
 					{
 						Timestamp: time.Unix(1518904771, 0),
-						Bid:       1225.6,
-						Ask:       1229.2,
+						Bid:       dec("1225.6"),
+						Ask:       dec("1229.2"),
 					},
 				},
 				Last: 1518905570,
@@ -577,3 +578,53 @@ func TestMarket_Spread(t *testing.T) {
 		})
 	}
 }
+
+func TestMarket_RefreshRegistry(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, AssetsResource):
+			w.Write([]byte(`{"error":[],"result":{"DOT":{"aclass":"currency","altname":"DOT","decimals":10,"display_decimals":8},"ZUSD":{"aclass":"currency","altname":"USD","decimals":4,"display_decimals":2}}}`))
+		case strings.HasSuffix(r.URL.Path, AssetPairsResource):
+			w.Write([]byte(`{"error":[],"result":{"DOTUSD":{"altname":"DOTUSD","wsname":"DOT/USD","base":"DOT","quote":"ZUSD","pair_decimals":4,"lot_decimals":8}}}`))
+		case strings.HasSuffix(r.URL.Path, TickerResource):
+			w.Write([]byte(`{"error":[],"result":{"DOTUSD":{"c":["6.5","1.0"]},"KSMUSD":{"c":["30.1","2.0"]}}}`))
+		}
+	}))
+
+	defer ts.Close()
+
+	k := New()
+	k.BaseURL = ts.URL
+
+	if err := k.Market.RefreshRegistry(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	pair, ok := pairs.Lookup("DOT/USD")
+	assert(true, ok, t)
+	assert("DOTUSD", pair.String(), t)
+
+	base, _ := pair.Base()
+	quote, _ := pair.Quote()
+	assert("DOT", base.String(), t)
+	assert(asset.ZUSD, quote, t)
+
+	info, _ := base.Info()
+	assert(asset.Info{Name: "DOT", Altname: "DOT", Decimals: 10, DisplayDecimals: 8}, info, t)
+
+	// Pairs which have not been registered are not dropped.
+	res, err := k.Market.Ticker(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(2, len(res), t)
+	assert([]string{"6.5", "1.0"}, res[pair].C, t)
+
+	ksm, ok := pairs.Lookup("KSMUSD")
+	assert(true, ok, t)
+	assert([]string{"30.1", "2.0"}, res[ksm].C, t)
+}
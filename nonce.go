@@ -0,0 +1,99 @@
+package gokraken
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultNonce is the nonce source used by clients which do not set one. It is
+// shared so that clients in the same process using one API key do not
+// generate conflicting nonces.
+var defaultNonce = &MonotonicNonce{}
+
+// NonceSource generates nonces for private Kraken API requests. Each nonce
+// must be greater than every nonce previously used with the API key.
+// https://www.kraken.com/en-gb/help/api#general-usage
+type NonceSource interface {
+	Nonce() (uint64, error)
+}
+
+// MonotonicNonce generates nonces from the current time in nanoseconds, which
+// are guaranteed to increase even if it is called concurrently or the wall
+// clock steps backwards. It is safe for concurrent use.
+type MonotonicNonce struct {
+	last uint64
+}
+
+// Nonce returns the next nonce.
+func (n *MonotonicNonce) Nonce() (uint64, error) {
+	for {
+		last := atomic.LoadUint64(&n.last)
+
+		next := uint64(time.Now().UnixNano())
+		if next <= last {
+			next = last + 1
+		}
+
+		if atomic.CompareAndSwapUint64(&n.last, last, next) {
+			return next, nil
+		}
+	}
+}
+
+// FileNonce generates nonces which are shared between processes on the same
+// host. The last nonce used is stored in a file which is locked while each
+// nonce is generated.
+type FileNonce struct {
+	Path string
+}
+
+// NewFileNonce returns a new FileNonce storing the last nonce at path.
+func NewFileNonce(path string) *FileNonce {
+	return &FileNonce{Path: path}
+}
+
+// Nonce returns the next nonce.
+func (n *FileNonce) Nonce() (nonce uint64, err error) {
+	f, err := os.OpenFile(n.Path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if err = lockFile(f); err != nil {
+		err = fmt.Errorf("could not lock nonce file: %s", err)
+		return
+	}
+	defer unlockFile(f)
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return
+	}
+
+	var last uint64
+	if s := strings.TrimSpace(string(b)); s != "" {
+		last, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			err = fmt.Errorf("could not parse nonce file: %s", err)
+			return
+		}
+	}
+
+	nonce = uint64(time.Now().UnixNano())
+	if nonce <= last {
+		nonce = last + 1
+	}
+
+	if err = f.Truncate(0); err != nil {
+		return
+	}
+
+	_, err = f.WriteAt([]byte(strconv.FormatUint(nonce, 10)), 0)
+	return
+}
@@ -0,0 +1,23 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package gokraken
+
+import (
+	"errors"
+	"os"
+)
+
+// errFileLockUnsupported is returned when file locking is not supported on the
+// current platform.
+var errFileLockUnsupported = errors.New("file locking is not supported on this platform")
+
+// lockFile acquires an exclusive lock on f, blocking until it is available.
+func lockFile(f *os.File) error {
+	return errFileLockUnsupported
+}
+
+// unlockFile releases the lock on f.
+func unlockFile(f *os.File) error {
+	return errFileLockUnsupported
+}
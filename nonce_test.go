@@ -0,0 +1,180 @@
+package gokraken
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingNonce is a nonce source which counts the nonces it generates.
+type countingNonce struct {
+	mu    sync.Mutex
+	count uint64
+}
+
+func (n *countingNonce) Nonce() (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.count++
+	return n.count, nil
+}
+
+// assertUniqueIncreasing generates nonces concurrently from each source and
+// asserts that every nonce is unique and each goroutine sees them increase.
+func assertUniqueIncreasing(sources []NonceSource, perSource int, t *testing.T) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[uint64]bool)
+		wg   sync.WaitGroup
+	)
+
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source NonceSource) {
+			defer wg.Done()
+
+			var last uint64
+			for i := 0; i < perSource; i++ {
+				nonce, err := source.Nonce()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if nonce <= last {
+					t.Errorf("%s: nonce %d not greater than %d", t.Name(), nonce, last)
+				}
+				last = nonce
+
+				mu.Lock()
+				if seen[nonce] {
+					t.Errorf("%s: duplicate nonce %d", t.Name(), nonce)
+				}
+				seen[nonce] = true
+				mu.Unlock()
+			}
+		}(source)
+	}
+
+	wg.Wait()
+}
+
+func TestMonotonicNonce_Nonce(t *testing.T) {
+	n := &MonotonicNonce{}
+
+	assertUniqueIncreasing([]NonceSource{n, n, n, n}, 1000, t)
+}
+
+func TestMonotonicNonce_ClockStepsBack(t *testing.T) {
+	future := uint64(time.Now().Add(time.Hour).UnixNano())
+	n := &MonotonicNonce{last: future}
+
+	nonce, err := n.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(future+1, nonce, t)
+}
+
+func TestFileNonce_Nonce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonce")
+
+	assertUniqueIncreasing([]NonceSource{NewFileNonce(path), NewFileNonce(path)}, 100, t)
+}
+
+func TestFileNonce_ClockStepsBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonce")
+
+	future := uint64(time.Now().Add(time.Hour).UnixNano())
+	if err := ioutil.WriteFile(path, []byte(strconv.FormatUint(future, 10)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	nonce, err := NewFileNonce(path).Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(future+1, nonce, t)
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(strconv.FormatUint(future+1, 10), string(b), t)
+}
+
+func TestFileNonce_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonce")
+	if err := ioutil.WriteFile(path, []byte("foo"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileNonce(path).Nonce(); err == nil {
+		t.Fatalf("%s: expected error", t.Name())
+	}
+}
+
+func TestKraken_CallNonceWindow(t *testing.T) {
+	cases := []struct {
+		name           string
+		nonceWindow    time.Duration
+		expectedNonces []string
+	}{
+		{
+			name:           "no window",
+			expectedNonces: []string{"2"},
+		},
+		{
+			name:           "within window",
+			nonceWindow:    time.Hour,
+			expectedNonces: []string{"1"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var nonces []string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				body, _ := url.ParseQuery(string(b))
+				nonces = append(nonces, body.Get(APINonceParam))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+
+				w.Write([]byte(`{"error":[],"result":{}}`))
+			}))
+
+			defer ts.Close()
+
+			k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+			k.BaseURL = ts.URL
+			k.Nonce = &countingNonce{}
+			k.NonceWindow = c.nonceWindow
+
+			// Fill the limiter so the call is held for around 10ms.
+			k.Limiter = NewRateLimiter(TierPro)
+			k.Limiter.api.value = k.Limiter.api.max - 0.99
+			k.Limiter.api.last = time.Now()
+
+			if _, err := k.UserData.Balance(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.expectedNonces, nonces, t)
+		})
+	}
+}
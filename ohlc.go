@@ -25,11 +25,11 @@ type OhlcResponse struct {
 // OhlcData represents a set of OHLC data from Kraken.
 type OhlcData struct {
 	Timestamp time.Time
-	Open      float64
-	High      float64
-	Low       float64
-	Close     float64
-	Vwap      float64
-	Volume    float64
+	Open      Decimal
+	High      Decimal
+	Low       Decimal
+	Close     Decimal
+	Vwap      Decimal
+	Volume    Decimal
 	Count     int
 }
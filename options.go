@@ -0,0 +1,99 @@
+package gokraken
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Option configures a Kraken client created by NewClient.
+type Option func(*Kraken)
+
+// WithCredentials sets the authentication credentials required for private
+// api endpoints.
+func WithCredentials(apiKey, privateKey string) Option {
+	return func(k *Kraken) {
+		k.APIKey = apiKey
+		k.PrivateKey = privateKey
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to make requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(k *Kraken) {
+		k.HTTPClient = httpClient
+	}
+}
+
+// WithBaseURL sets the base URL of the Kraken API.
+func WithBaseURL(baseURL string) Option {
+	return func(k *Kraken) {
+		k.BaseURL = baseURL
+	}
+}
+
+// WithUserAgent sets the user agent string applied to requests.
+func WithUserAgent(userAgent string) Option {
+	return func(k *Kraken) {
+		k.UserAgent = userAgent
+	}
+}
+
+// WithOTP sets a static two-factor password sent with private requests.
+func WithOTP(otp string) Option {
+	return func(k *Kraken) {
+		k.OTP = StaticOTP(otp)
+	}
+}
+
+// WithOTPSource sets the source of two-factor passwords sent with private
+// requests, such as a TOTP.
+func WithOTPSource(otp OTPSource) Option {
+	return func(k *Kraken) {
+		k.OTP = otp
+	}
+}
+
+// WithLimiter sets the rate limiter applied to private requests.
+func WithLimiter(limiter *RateLimiter) Option {
+	return func(k *Kraken) {
+		k.Limiter = limiter
+	}
+}
+
+// WithRetryPolicy sets the policy for retrying failed requests.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(k *Kraken) {
+		k.Retry = &policy
+	}
+}
+
+// WithOrderValidation validates orders against the precision and minimums of
+// their asset pair before they are added. If round is true, values with too
+// many decimal places are rounded instead of rejected.
+func WithOrderValidation(round bool) Option {
+	return func(k *Kraken) {
+		k.Validator = &OrderValidator{Round: round}
+	}
+}
+
+// WithNonceSource sets the source of nonces for private requests.
+func WithNonceSource(nonce NonceSource) Option {
+	return func(k *Kraken) {
+		k.Nonce = nonce
+	}
+}
+
+// WithNonceWindow sets the nonce window configured for the API key.
+func WithNonceWindow(window time.Duration) Option {
+	return func(k *Kraken) {
+		k.NonceWindow = window
+	}
+}
+
+// WithLogger sets the logger used to report retried requests.
+func WithLogger(logger *log.Logger) Option {
+	return func(k *Kraken) {
+		k.Logger = logger
+	}
+}
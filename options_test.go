@@ -0,0 +1,62 @@
+package gokraken
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	httpClient := &http.Client{
+		Timeout: 1 * time.Second,
+	}
+	limiter := NewRateLimiter(TierPro)
+	nonce := &MonotonicNonce{}
+	logger := log.New(new(bytes.Buffer), "", 0)
+
+	k := NewClient(
+		WithCredentials("foo", "bar"),
+		WithHTTPClient(httpClient),
+		WithBaseURL("https://api.foo.com"),
+		WithUserAgent("baz"),
+		WithOTP("qux"),
+		WithLimiter(limiter),
+		WithRetryPolicy(DefaultRetryPolicy),
+		WithNonceSource(nonce),
+		WithNonceWindow(time.Second),
+		WithLogger(logger),
+		WithOrderValidation(true),
+	)
+
+	assert("foo", k.APIKey, t)
+	assert("bar", k.PrivateKey, t)
+	assert(httpClient, k.HTTPClient, t)
+	assert("https://api.foo.com", k.BaseURL, t)
+	assert("baz", k.UserAgent, t)
+	assert(StaticOTP("qux"), k.OTP, t)
+	assert(limiter, k.Limiter, t)
+	assert(&DefaultRetryPolicy, k.Retry, t)
+	assert(nonce, k.Nonce, t)
+	assert(time.Second, k.NonceWindow, t)
+	assert(logger, k.Logger, t)
+	assert(true, k.Validator.Round, t)
+	assert(k.Market, k.Validator.Market, t)
+
+	if k.Market == nil || k.UserData == nil || k.Trading == nil || k.Funding == nil {
+		t.Fatalf("%s: nil service", t.Name())
+	}
+}
+
+func TestNewClient_Defaults(t *testing.T) {
+	k := NewClient()
+
+	if k.HTTPClient == nil {
+		t.Fatalf("%s: nil http client", t.Name())
+	}
+
+	if k.Retry != nil || k.Limiter != nil || k.OTP != nil || k.Validator != nil {
+		t.Fatalf("%s: unexpected optional configuration", t.Name())
+	}
+}
@@ -0,0 +1,424 @@
+package gokraken
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+const (
+	// checksumLevels is the number of levels on each side of the book
+	// included in Kraken's order book checksum.
+	checksumLevels = 10
+
+	// maxSkipHeight is the maximum number of lists a price level is linked
+	// into, enough for a million levels on each side of the book.
+	maxSkipHeight = 10
+)
+
+// ErrChecksumMismatch is returned when an order book no longer matches the
+// checksum sent by Kraken and cannot be resynced.
+var ErrChecksumMismatch = errors.New("order book checksum mismatch")
+
+// OrderBook is a locally maintained level 2 order book for an asset pair. It
+// is loaded from a snapshot and applies streamed updates, such as those from
+// the book channel of the Kraken WebSocket API. Updates must come from the
+// same stream as the snapshot, as Depth snapshots from the REST API are not
+// sequenced with them.
+//
+// The levels on each side are kept in a skip list ordered by price, so
+// finding, inserting and removing a level costs O(log n) in the number of
+// levels kept. It is safe to read the book from other goroutines while it is
+// being updated.
+type OrderBook struct {
+	Pair           pairs.AssetPair
+	Levels         int // Number of levels kept on each side of the book.
+	PriceDecimals  int // Decimal places of prices, used for checksums.
+	VolumeDecimals int // Decimal places of volumes, used for checksums.
+
+	// Resync requests a new snapshot of the book when it does not match the
+	// checksum of an update, such as by resubscribing to the book channel.
+	// The book is emptied and ignores updates until the snapshot is loaded.
+	// If nil, Update returns ErrChecksumMismatch.
+	Resync func(ctx context.Context) error
+
+	mu        sync.RWMutex
+	asks      priceLevels // Ordered by ascending price.
+	bids      priceLevels // Ordered by descending price.
+	resyncing bool        // Waiting for a snapshot after a mismatch.
+}
+
+// NewOrderBook returns a new empty OrderBook for the given pair, keeping the
+// given number of levels on each side of the book. The decimal places are
+// those of the pair in AssetPairData.
+func NewOrderBook(pair pairs.AssetPair, levels, priceDecimals, volumeDecimals int) *OrderBook {
+	return &OrderBook{
+		Pair:           pair,
+		Levels:         levels,
+		PriceDecimals:  priceDecimals,
+		VolumeDecimals: volumeDecimals,
+	}
+}
+
+// Load replaces the contents of the book with a snapshot, ending any resync.
+func (b *OrderBook) Load(depth Depth) {
+	var asks, bids priceLevels
+	for _, level := range depth.Asks {
+		asks.set(level, ascending)
+	}
+
+	for _, level := range depth.Bids {
+		bids.set(level, descending)
+	}
+
+	b.truncate(&asks)
+	b.truncate(&bids)
+
+	b.mu.Lock()
+	b.asks, b.bids = asks, bids
+	b.resyncing = false
+	b.mu.Unlock()
+}
+
+// Update applies changed levels to the book. Levels with a zero volume are
+// removed. If checksum is not zero it is verified against the updated book,
+// and the book is resynced if they do not match. Updates received while the
+// book is waiting for a new snapshot are ignored.
+func (b *OrderBook) Update(ctx context.Context, asks, bids []DepthItem, checksum uint32) error {
+	b.mu.Lock()
+	if b.resyncing {
+		b.mu.Unlock()
+		return nil
+	}
+
+	for _, level := range asks {
+		b.asks.set(level, ascending)
+	}
+
+	for _, level := range bids {
+		b.bids.set(level, descending)
+	}
+
+	b.truncate(&b.asks)
+	b.truncate(&b.bids)
+
+	valid := checksum == 0 || b.checksum() == checksum
+	if !valid && b.Resync != nil {
+		b.asks, b.bids, b.resyncing = priceLevels{}, priceLevels{}, true
+	}
+	b.mu.Unlock()
+
+	if valid {
+		return nil
+	}
+
+	if b.Resync == nil {
+		return ErrChecksumMismatch
+	}
+
+	if err := b.Resync(ctx); err != nil {
+		// Apply later updates to the empty book, so that the next mismatch
+		// tries again.
+		b.mu.Lock()
+		b.resyncing = false
+		b.mu.Unlock()
+
+		return fmt.Errorf("could not resync order book: %s", err)
+	}
+
+	return nil
+}
+
+// Resyncing returns whether the book is waiting for a new snapshot.
+func (b *OrderBook) Resyncing() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.resyncing
+}
+
+// Checksum returns Kraken's CRC32 checksum of the top levels of the book.
+func (b *OrderBook) Checksum() uint32 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.checksum()
+}
+
+// BestAsk returns the lowest ask, if there is one.
+func (b *OrderBook) BestAsk() (DepthItem, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.asks.first()
+}
+
+// BestBid returns the highest bid, if there is one.
+func (b *OrderBook) BestBid() (DepthItem, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.bids.first()
+}
+
+// Mid returns the price halfway between the best bid and ask, if the book has
+// both.
+func (b *OrderBook) Mid() (Decimal, bool) {
+	ask, bid, ok := b.top()
+	if !ok {
+		return Decimal{}, false
+	}
+
+	sum := ask.Price.Add(bid.Price)
+	return sum.Div(NewDecimal(2, 0), sum.Scale()+1), true
+}
+
+// Spread returns the difference between the best ask and bid prices, if the
+// book has both.
+func (b *OrderBook) Spread() (Decimal, bool) {
+	ask, bid, ok := b.top()
+	if !ok {
+		return Decimal{}, false
+	}
+
+	return ask.Price.Sub(bid.Price), true
+}
+
+// AskDepth returns the total volume of asks at or below the given price.
+func (b *OrderBook) AskDepth(price Decimal) (volume Decimal) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	b.asks.each(func(level DepthItem) bool {
+		if level.Price.Cmp(price) > 0 {
+			return false
+		}
+
+		volume = volume.Add(level.Volume)
+		return true
+	})
+
+	return
+}
+
+// BidDepth returns the total volume of bids at or above the given price.
+func (b *OrderBook) BidDepth(price Decimal) (volume Decimal) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	b.bids.each(func(level DepthItem) bool {
+		if level.Price.Cmp(price) < 0 {
+			return false
+		}
+
+		volume = volume.Add(level.Volume)
+		return true
+	})
+
+	return
+}
+
+// Snapshot returns a copy of the book, with asks sorted by ascending price and
+// bids by descending price.
+func (b *OrderBook) Snapshot() Depth {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return Depth{
+		Asks: b.asks.items(),
+		Bids: b.bids.items(),
+	}
+}
+
+// top returns the best ask and bid consistently.
+func (b *OrderBook) top() (ask, bid DepthItem, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	ask, askOK := b.asks.first()
+	bid, bidOK := b.bids.first()
+
+	return ask, bid, askOK && bidOK
+}
+
+// truncate drops the levels beyond the depth of the book.
+func (b *OrderBook) truncate(levels *priceLevels) {
+	for b.Levels > 0 && levels.count > b.Levels {
+		levels.removeLast()
+	}
+}
+
+// checksum calculates the checksum of the book. The caller must hold the lock.
+//
+// The checksum is the CRC32 of the prices and volumes of the top ten asks and
+// then the top ten bids, each formatted without the decimal point or leading
+// zeros.
+// https://docs.kraken.com/websockets/#book-checksum
+func (b *OrderBook) checksum() uint32 {
+	var buf bytes.Buffer
+
+	for _, levels := range []*priceLevels{&b.asks, &b.bids} {
+		n := 0
+		levels.each(func(level DepthItem) bool {
+			buf.WriteString(checksumValue(level.Price, b.PriceDecimals))
+			buf.WriteString(checksumValue(level.Volume, b.VolumeDecimals))
+
+			n++
+			return n < checksumLevels
+		})
+	}
+
+	return crc32.ChecksumIEEE(buf.Bytes())
+}
+
+// checksumValue formats a value for the order book checksum.
+func checksumValue(v Decimal, decimals int) string {
+	s := v.StringFixed(int32(decimals))
+	s = strings.Replace(s, ".", "", 1)
+
+	return strings.TrimLeft(s, "0")
+}
+
+// ascending orders asks by price.
+func ascending(a, b Decimal) bool { return a.Cmp(b) < 0 }
+
+// descending orders bids by price.
+func descending(a, b Decimal) bool { return a.Cmp(b) > 0 }
+
+// priceLevels is one side of an order book, kept in a skip list ordered by
+// price. Each level is linked into the lowest list and, with decreasing
+// probability, the lists above it, so a level is found by searching the
+// sparsest list first. The zero value is empty.
+type priceLevels struct {
+	head   levelNode // Links to the first level of each list.
+	height int       // Number of lists in use.
+	count  int       // Number of levels.
+	rand   *rand.Rand
+}
+
+// levelNode is a price level in a skip list.
+type levelNode struct {
+	level DepthItem
+	next  []*levelNode // Next level in each list the level is linked into.
+}
+
+// first returns the best level, if there is one.
+func (l *priceLevels) first() (DepthItem, bool) {
+	if l.height == 0 {
+		return DepthItem{}, false
+	}
+
+	return l.head.next[0].level, true
+}
+
+// each calls fn with each level in order until it returns false.
+func (l *priceLevels) each(fn func(DepthItem) bool) {
+	if l.height == 0 {
+		return
+	}
+
+	for n := l.head.next[0]; n != nil; n = n.next[0] {
+		if !fn(n.level) {
+			return
+		}
+	}
+}
+
+// items returns a copy of the levels in order, or nil if there are none.
+func (l *priceLevels) items() (items []DepthItem) {
+	l.each(func(level DepthItem) bool {
+		items = append(items, level)
+		return true
+	})
+
+	return
+}
+
+// set sets a price level, where the levels are ordered by less. A level with
+// a zero volume is removed.
+func (l *priceLevels) set(level DepthItem, less func(a, b Decimal) bool) {
+	if l.head.next == nil {
+		l.head.next = make([]*levelNode, maxSkipHeight)
+		l.rand = rand.New(rand.NewSource(1))
+	}
+
+	// Find the last level before the price in each list, which are the
+	// levels linked to the one being inserted or removed.
+	var prev [maxSkipHeight]*levelNode
+	n := &l.head
+	for h := maxSkipHeight - 1; h >= 0; h-- {
+		for n.next[h] != nil && less(n.next[h].level.Price, level.Price) {
+			n = n.next[h]
+		}
+		prev[h] = n
+	}
+
+	n = n.next[0]
+	exists := n != nil && n.level.Price.Equal(level.Price)
+
+	switch {
+	case exists && level.Volume.IsZero():
+		l.unlink(n, prev[:])
+	case exists:
+		n.level = level
+	case !level.Volume.IsZero():
+		n = &levelNode{level: level, next: make([]*levelNode, l.randomHeight())}
+		for h := range n.next {
+			n.next[h], prev[h].next[h] = prev[h].next[h], n
+		}
+
+		if len(n.next) > l.height {
+			l.height = len(n.next)
+		}
+		l.count++
+	}
+}
+
+// removeLast removes the worst level.
+func (l *priceLevels) removeLast() {
+	if l.height == 0 {
+		return
+	}
+
+	// Find the level before the last in each list.
+	var prev [maxSkipHeight]*levelNode
+	n := &l.head
+	for h := l.height - 1; h >= 0; h-- {
+		for n.next[h] != nil && n.next[h].next[0] != nil {
+			n = n.next[h]
+		}
+		prev[h] = n
+	}
+
+	l.unlink(prev[0].next[0], prev[:])
+}
+
+// unlink removes a level, given the level before it in each list.
+func (l *priceLevels) unlink(n *levelNode, prev []*levelNode) {
+	for h := range n.next {
+		prev[h].next[h] = n.next[h]
+	}
+
+	for l.height > 0 && l.head.next[l.height-1] == nil {
+		l.height--
+	}
+	l.count--
+}
+
+// randomHeight returns the number of lists to link a new level into, where
+// each list holds a quarter of the levels of the list below.
+func (l *priceLevels) randomHeight() int {
+	h := 1
+	for h < maxSkipHeight && l.rand.Intn(4) == 0 {
+		h++
+	}
+
+	return h
+}
@@ -0,0 +1,245 @@
+package gokraken
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// checksumDepth is the example book from the Kraken checksum documentation.
+var checksumDepth = Depth{
+	Asks: []DepthItem{
+		{Price: dec("0.05005"), Volume: dec("0.00000500")},
+		{Price: dec("0.05010"), Volume: dec("0.00000500")},
+		{Price: dec("0.05015"), Volume: dec("0.00000500")},
+		{Price: dec("0.05020"), Volume: dec("0.00000500")},
+		{Price: dec("0.05025"), Volume: dec("0.00000500")},
+		{Price: dec("0.05030"), Volume: dec("0.00000500")},
+		{Price: dec("0.05035"), Volume: dec("0.00000500")},
+		{Price: dec("0.05040"), Volume: dec("0.00000500")},
+		{Price: dec("0.05045"), Volume: dec("0.00000500")},
+		{Price: dec("0.05050"), Volume: dec("0.00000500")},
+	},
+	Bids: []DepthItem{
+		{Price: dec("0.05000"), Volume: dec("0.00000500")},
+		{Price: dec("0.04995"), Volume: dec("0.00000500")},
+		{Price: dec("0.04990"), Volume: dec("0.00000500")},
+		{Price: dec("0.04980"), Volume: dec("0.00000500")},
+		{Price: dec("0.04975"), Volume: dec("0.00000500")},
+		{Price: dec("0.04970"), Volume: dec("0.00000500")},
+		{Price: dec("0.04965"), Volume: dec("0.00000500")},
+		{Price: dec("0.04960"), Volume: dec("0.00000500")},
+		{Price: dec("0.04955"), Volume: dec("0.00000500")},
+		{Price: dec("0.04950"), Volume: dec("0.00000500")},
+	},
+}
+
+func TestOrderBook_Checksum(t *testing.T) {
+	book := NewOrderBook(pairs.XETHXXBT, 10, 5, 8)
+	book.Load(checksumDepth)
+
+	assert(uint32(974947235), book.Checksum(), t)
+}
+
+func TestOrderBook_Update(t *testing.T) {
+	book := NewOrderBook(pairs.XXBTZUSD, 3, 1, 8)
+	book.Load(Depth{
+		Asks: []DepthItem{{Price: dec("102"), Volume: dec("1")}, {Price: dec("101"), Volume: dec("2")}, {Price: dec("103"), Volume: dec("3")}},
+		Bids: []DepthItem{{Price: dec("99"), Volume: dec("1")}, {Price: dec("100"), Volume: dec("2")}, {Price: dec("98"), Volume: dec("3")}},
+	})
+
+	// Replace, remove and insert levels; the worst levels are truncated.
+	asks := []DepthItem{{Price: dec("101"), Volume: dec("5")}, {Price: dec("102"), Volume: dec("0")}, {Price: dec("100.5"), Volume: dec("1")}}
+	bids := []DepthItem{{Price: dec("99.5"), Volume: dec("4")}, {Price: dec("97"), Volume: dec("1")}}
+
+	expected := Depth{
+		Asks: []DepthItem{{Price: dec("100.5"), Volume: dec("1")}, {Price: dec("101"), Volume: dec("5")}, {Price: dec("103"), Volume: dec("3")}},
+		Bids: []DepthItem{{Price: dec("100"), Volume: dec("2")}, {Price: dec("99.5"), Volume: dec("4")}, {Price: dec("99"), Volume: dec("1")}},
+	}
+
+	updated := NewOrderBook(pairs.XXBTZUSD, 3, 1, 8)
+	updated.Load(expected)
+
+	if err := book.Update(context.Background(), asks, bids, updated.Checksum()); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(expected, book.Snapshot(), t)
+
+	ask, _ := book.BestAsk()
+	bid, _ := book.BestBid()
+	mid, _ := book.Mid()
+	spread, _ := book.Spread()
+
+	assert(dec("100.5"), ask.Price, t)
+	assert(dec("100"), bid.Price, t)
+	assert(dec("100.25"), mid, t)
+	assert(dec("0.5"), spread, t)
+	assert(dec("6"), book.AskDepth(dec("101")), t)
+	assert(dec("6"), book.BidDepth(dec("99.5")), t)
+	assert(Decimal{}, book.AskDepth(dec("100")), t)
+}
+
+func TestOrderBook_UpdateMismatch(t *testing.T) {
+	book := NewOrderBook(pairs.XETHXXBT, 10, 5, 8)
+	book.Load(checksumDepth)
+
+	err := book.Update(context.Background(), []DepthItem{{Price: dec("0.05005"), Volume: dec("1")}}, nil, 974947235)
+	assert(ErrChecksumMismatch, err, t)
+
+	// With a resync function the book is emptied until a new snapshot is
+	// loaded, ignoring updates in the meantime.
+	var resyncs int
+	book.Resync = func(ctx context.Context) error {
+		resyncs++
+		return nil
+	}
+
+	if err = book.Update(context.Background(), []DepthItem{{Price: dec("0.05005"), Volume: dec("1")}}, nil, 974947235); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = book.Update(context.Background(), []DepthItem{{Price: dec("0.05006"), Volume: dec("1")}}, nil, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(1, resyncs, t)
+	assert(true, book.Resyncing(), t)
+	assert(Depth{}, book.Snapshot(), t)
+
+	book.Load(checksumDepth)
+	assert(false, book.Resyncing(), t)
+	assert(checksumDepth, book.Snapshot(), t)
+}
+
+func TestOrderBook_ResyncError(t *testing.T) {
+	book := NewOrderBook(pairs.XETHXXBT, 10, 5, 8)
+	book.Load(checksumDepth)
+
+	book.Resync = func(ctx context.Context) error {
+		return errors.New("not connected")
+	}
+
+	err := book.Update(context.Background(), []DepthItem{{Price: dec("0.05005"), Volume: dec("1")}}, nil, 974947235)
+	assert("could not resync order book: not connected", err.Error(), t)
+	assert(false, book.Resyncing(), t)
+}
+
+func TestOrderBook_ManyLevels(t *testing.T) {
+	book := NewOrderBook(pairs.XXBTZUSD, 500, 0, 0)
+
+	// Apply random changes to a wide range of prices and check the book
+	// against the levels set, truncated to the best 500 after each change.
+	rng := rand.New(rand.NewSource(42))
+	volumes := make(map[int64]int64)
+	for i := 0; i < 20000; i++ {
+		price, volume := rng.Int63n(2000), rng.Int63n(3)
+		if volume == 0 {
+			delete(volumes, price)
+		} else {
+			volumes[price] = volume
+		}
+
+		if len(volumes) > 500 {
+			var worst int64
+			for p := range volumes {
+				if p > worst {
+					worst = p
+				}
+			}
+			delete(volumes, worst)
+		}
+
+		book.Update(context.Background(), []DepthItem{{Price: NewDecimal(price, 0), Volume: NewDecimal(volume, 0)}}, nil, 0)
+	}
+
+	var expected []DepthItem
+	for price := int64(0); price < 2000 && len(expected) < 500; price++ {
+		if volumes[price] != 0 {
+			expected = append(expected, DepthItem{Price: NewDecimal(price, 0), Volume: NewDecimal(volumes[price], 0)})
+		}
+	}
+
+	asks := book.Snapshot().Asks
+	assert(len(expected), len(asks), t)
+	for i := range asks {
+		if !asks[i].Price.Equal(expected[i].Price) || !asks[i].Volume.Equal(expected[i].Volume) {
+			t.Fatalf("%s: level %d: expected %v, got %v", t.Name(), i, expected[i], asks[i])
+		}
+	}
+}
+
+func TestOrderBook_Empty(t *testing.T) {
+	book := NewOrderBook(pairs.XXBTZUSD, 10, 1, 8)
+
+	_, ok := book.BestAsk()
+	assert(false, ok, t)
+
+	_, ok = book.Mid()
+	assert(false, ok, t)
+}
+
+func TestOrderBook_Concurrent(t *testing.T) {
+	book := NewOrderBook(pairs.XETHXXBT, 10, 5, 8)
+	book.Load(checksumDepth)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			book.Update(context.Background(), []DepthItem{{Price: dec("0.05"), Volume: NewDecimal(int64(i%2), 0)}}, nil, 0)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			snapshot := book.Snapshot()
+			if len(snapshot.Asks) > 10 {
+				t.Errorf("%s: book not truncated", t.Name())
+			}
+		}
+	}()
+
+	wg.Wait()
+}
+
+func TestMarket_OrderBook(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, AssetPairsResource):
+			w.Write([]byte(`{"error":[],"result":{"XXBTZUSD":{"altname":"XBTUSD","pair_decimals":1,"lot_decimals":8}}}`))
+		case strings.HasSuffix(r.URL.Path, DepthResource):
+			w.Write([]byte(`{"error":[],"result":{"XXBTZUSD":{"asks":[["6500.1","1.000",1534614248]],"bids":[["6499.9","2.000",1534614248]]}}}`))
+		}
+	}))
+
+	defer ts.Close()
+
+	k := New()
+	k.BaseURL = ts.URL
+
+	book, err := k.Market.OrderBook(context.Background(), pairs.XXBTZUSD, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(1, book.PriceDecimals, t)
+	assert(8, book.VolumeDecimals, t)
+
+	spread, _ := book.Spread()
+	assert(dec("0.2"), spread, t)
+	assert(false, book.Resync != nil, t)
+}
@@ -1,6 +1,11 @@
 package gokraken
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/danmrichards/gokraken/pairs"
@@ -10,9 +15,22 @@ const (
 	// AddOrderResource is the API resource for adding orders.
 	AddOrderResource = "AddOrder"
 
+	// AddOrderBatchResource is the API resource for adding a batch of orders.
+	AddOrderBatchResource = "AddOrderBatch"
+
+	// EditOrderResource is the API resource for editing open orders.
+	EditOrderResource = "EditOrder"
+
 	// CancelOrderResource is the API resource for canceling open orders.
 	CancelOrderResource = "CancelOrder"
 
+	// CancelAllResource is the API resource for canceling all open orders.
+	CancelAllResource = "CancelAll"
+
+	// CancelAllOrdersAfterResource is the API resource for canceling all open
+	// orders after a timeout.
+	CancelAllOrdersAfterResource = "CancelAllOrdersAfter"
+
 	// ClosedOrdersResource is the API resource for closed orders.
 	ClosedOrdersResource = "ClosedOrders"
 
@@ -31,6 +49,21 @@ const (
 	// OpenOrdersResource is the API resource for open orders.
 	OpenOrdersResource = "OpenOrders"
 
+	// OrderStatusPending indicates that an order is pending book entry.
+	OrderStatusPending OrderStatus = "pending"
+
+	// OrderStatusOpen indicates that an order is open.
+	OrderStatusOpen OrderStatus = "open"
+
+	// OrderStatusClosed indicates that an order is closed.
+	OrderStatusClosed OrderStatus = "closed"
+
+	// OrderStatusCanceled indicates that an order was canceled.
+	OrderStatusCanceled OrderStatus = "canceled"
+
+	// OrderStatusExpired indicates that an order expired.
+	OrderStatusExpired OrderStatus = "expired"
+
 	// OrderFlagViqc is an order flag for volume in quote currency
 	// (not available for leveraged orders).
 	OrderFlagViqc OrderFlag = "viqc"
@@ -109,9 +142,43 @@ type OrderCloseTime string
 // of the Kraken API.
 type QueryOrdersResponse map[string]Order
 
+// OrderStatus is the status of a Kraken order.
+type OrderStatus string
+
 // OrderFlag is a flag to use when creating a Kraken order.
 type OrderFlag string
 
+// SplitList splits a comma delimited list, such as the miscellaneous info of
+// an order or trade, returning nil for an empty list.
+func SplitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+// ParseOrderFlags parses a comma delimited list of order flags, as returned by
+// Kraken.
+func ParseOrderFlags(s string) (flags []OrderFlag) {
+	for _, flag := range SplitList(s) {
+		flags = append(flags, OrderFlag(flag))
+	}
+
+	return
+}
+
+// JoinOrderFlags joins order flags as a comma delimited list, as sent to
+// Kraken.
+func JoinOrderFlags(flags []OrderFlag) string {
+	strs := make([]string, len(flags))
+	for i, flag := range flags {
+		strs[i] = string(flag)
+	}
+
+	return strings.Join(strs, ",")
+}
+
 // OrderType is a type of Kraken order.
 type OrderType string
 
@@ -120,24 +187,80 @@ type Order struct {
 	TransactionID  string           `json:"-"`
 	ReferenceID    string           `json:"refid"`
 	UserRef        int64            `json:"userref"`
-	Status         string           `json:"status"`
-	OpenTime       float64          `json:"opentm"`
-	StartTime      float64          `json:"starttm"`
-	ExpireTime     float64          `json:"expiretm"`
+	Status         OrderStatus      `json:"status"`
+	OpenTime       time.Time        `json:"opentm"`
+	StartTime      time.Time        `json:"starttm"`
+	ExpireTime     time.Time        `json:"expiretm"`
 	Description    OrderDescription `json:"descr"`
-	Volume         string           `json:"vol"`
-	VolumeExecuted float64          `json:"vol_exec,string"`
-	Cost           float64          `json:"cost,string"`
-	Fee            float64          `json:"fee,string"`
-	Price          float64          `json:"price,string"`
-	StopPrice      float64          `json:"stopprice.string"`
-	LimitPrice     float64          `json:"limitprice,string"`
-	Misc           string           `json:"misc"`
-	OrderFlags     string           `json:"oflags"`
-	CloseTime      float64          `json:"closetm"`
+	Volume         Decimal          `json:"vol"`
+	VolumeExecuted Decimal          `json:"vol_exec"`
+	Cost           Decimal          `json:"cost"`
+	Fee            Decimal          `json:"fee"`
+	Price          Decimal          `json:"price"`
+	StopPrice      Decimal          `json:"stopprice"`
+	LimitPrice     Decimal          `json:"limitprice"`
+	Misc           []string         `json:"misc"`
+	OrderFlags     []OrderFlag      `json:"oflags"`
+	CloseTime      time.Time        `json:"closetm"`
 	Reason         string           `json:"reason"`
 }
 
+// UnmarshalJSON parses the JSON-encoded data and stores the result
+// in the value pointed to by o.
+//
+// Kraken encodes the times of an order as Unix timestamps with fractional
+// seconds, and its miscellaneous info and flags as comma delimited lists, so
+// these fields are unmarshalled into an auxiliary struct and then converted.
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type order Order
+	aux := struct {
+		*order
+		OpenTime   json.Number `json:"opentm"`
+		StartTime  json.Number `json:"starttm"`
+		ExpireTime json.Number `json:"expiretm"`
+		CloseTime  json.Number `json:"closetm"`
+		Misc       string      `json:"misc"`
+		OrderFlags string      `json:"oflags"`
+	}{
+		order: (*order)(o),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	o.Misc = SplitList(aux.Misc)
+	o.OrderFlags = ParseOrderFlags(aux.OrderFlags)
+
+	return parseUnixTimes(
+		[]json.Number{aux.OpenTime, aux.StartTime, aux.ExpireTime, aux.CloseTime},
+		&o.OpenTime, &o.StartTime, &o.ExpireTime, &o.CloseTime,
+	)
+}
+
+// MarshalJSON encodes the order in the format used by Kraken, so that it
+// round trips through UnmarshalJSON.
+func (o Order) MarshalJSON() ([]byte, error) {
+	type order Order
+	return json.Marshal(struct {
+		order
+		OpenTime   json.Number `json:"opentm"`
+		StartTime  json.Number `json:"starttm"`
+		ExpireTime json.Number `json:"expiretm"`
+		CloseTime  json.Number `json:"closetm"`
+		Misc       string      `json:"misc"`
+		OrderFlags string      `json:"oflags"`
+	}{
+		order:      order(o),
+		OpenTime:   unixNumber(o.OpenTime),
+		StartTime:  unixNumber(o.StartTime),
+		ExpireTime: unixNumber(o.ExpireTime),
+		CloseTime:  unixNumber(o.CloseTime),
+		Misc:       strings.Join(o.Misc, ","),
+		OrderFlags: JoinOrderFlags(o.OrderFlags),
+	})
+}
+
 // OrderDescription describes the detail of an order including the assets pair
 // involved, prices and the order type.
 type OrderDescription struct {
@@ -148,7 +271,7 @@ type OrderDescription struct {
 	OrderType      OrderType       `json:"ordertype"`
 	PrimaryPrice   string          `json:"price"`
 	SecondaryPrice string          `json:"price2"`
-	Type           string          `json:"type"`
+	Type           TradeBuySell    `json:"type"`
 }
 
 // OpenOrdersResponse represents the response from the OpenOrders endpoint
@@ -158,14 +281,15 @@ type OpenOrdersResponse struct {
 	Count int              `json:"count"`
 }
 
-// UserOrder represents a user request to add an order.
+// UserOrder represents a user request to add an order. Prices may be absolute
+// or relative to the last traded price.
 type UserOrder struct {
 	Pair              pairs.AssetPair // Asset pair.
 	Type              TradeBuySell    // Type of order (buy/sell).
 	OrderType         OrderType       // Order type.
-	Price             float64         // Price.
-	Price2            float64         // Secondary price.
-	Volume            float64         // Order volume in lots.
+	Price             OrderPrice      // Price.
+	Price2            OrderPrice      // Secondary price.
+	Volume            Decimal         // Order volume in lots.
 	Leverage          string          // Amount of leverage desired.
 	OFlags            []OrderFlag     // List of order flags.
 	StartTm           string          // Scheduled start time: 0 (now - default), +<n> (schedule start time <n> seconds from now) , <n> (unix timestamp of start time).
@@ -173,8 +297,160 @@ type UserOrder struct {
 	UserRef           int             // User reference id.
 	Validate          bool            // Validate inputs only.
 	CloseOrderType    OrderType       // Type of closing order to add to system when order gets filled.
-	ClosedOrderPrice  float64         // Price of closing order to add to system when order gets filled.
-	ClosedOrderPrice2 float64         // Secondary price of closing order to add to system when order gets filled.
+	ClosedOrderPrice  OrderPrice      // Price of closing order to add to system when order gets filled.
+	ClosedOrderPrice2 OrderPrice      // Secondary price of closing order to add to system when order gets filled.
+}
+
+// Values returns the order as Kraken API request parameters.
+func (o UserOrder) Values() url.Values {
+	body := url.Values{
+		"pair":      {o.Pair.String()},
+		"type":      {string(o.Type)},
+		"ordertype": {string(o.OrderType)},
+		"volume":    {o.Volume.String()},
+	}
+
+	if !o.Price.IsZero() {
+		body.Add("price", o.Price.String())
+	}
+
+	if !o.Price2.IsZero() {
+		body.Add("price2", o.Price2.String())
+	}
+
+	if o.Leverage != "" {
+		body.Add("leverage", o.Leverage)
+	}
+
+	if len(o.OFlags) > 0 {
+		body.Add("oflags", JoinOrderFlags(o.OFlags))
+	}
+
+	if o.StartTm != "" {
+		body.Add("starttm", o.StartTm)
+	}
+
+	if o.ExpireTm != "" {
+		body.Add("expiretm", o.ExpireTm)
+	}
+
+	if o.UserRef != 0 {
+		body.Add("userref", strconv.Itoa(o.UserRef))
+	}
+
+	if o.Validate {
+		body.Add("validate", "true")
+	}
+
+	if string(o.CloseOrderType) != "" {
+		body.Add("close[ordertype]", string(o.CloseOrderType))
+	}
+
+	if !o.ClosedOrderPrice.IsZero() {
+		body.Add("close[price]", o.ClosedOrderPrice.String())
+	}
+
+	if !o.ClosedOrderPrice2.IsZero() {
+		body.Add("close[price2]", o.ClosedOrderPrice2.String())
+	}
+
+	return body
+}
+
+// AddOrderBatchResponse represents the response from the AddOrderBatch
+// endpoint of the Kraken API.
+type AddOrderBatchResponse struct {
+	Orders []BatchOrderResult `json:"orders"` // Results in the order of the batch.
+}
+
+// BatchOrderResult is the result of adding one order of a batch.
+type BatchOrderResult struct {
+	Description OrderDescription `json:"descr"`
+	TxID        string           `json:"txid"`
+	Error       string           `json:"error"`
+}
+
+// Err returns the error which prevented the order being added as an
+// *APIError, or nil if it was added.
+func (r BatchOrderResult) Err() error {
+	if r.Error == "" {
+		return nil
+	}
+
+	return ParseAPIError(r.Error)
+}
+
+// EditOrderRequest represents a user request to edit an open order. Zero
+// values leave the order unchanged.
+type EditOrderRequest struct {
+	TxID       TxID            // Transaction id of the order to edit.
+	UserRef    int64           // User reference id of the order to edit, if TxID is empty.
+	Pair       pairs.AssetPair // Asset pair of the order.
+	Price      OrderPrice      // New price.
+	Price2     OrderPrice      // New secondary price.
+	Volume     Decimal         // New order volume in lots.
+	OFlags     []OrderFlag     // New list of order flags.
+	Deadline   time.Time       // Time after which Kraken rejects the edit.
+	NewUserRef int64           // New user reference id.
+	Validate   bool            // Validate inputs only.
+}
+
+// OrderID returns the transaction id, or else the user reference id, of the
+// order to edit. It returns a ValidationError if the edit has neither or the
+// transaction id is not in the format used by Kraken.
+func (e EditOrderRequest) OrderID() (string, error) {
+	switch {
+	case e.TxID != "" && !e.TxID.Valid():
+		return "", ValidationError{{Field: "TxID", Message: fmt.Sprintf("invalid txid %q", string(e.TxID))}}
+	case e.TxID != "":
+		return e.TxID.String(), nil
+	case e.UserRef != 0:
+		return strconv.FormatInt(e.UserRef, 10), nil
+	}
+
+	return "", ValidationError{{Field: "TxID", Message: "no order to edit, set TxID or UserRef"}}
+}
+
+// Values returns the edit as Kraken API request parameters. The txid is
+// omitted if OrderID returns an error.
+func (e EditOrderRequest) Values() url.Values {
+	body := url.Values{
+		"pair": {e.Pair.String()},
+	}
+
+	if orderID, err := e.OrderID(); err == nil {
+		body.Set("txid", orderID)
+	}
+
+	if !e.Price.IsZero() {
+		body.Add("price", e.Price.String())
+	}
+
+	if !e.Price2.IsZero() {
+		body.Add("price2", e.Price2.String())
+	}
+
+	if !e.Volume.IsZero() {
+		body.Add("volume", e.Volume.String())
+	}
+
+	if len(e.OFlags) > 0 {
+		body.Add("oflags", JoinOrderFlags(e.OFlags))
+	}
+
+	if !e.Deadline.IsZero() {
+		body.Add("deadline", e.Deadline.UTC().Format(time.RFC3339Nano))
+	}
+
+	if e.NewUserRef != 0 {
+		body.Add("userref", strconv.FormatInt(e.NewUserRef, 10))
+	}
+
+	if e.Validate {
+		body.Add("validate", "true")
+	}
+
+	return body
 }
 
 // AddOrderResponse represents the response from the AddOrder endpoint
@@ -184,9 +460,32 @@ type AddOrderResponse struct {
 	TxIDs       []string         `json:"txid"`
 }
 
+// EditOrderResponse represents the response from the EditOrder endpoint of
+// the Kraken API.
+type EditOrderResponse struct {
+	Description     OrderDescription `json:"descr"`
+	TxID            string           `json:"txid"`             // Transaction id of the new order.
+	OriginalTxID    string           `json:"originaltxid"`     // Transaction id of the order edited.
+	Status          string           `json:"status"`           // Status of the edit, "ok" or "err".
+	ErrorMessage    string           `json:"error_message"`    // Reason the edit failed.
+	OrdersCancelled int              `json:"orders_cancelled"` // Number of orders cancelled by the edit.
+	NewUserRef      int64            `json:"newuserref"`
+	OldUserRef      int64            `json:"olduserref"`
+	Volume          Decimal          `json:"volume"`
+	Price           Decimal          `json:"price"`
+	Price2          Decimal          `json:"price2"`
+}
+
 // CancelOrderResponse represents the response from the CancelOrder endpoint
 // of the Kraken API.
 type CancelOrderResponse struct {
 	Count   int  `json:"count"`
 	Pending bool `json:"pending"`
 }
+
+// CancelAllOrdersAfterResponse represents the response from the
+// CancelAllOrdersAfter endpoint of the Kraken API.
+type CancelAllOrdersAfterResponse struct {
+	CurrentTime time.Time // Time the request was processed.
+	TriggerTime time.Time // Time at which orders will be cancelled, or zero if disabled.
+}
@@ -0,0 +1,95 @@
+package gokraken
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+func TestOrder_UnmarshalJSON(t *testing.T) {
+	// Recorded from the ClosedOrders endpoint.
+	payload := []byte(`{"refid":"None","userref":42,"status":"closed","reason":null,"opentm":1688666559.8974,"closetm":1688666559.9029,"starttm":0,"expiretm":0,"descr":{"pair":"XBTUSD","type":"buy","ordertype":"stop-loss-limit","price":"30010.0","price2":"30020.0","leverage":"none","order":"buy 0.50000000 XBTUSD @ stop loss 30010.0 -> limit 30020.0","close":""},"vol":"0.50000000","vol_exec":"0.50000000","cost":"15005.00000","fee":"24.00800","price":"30010.0","stopprice":"30010.00000","limitprice":"30020.00000","misc":"stopped,touched","oflags":"fciq,post","trigger":"index","trades":["TZX2WP-XSEOP-FP7WYR"]}`)
+
+	var order Order
+	if err := json.Unmarshal(payload, &order); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(Order{
+		ReferenceID: "None",
+		UserRef:     42,
+		Status:      OrderStatusClosed,
+		OpenTime:    time.Unix(1688666559, 897400000),
+		Description: OrderDescription{
+			AssetPair:      pairs.XXBTZUSD,
+			Leverage:       "none",
+			Order:          "buy 0.50000000 XBTUSD @ stop loss 30010.0 -> limit 30020.0",
+			OrderType:      OrderTypeStopLossLimit,
+			PrimaryPrice:   "30010.0",
+			SecondaryPrice: "30020.0",
+			Type:           TradeBuy,
+		},
+		Volume:         dec("0.5"),
+		VolumeExecuted: dec("0.5"),
+		Cost:           dec("15005"),
+		Fee:            dec("24.008"),
+		Price:          dec("30010"),
+		StopPrice:      dec("30010"),
+		LimitPrice:     dec("30020"),
+		Misc:           []string{"stopped", "touched"},
+		OrderFlags:     []OrderFlag{OrderFlagFciq, OrderFlagPost},
+		CloseTime:      time.Unix(1688666559, 902900000),
+	}, order, t)
+
+	assert(true, order.StartTime.IsZero(), t)
+	assert(true, order.ExpireTime.IsZero(), t)
+
+	if err := json.Unmarshal([]byte(`{"opentm":"soon"}`), &order); err == nil {
+		t.Fatal("expected an invalid time error")
+	}
+}
+
+func TestOrder_MarshalJSON(t *testing.T) {
+	payload := []byte(`{"refid":"None","userref":42,"status":"closed","opentm":1688666559.8974,"closetm":1688666559.9029,"starttm":0,"expiretm":0,"descr":{"pair":"XBTUSD","type":"buy","ordertype":"limit","price":"30010.0"},"vol":"0.50000000","price":"30010.0","misc":"stopped,touched","oflags":"fciq,post"}`)
+
+	var order Order
+	if err := json.Unmarshal(payload, &order); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Times and lists are written as Kraken sends them.
+	var wire map[string]interface{}
+	if err = json.Unmarshal(b, &wire); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(1688666559.8974, wire["opentm"], t)
+	assert(float64(0), wire["starttm"], t)
+	assert("stopped,touched", wire["misc"], t)
+	assert("fciq,post", wire["oflags"], t)
+
+	var decoded Order
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(order, decoded, t)
+}
+
+func TestOrderFlags(t *testing.T) {
+	flags := ParseOrderFlags("fciq,post")
+	assert([]OrderFlag{OrderFlagFciq, OrderFlagPost}, flags, t)
+	assert("fciq,post", JoinOrderFlags(flags), t)
+
+	assert([]OrderFlag(nil), ParseOrderFlags(""), t)
+	assert("", JoinOrderFlags(nil), t)
+	assert([]string(nil), SplitList(""), t)
+	assert([]string{"stopped", "touched"}, SplitList("stopped,touched"), t)
+}
@@ -0,0 +1,103 @@
+package gokraken
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// APIOTPParam is the parameter to send the two-factor password to Kraken in.
+	APIOTPParam = "otp"
+
+	// TOTPDigits is the default number of digits in a TOTP password.
+	TOTPDigits = 6
+
+	// TOTPPeriod is the default period for which a TOTP password is valid.
+	TOTPPeriod = 30 * time.Second
+)
+
+// OTPSource provides the two-factor password for private Kraken API requests
+// when one is set on the API key.
+type OTPSource interface {
+	OTP() (string, error)
+}
+
+// StaticOTP is a static two-factor password.
+type StaticOTP string
+
+// OTP returns the password.
+func (s StaticOTP) OTP() (string, error) {
+	return string(s), nil
+}
+
+// TOTP generates time-based one-time passwords from a shared secret, as
+// described in RFC 6238. It is compatible with the Google Authenticator
+// two-factor method on Kraken API keys.
+// https://tools.ietf.org/html/rfc6238
+type TOTP struct {
+	Secret []byte
+	Digits int           // Number of digits in the password, TOTPDigits if zero.
+	Period time.Duration // Period for which a password is valid, TOTPPeriod if zero.
+
+	now func() time.Time
+}
+
+// NewTOTP returns a new TOTP for the given base32 encoded secret, as shown
+// by Kraken when setting up two-factor authentication on an API key.
+func NewTOTP(secret string) (*TOTP, error) {
+	secret = strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	secret = strings.TrimRight(secret, "=")
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode totp secret: %s", err)
+	}
+
+	return &TOTP{Secret: key}, nil
+}
+
+// OTP returns the password for the current time.
+func (t *TOTP) OTP() (string, error) {
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+
+	return t.At(now()), nil
+}
+
+// At returns the password for the given time.
+func (t *TOTP) At(tm time.Time) string {
+	digits := t.Digits
+	if digits == 0 {
+		digits = TOTPDigits
+	}
+
+	period := t.Period
+	if period == 0 {
+		period = TOTPPeriod
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(tm.Unix()/int64(period/time.Second)))
+
+	mac := hmac.New(sha1.New, t.Secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation as described in RFC 4226.
+	offset := sum[len(sum)-1] & 0xf
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, code%mod)
+}
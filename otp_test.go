@@ -0,0 +1,107 @@
+package gokraken
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTOTP_At(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B for SHA1.
+	totp := &TOTP{
+		Secret: []byte("12345678901234567890"),
+		Digits: 8,
+	}
+
+	cases := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "94287082"},
+		{unix: 1111111109, expected: "07081804"},
+		{unix: 1111111111, expected: "14050471"},
+		{unix: 1234567890, expected: "89005924"},
+		{unix: 2000000000, expected: "69279037"},
+		{unix: 20000000000, expected: "65353130"},
+	}
+	for _, c := range cases {
+		assert(c.expected, totp.At(time.Unix(c.unix, 0)), t)
+	}
+}
+
+func TestNewTOTP(t *testing.T) {
+	cases := []struct {
+		name          string
+		secret        string
+		expectedError bool
+	}{
+		{
+			name:   "valid",
+			secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		},
+		{
+			name:   "lower case with spaces",
+			secret: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq",
+		},
+		{
+			name:          "invalid",
+			secret:        "not base32!",
+			expectedError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			totp, err := NewTOTP(c.secret)
+
+			assert(c.expectedError, err != nil, t)
+			if err == nil {
+				assert([]byte("12345678901234567890"), totp.Secret, t)
+			}
+		})
+	}
+}
+
+func TestKraken_DialWithAuthTOTP(t *testing.T) {
+	totp := &TOTP{
+		Secret: []byte("12345678901234567890"),
+		now: func() time.Time {
+			return time.Unix(59, 0)
+		},
+	}
+
+	k := NewClient(
+		WithCredentials("baz2345qux", "Zm9vMTIzNGJhcg=="),
+		WithOTPSource(totp),
+	)
+
+	req, err := k.DialWithAuth(context.Background(), http.MethodPost, BalanceResource, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := url.ParseQuery(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert("287082", body.Get(APIOTPParam), t)
+
+	// The password must be covered by the signature.
+	secret, _ := base64.StdEncoding.DecodeString(k.PrivateKey)
+	signature := &Signature{
+		APISecret: secret,
+		Data:      body,
+		URI:       k.ResourceURI(APIPrivateNamespace, BalanceResource),
+	}
+
+	assert(signature.Generate(), req.Header.Get(APISignHeader), t)
+}
@@ -0,0 +1,330 @@
+package gokraken
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Checkpoint records the progress of an iterator, so that a walk which was
+// interrupted can be resumed from where it stopped. It can be stored as JSON.
+type Checkpoint struct {
+	Ofs  int      `json:"ofs"`  // Offset of the page being walked.
+	Seen []string `json:"seen"` // IDs returned from this and the previous page.
+}
+
+// pageFetcher fetches the page of records at the given offset. It returns
+// the IDs of the records on the page, newest first, and the total number of
+// records.
+type pageFetcher func(ctx context.Context, ofs int) (ids []string, count int, err error)
+
+// pager walks the pages of an endpoint which returns up to 50 records at an
+// offset, newest first.
+//
+// Records added while walking push older records to higher offsets, so some
+// records are fetched twice. The pager skips records on a page which were on
+// the previous page, so each record is returned only once unless more than a
+// page of records is added between two fetches. Only the IDs of two pages are
+// kept, however long the walk.
+type pager struct {
+	ctx   context.Context
+	fetch pageFetcher
+
+	ofs     int             // Offset of the next page to fetch.
+	pageOfs int             // Offset of the last page fetched.
+	pending []string        // IDs fetched but not yet returned.
+	prev    map[string]bool // IDs on the previous page.
+	seen    map[string]bool // IDs on the current page already returned.
+	id      string          // ID of the current record.
+	done    bool
+	err     error
+}
+
+// newPager returns a new pager starting at the given offset.
+func newPager(ctx context.Context, ofs int, fetch pageFetcher) *pager {
+	return &pager{
+		ctx:     ctx,
+		fetch:   fetch,
+		ofs:     ofs,
+		pageOfs: ofs,
+		seen:    make(map[string]bool),
+	}
+}
+
+// next advances to the next record, fetching pages as required.
+func (p *pager) next() bool {
+	for len(p.pending) == 0 {
+		if p.done || p.err != nil {
+			return false
+		}
+
+		p.fill()
+	}
+
+	p.id, p.pending = p.pending[0], p.pending[1:]
+	p.seen[p.id] = true
+	return true
+}
+
+// fill fetches the next page.
+func (p *pager) fill() {
+	if err := p.ctx.Err(); err != nil {
+		p.err = err
+		return
+	}
+
+	ids, count, err := p.fetch(p.ctx, p.ofs)
+	if err != nil {
+		p.err = err
+		return
+	}
+
+	p.pageOfs = p.ofs
+	p.ofs += len(ids)
+	if len(ids) == 0 || p.ofs >= count {
+		p.done = true
+	}
+
+	// Every record of the page just walked has been returned.
+	p.prev, p.seen = p.seen, make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if p.prev[id] {
+			p.seen[id] = true
+		} else {
+			p.pending = append(p.pending, id)
+		}
+	}
+}
+
+// checkpoint returns the progress of the walk. Records on the current page
+// which have not been returned yet are fetched again when resuming.
+func (p *pager) checkpoint() Checkpoint {
+	cp := Checkpoint{
+		Ofs:  p.pageOfs,
+		Seen: make([]string, 0, len(p.prev)+len(p.seen)),
+	}
+
+	for _, ids := range []map[string]bool{p.prev, p.seen} {
+		for id := range ids {
+			cp.Seen = append(cp.Seen, id)
+		}
+	}
+	sort.Strings(cp.Seen)
+
+	return cp
+}
+
+// resume restores the progress of a walk from a checkpoint. The IDs in the
+// checkpoint are skipped when the current page is fetched again.
+func (p *pager) resume(cp Checkpoint) {
+	p.ofs, p.pageOfs = cp.Ofs, cp.Ofs
+	p.pending, p.done, p.err = nil, false, nil
+
+	p.prev = nil
+	p.seen = make(map[string]bool, len(cp.Seen))
+	for _, id := range cp.Seen {
+		p.seen[id] = true
+	}
+}
+
+// sortNewestFirst sorts the IDs of records newest first by the given times,
+// as Kraken returns records in a JSON object which does not keep their order.
+func sortNewestFirst(ids []string, timeOf func(id string) time.Time) {
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := timeOf(ids[i]), timeOf(ids[j])
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+
+		return ids[i] < ids[j]
+	})
+}
+
+// ClosedOrdersIterator iterates over all closed orders matching a request.
+type ClosedOrdersIterator struct {
+	*pager
+	page map[string]Order
+}
+
+// ClosedOrdersIter returns an iterator over all the closed orders matching
+// the request, newest first, starting at its offset. Each call to Kraken goes
+// through the client's rate limiter, if it has one.
+func (u *UserData) ClosedOrdersIter(ctx context.Context, closedReq ClosedOrdersRequest) *ClosedOrdersIterator {
+	it := &ClosedOrdersIterator{}
+	it.pager = newPager(ctx, closedReq.Ofs, func(ctx context.Context, ofs int) ([]string, int, error) {
+		closedReq.Ofs = ofs
+		res, err := u.ClosedOrders(ctx, closedReq)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		ids := make([]string, 0, len(res.Closed))
+		for txid := range res.Closed {
+			ids = append(ids, txid)
+		}
+		sortNewestFirst(ids, func(txid string) time.Time {
+			return res.Closed[txid].CloseTime
+		})
+
+		it.page = res.Closed
+		return ids, res.Count, nil
+	})
+
+	return it
+}
+
+// Next advances to the next order. It returns false when there are no more
+// orders or an error occurred.
+func (it *ClosedOrdersIterator) Next() bool {
+	return it.next()
+}
+
+// Order returns the current order, with its transaction id set.
+func (it *ClosedOrdersIterator) Order() Order {
+	order := it.page[it.id]
+	order.TransactionID = it.id
+	return order
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *ClosedOrdersIterator) Err() error {
+	return it.err
+}
+
+// Checkpoint returns the progress of the iterator.
+func (it *ClosedOrdersIterator) Checkpoint() Checkpoint {
+	return it.checkpoint()
+}
+
+// Resume continues the walk from a checkpoint. It must be called before Next.
+func (it *ClosedOrdersIterator) Resume(cp Checkpoint) {
+	it.resume(cp)
+}
+
+// TradesHistoryIterator iterates over all trades matching a request.
+type TradesHistoryIterator struct {
+	*pager
+	page map[string]UserTrade
+}
+
+// TradesHistoryIter returns an iterator over all the trades matching the
+// request, newest first, starting at its offset. Each call to Kraken goes
+// through the client's rate limiter, if it has one.
+func (u *UserData) TradesHistoryIter(ctx context.Context, tradesReq TradesHistoryRequest) *TradesHistoryIterator {
+	it := &TradesHistoryIterator{}
+	it.pager = newPager(ctx, tradesReq.Ofs, func(ctx context.Context, ofs int) ([]string, int, error) {
+		tradesReq.Ofs = ofs
+		res, err := u.TradesHistory(ctx, tradesReq)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		ids := make([]string, 0, len(res.Trades))
+		for txid := range res.Trades {
+			ids = append(ids, txid)
+		}
+		sortNewestFirst(ids, func(txid string) time.Time {
+			return res.Trades[txid].Time
+		})
+
+		it.page = res.Trades
+		return ids, res.Count, nil
+	})
+
+	return it
+}
+
+// Next advances to the next trade. It returns false when there are no more
+// trades or an error occurred.
+func (it *TradesHistoryIterator) Next() bool {
+	return it.next()
+}
+
+// TxID returns the transaction id of the current trade.
+func (it *TradesHistoryIterator) TxID() string {
+	return it.id
+}
+
+// Trade returns the current trade.
+func (it *TradesHistoryIterator) Trade() UserTrade {
+	return it.page[it.id]
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *TradesHistoryIterator) Err() error {
+	return it.err
+}
+
+// Checkpoint returns the progress of the iterator.
+func (it *TradesHistoryIterator) Checkpoint() Checkpoint {
+	return it.checkpoint()
+}
+
+// Resume continues the walk from a checkpoint. It must be called before Next.
+func (it *TradesHistoryIterator) Resume(cp Checkpoint) {
+	it.resume(cp)
+}
+
+// LedgersIterator iterates over all ledger entries matching a request.
+type LedgersIterator struct {
+	*pager
+	page LedgersResponse
+}
+
+// LedgersIter returns an iterator over all the ledger entries matching the
+// request, newest first, starting at its offset. Each call to Kraken goes
+// through the client's rate limiter, if it has one.
+func (u *UserData) LedgersIter(ctx context.Context, ledgersReq LedgersRequest) *LedgersIterator {
+	it := &LedgersIterator{}
+	it.pager = newPager(ctx, ledgersReq.Ofs, func(ctx context.Context, ofs int) ([]string, int, error) {
+		ledgersReq.Ofs = ofs
+		res, count, err := u.ledgers(ctx, ledgersReq)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		ids := make([]string, 0, len(res))
+		for id := range res {
+			ids = append(ids, id)
+		}
+		sortNewestFirst(ids, func(id string) time.Time {
+			return res[id].Time
+		})
+
+		it.page = res
+		return ids, count, nil
+	})
+
+	return it
+}
+
+// Next advances to the next ledger entry. It returns false when there are no
+// more entries or an error occurred.
+func (it *LedgersIterator) Next() bool {
+	return it.next()
+}
+
+// ID returns the ledger id of the current entry.
+func (it *LedgersIterator) ID() string {
+	return it.id
+}
+
+// Ledger returns the current ledger entry.
+func (it *LedgersIterator) Ledger() Ledger {
+	return it.page[it.id]
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *LedgersIterator) Err() error {
+	return it.err
+}
+
+// Checkpoint returns the progress of the iterator.
+func (it *LedgersIterator) Checkpoint() Checkpoint {
+	return it.checkpoint()
+}
+
+// Resume continues the walk from a checkpoint. It must be called before Next.
+func (it *LedgersIterator) Resume(cp Checkpoint) {
+	it.resume(cp)
+}
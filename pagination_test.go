@@ -0,0 +1,267 @@
+package gokraken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// pagedRecord is a record of a paged resource.
+type pagedRecord struct {
+	id   string
+	time int64
+}
+
+// pagedRecords returns n records a second apart, newest first.
+func pagedRecords(n int) []pagedRecord {
+	records := make([]pagedRecord, n)
+	for i := range records {
+		records[i] = pagedRecord{id: fmt.Sprintf("ID%03d", i), time: int64(1520000000 - i)}
+	}
+
+	return records
+}
+
+// writePage writes the page of 50 records at the offset requested by r, in
+// the result format of the given resource.
+func writePage(w http.ResponseWriter, r *http.Request, resource string, records []pagedRecord) {
+	b, _ := ioutil.ReadAll(r.Body)
+	body, _ := url.ParseQuery(string(b))
+	ofs, _ := strconv.Atoi(body.Get("ofs"))
+
+	end := ofs + 50
+	if end > len(records) {
+		end = len(records)
+	}
+	if ofs > end {
+		ofs = end
+	}
+
+	page := make(map[string]interface{})
+	for _, record := range records[ofs:end] {
+		switch resource {
+		case ClosedOrdersResource:
+			page[record.id] = map[string]interface{}{"closetm": record.time, "status": "closed"}
+		default:
+			page[record.id] = map[string]interface{}{"time": record.time, "refid": "R" + record.id}
+		}
+	}
+
+	var result interface{}
+	switch resource {
+	case ClosedOrdersResource:
+		result = map[string]interface{}{"closed": page, "count": len(records)}
+	case TradesHistoryResource:
+		result = map[string]interface{}{"trades": page, "count": len(records)}
+	case LedgersResource:
+		result = map[string]interface{}{"ledger": page, "count": len(records)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": []string{}, "result": result})
+}
+
+// assertWalk asserts that ids contains each of the first n records of a
+// pagedRecords exactly once, in order.
+func assertWalk(ids []string, n int, t *testing.T) {
+	t.Helper()
+
+	assert(n, len(ids), t)
+	for i, id := range ids {
+		assert(fmt.Sprintf("ID%03d", i), id, t)
+	}
+}
+
+func TestUserData_Iter(t *testing.T) {
+	cases := []struct {
+		name     string
+		resource string
+		records  int
+		shift    bool
+		walk     func(ctx context.Context, k *Kraken, t *testing.T) ([]string, error)
+		calls    int
+	}{
+		{
+			name:     "closed orders",
+			resource: ClosedOrdersResource,
+			records:  120,
+			shift:    true,
+			walk: func(ctx context.Context, k *Kraken, t *testing.T) (ids []string, err error) {
+				it := k.UserData.ClosedOrdersIter(ctx, ClosedOrdersRequest{})
+				for it.Next() {
+					order := it.Order()
+					assert(OrderStatusClosed, order.Status, t)
+					ids = append(ids, order.TransactionID)
+				}
+
+				return ids, it.Err()
+			},
+			calls: 3,
+		},
+		{
+			name:     "trades history",
+			resource: TradesHistoryResource,
+			records:  120,
+			shift:    true,
+			walk: func(ctx context.Context, k *Kraken, t *testing.T) (ids []string, err error) {
+				it := k.UserData.TradesHistoryIter(ctx, TradesHistoryRequest{})
+				for it.Next() {
+					ids = append(ids, it.TxID())
+				}
+
+				return ids, it.Err()
+			},
+			calls: 3,
+		},
+		{
+			name:     "ledgers",
+			resource: LedgersResource,
+			records:  75,
+			walk: func(ctx context.Context, k *Kraken, t *testing.T) (ids []string, err error) {
+				it := k.UserData.LedgersIter(ctx, LedgersRequest{})
+				for it.Next() {
+					assert("R"+it.ID(), it.Ledger().Refid, t)
+					ids = append(ids, it.ID())
+				}
+
+				return ids, it.Err()
+			},
+			calls: 2,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			records := pagedRecords(c.records)
+
+			var calls int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writePage(w, r, c.resource, records)
+
+				// New records are added while walking, shifting the offsets
+				// of older records.
+				calls++
+				if c.shift && calls == 1 {
+					records = append([]pagedRecord{{id: "NEW2", time: 1520000002}, {id: "NEW1", time: 1520000001}}, records...)
+				}
+			}))
+
+			defer ts.Close()
+
+			k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+			k.BaseURL = ts.URL
+
+			ids, err := c.walk(context.Background(), k, t)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertWalk(ids, c.records, t)
+			assert(c.calls, calls, t)
+		})
+	}
+}
+
+func TestUserData_TradesHistoryIterResume(t *testing.T) {
+	records := pagedRecords(120)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writePage(w, r, TradesHistoryResource, records)
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	var ids []string
+	it := k.UserData.TradesHistoryIter(context.Background(), TradesHistoryRequest{})
+	for len(ids) < 60 && it.Next() {
+		ids = append(ids, it.TxID())
+		assert(time.Unix(records[len(ids)-1].time, 0), it.Trade().Time, t)
+	}
+
+	b, err := json.Marshal(it.Checkpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new trade before resuming shifts the offsets again.
+	records = append([]pagedRecord{{id: "NEW1", time: 1520000001}}, records...)
+
+	var cp Checkpoint
+	if err = json.Unmarshal(b, &cp); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(50, cp.Ofs, t)
+	assert(60, len(cp.Seen), t)
+
+	it = k.UserData.TradesHistoryIter(context.Background(), TradesHistoryRequest{})
+	it.Resume(cp)
+	for it.Next() {
+		ids = append(ids, it.TxID())
+	}
+
+	if err = it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertWalk(ids, 120, t)
+}
+
+func TestUserData_ClosedOrdersIterCheckpointSize(t *testing.T) {
+	records := pagedRecords(300)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writePage(w, r, ClosedOrdersResource, records)
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	it := k.UserData.ClosedOrdersIter(context.Background(), ClosedOrdersRequest{})
+	for n := 0; n < 260 && it.Next(); n++ {
+	}
+
+	// Only the previous page and the records returned from the current page
+	// are kept.
+	cp := it.Checkpoint()
+	assert(250, cp.Ofs, t)
+	assert(60, len(cp.Seen), t)
+	assert("ID200", cp.Seen[0], t)
+	assert("ID259", cp.Seen[len(cp.Seen)-1], t)
+}
+
+func TestUserData_LedgersIterError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":["EGeneral:Permission denied"]}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	it := k.UserData.LedgersIter(context.Background(), LedgersRequest{})
+	assert(false, it.Next(), t)
+	assert(true, errors.Is(it.Err(), ErrPermissionDenied), t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	it = k.UserData.LedgersIter(ctx, LedgersRequest{})
+	assert(false, it.Next(), t)
+	assert(context.Canceled, it.Err(), t)
+}
@@ -1,4 +1,3 @@
-//go:generate stringer -type AssetPair
 package pairs
 
 // Code generated by pairs
@@ -66,62 +65,62 @@ const (
 
 type AssetPair int
 
-var validPairs = []AssetPair{
-	XETHZEUR,
-	XREPZUSD,
-	XXMRZEUR,
-	EOSETH,
-	XICNXETH,
-	XXRPZUSD,
-	XZECZJPY,
-	XZECZUSD,
-	DASHXBT,
-	XETCXETH,
-	XREPXXBT,
-	EOSUSD,
-	XETHXXBT,
-	XETHZCAD,
-	XXDGXXBT,
-	XXRPZJPY,
-	BCHUSD,
-	EOSEUR,
-	GNOXBT,
-	XXBTZJPY,
-	EOSXBT,
-	GNOEUR,
-	XXRPZCAD,
-	XETCZUSD,
-	XXLMXXBT,
-	XXBTZEUR,
-	XXMRXXBT,
-	DASHEUR,
-	XETCXXBT,
-	XXBTZUSD,
-	USDTZUSD,
-	XREPZEUR,
-	XREPXETH,
-	XXLMZUSD,
-	XLTCXXBT,
-	XMLNXXBT,
-	XXBTZCAD,
-	XXBTZGBP,
-	XXLMZEUR,
-	XETCZEUR,
-	XLTCZUSD,
-	XETHZJPY,
-	XXMRZUSD,
-	XZECXXBT,
-	GNOUSD,
-	BCHEUR,
-	GNOETH,
-	XETHZGBP,
-	XICNXXBT,
-	XZECZEUR,
-	BCHXBT,
-	XETHZUSD,
-	XMLNXETH,
-	DASHUSD,
-	XXRPXXBT,
-	XXRPZEUR,
-	XLTCZEUR,
+var pairNames = []string{
+	"XETHZEUR",
+	"XREPZUSD",
+	"XXMRZEUR",
+	"EOSETH",
+	"XICNXETH",
+	"XXRPZUSD",
+	"XZECZJPY",
+	"XZECZUSD",
+	"DASHXBT",
+	"XETCXETH",
+	"XREPXXBT",
+	"EOSUSD",
+	"XETHXXBT",
+	"XETHZCAD",
+	"XXDGXXBT",
+	"XXRPZJPY",
+	"BCHUSD",
+	"EOSEUR",
+	"GNOXBT",
+	"XXBTZJPY",
+	"EOSXBT",
+	"GNOEUR",
+	"XXRPZCAD",
+	"XETCZUSD",
+	"XXLMXXBT",
+	"XXBTZEUR",
+	"XXMRXXBT",
+	"DASHEUR",
+	"XETCXXBT",
+	"XXBTZUSD",
+	"USDTZUSD",
+	"XREPZEUR",
+	"XREPXETH",
+	"XXLMZUSD",
+	"XLTCXXBT",
+	"XMLNXXBT",
+	"XXBTZCAD",
+	"XXBTZGBP",
+	"XXLMZEUR",
+	"XETCZEUR",
+	"XLTCZUSD",
+	"XETHZJPY",
+	"XXMRZUSD",
+	"XZECXXBT",
+	"GNOUSD",
+	"BCHEUR",
+	"GNOETH",
+	"XETHZGBP",
+	"XICNXXBT",
+	"XZECZEUR",
+	"BCHXBT",
+	"XETHZUSD",
+	"XMLNXETH",
+	"DASHUSD",
+	"XXRPXXBT",
+	"XXRPZEUR",
+	"XLTCZEUR",
 }
@@ -1,4 +1,3 @@
-//go:generate stringer -type AssetPair
 package pairs
 
 // Code generated by pairs
@@ -17,8 +16,8 @@ const (
 
 type AssetPair int
 
-var validPairs = []AssetPair{
+var pairNames = []string{
 {{- range $k, $v := .}}
-{{$v}},
+"{{$v}}",
 {{- end}}
 }
\ No newline at end of file
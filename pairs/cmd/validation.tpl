@@ -1,19 +1,24 @@
 package pairs
 
 func Valid(pair string) bool {
-for _, v := range validPairs {
-if v.String() == pair {
-return true
-}
-}
-return false
+_, ok := Lookup(pair)
+return ok
 }
 
 func Find(pair string) *AssetPair {
-for _, v := range validPairs {
-if v.String() == pair {
+if v, ok := Lookup(pair); ok {
 return &v
 }
-}
 return nil
-}
\ No newline at end of file
+}
+
+func All() []AssetPair {
+registry.RLock()
+defer registry.RUnlock()
+
+all := make([]AssetPair, len(registry.pairs))
+for i := range all {
+all[i] = AssetPair(i)
+}
+return all
+}
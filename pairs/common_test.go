@@ -0,0 +1,6 @@
+package pairs
+
+import "github.com/danmrichards/gokraken/internal/testutil"
+
+// Test helper for asserting values are equal.
+var assert = testutil.Assert
@@ -0,0 +1,211 @@
+package pairs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/danmrichards/gokraken/asset"
+)
+
+// Unknown is an asset pair which is not known to the registry. It is outside
+// the range of the generated constants, so their values do not change.
+const Unknown AssetPair = -1
+
+// Info describes an asset pair known to the registry.
+type Info struct {
+	Name    string // Kraken name, e.g. XXBTZUSD.
+	Altname string // Alternate name, e.g. XBTUSD.
+	WSName  string // WebSocket API name, e.g. XBT/USD.
+	Base    string // Kraken name of the base asset, e.g. XXBT.
+	Quote   string // Kraken name of the quote asset, e.g. ZUSD.
+}
+
+// registry holds every known asset pair, indexed by AssetPair. It is seeded
+// with the generated constants and extended by Register.
+var registry = struct {
+	sync.RWMutex
+	pairs  []Info
+	byName map[string]AssetPair // By Kraken name.
+	lookup map[string]AssetPair // By Kraken name, altname and wsname.
+}{
+	byName: make(map[string]AssetPair),
+	lookup: make(map[string]AssetPair),
+}
+
+func init() {
+	for _, name := range pairNames {
+		Register(seedInfo(name))
+	}
+}
+
+// seedInfo returns the information about a generated asset pair, splitting its
+// name into the names of two known assets. The altname and wsname are formed
+// from the altnames of the assets, e.g. XBTUSD and XBT/USD for XXBTZUSD.
+func seedInfo(name string) Info {
+	info := Info{Name: name}
+
+	for i := 3; i <= len(name)-3; i++ {
+		base, ok := lookupAsset(name[:i])
+		if !ok {
+			continue
+		}
+
+		quote, ok := lookupAsset(name[i:])
+		if !ok {
+			continue
+		}
+
+		info.Base, info.Quote = base.Name, quote.Name
+		info.Altname = base.Altname + quote.Altname
+		info.WSName = base.Altname + "/" + quote.Altname
+		break
+	}
+
+	return info
+}
+
+// lookupAsset returns the information about the asset with the given name or
+// altname.
+func lookupAsset(name string) (asset.Info, bool) {
+	currency, ok := asset.Lookup(name)
+	if !ok {
+		return asset.Info{}, false
+	}
+
+	return currency.Info()
+}
+
+// Register adds an asset pair to the registry, or updates the pair with the
+// same Kraken name, and returns it. Fields left empty keep their registered
+// values.
+//
+// Pairs listed by Kraken after this package was generated are given new
+// AssetPair values, which are only valid for the life of the process. They
+// are registered by Market.RefreshRegistry and Market.AssetPairs. Registering
+// a pair without a name returns Unknown.
+func Register(info Info) AssetPair {
+	if info.Name == "" {
+		return Unknown
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	pair, ok := registry.byName[info.Name]
+	if !ok {
+		pair = AssetPair(len(registry.pairs))
+		registry.pairs = append(registry.pairs, Info{Name: info.Name})
+		registry.byName[info.Name] = pair
+	}
+
+	existing := &registry.pairs[pair]
+	if info.Altname != "" {
+		existing.Altname = info.Altname
+	}
+	if info.WSName != "" {
+		existing.WSName = info.WSName
+	}
+	if info.Base != "" {
+		existing.Base = info.Base
+	}
+	if info.Quote != "" {
+		existing.Quote = info.Quote
+	}
+
+	// Kraken names take precedence over the altnames of other pairs.
+	for _, name := range []string{existing.Name, existing.Altname, existing.WSName} {
+		if _, taken := registry.lookup[name]; name != "" && (!taken || name == existing.Name) {
+			registry.lookup[name] = pair
+		}
+	}
+
+	return pair
+}
+
+// Lookup returns the asset pair with the given Kraken name, altname or
+// wsname, or Unknown if there is none.
+func Lookup(name string) (AssetPair, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	pair, ok := registry.lookup[name]
+	if !ok {
+		return Unknown, false
+	}
+
+	return pair, true
+}
+
+// Info returns the registered information about the asset pair.
+func (i AssetPair) Info() (Info, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if i < 0 || int(i) >= len(registry.pairs) {
+		return Info{}, false
+	}
+
+	return registry.pairs[i], true
+}
+
+// Base returns the base asset of the pair, e.g. asset.XXBT for XXBTZUSD.
+func (i AssetPair) Base() (asset.Currency, bool) {
+	info, _ := i.Info()
+	return asset.Lookup(info.Base)
+}
+
+// Quote returns the quote asset of the pair, e.g. asset.ZUSD for XXBTZUSD.
+func (i AssetPair) Quote() (asset.Currency, bool) {
+	info, _ := i.Info()
+	return asset.Lookup(info.Quote)
+}
+
+// String returns the Kraken name of the asset pair, or an empty string for
+// Unknown.
+func (i AssetPair) String() string {
+	if i == Unknown {
+		return ""
+	}
+
+	info, ok := i.Info()
+	if !ok {
+		return fmt.Sprintf("AssetPair(%d)", i)
+	}
+
+	return info.Name
+}
+
+// MarshalJSON encodes the asset pair as its Kraken name, or an empty string for
+// Unknown.
+func (i AssetPair) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON decodes an asset pair from any of its names, such as the
+// altname used in order descriptions, or its BASE/QUOTE symbol. Names which
+// have not been registered decode as Unknown, so pairs listed by Kraken after
+// this package was generated must be registered first, such as by calling
+// Market.RefreshRegistry. The value of a generated constant is also accepted,
+// but not the values of registered pairs, which differ between processes.
+func (i *AssetPair) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var value int
+		if json.Unmarshal(data, &value) != nil {
+			return err
+		}
+
+		if value < 0 || value >= len(pairNames) {
+			return fmt.Errorf("invalid asset pair %d", value)
+		}
+
+		*i = AssetPair(value)
+		return nil
+	}
+
+	pair, _ := FromSymbol(name)
+
+	*i = pair
+	return nil
+}
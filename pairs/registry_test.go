@@ -0,0 +1,125 @@
+package pairs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/danmrichards/gokraken/asset"
+)
+
+func TestLookup(t *testing.T) {
+	cases := []struct {
+		pair     AssetPair
+		expected Info
+	}{
+		{pair: XXBTZUSD, expected: Info{Name: "XXBTZUSD", Altname: "XBTUSD", WSName: "XBT/USD", Base: "XXBT", Quote: "ZUSD"}},
+		{pair: XETHXXBT, expected: Info{Name: "XETHXXBT", Altname: "ETHXBT", WSName: "ETH/XBT", Base: "XETH", Quote: "XXBT"}},
+		{pair: DASHXBT, expected: Info{Name: "DASHXBT", Altname: "DASHXBT", WSName: "DASH/XBT", Base: "DASH", Quote: "XXBT"}},
+		{pair: EOSETH, expected: Info{Name: "EOSETH", Altname: "EOSETH", WSName: "EOS/ETH", Base: "EOS", Quote: "XETH"}},
+		{pair: USDTZUSD, expected: Info{Name: "USDTZUSD", Altname: "USDTUSD", WSName: "USDT/USD", Base: "USDT", Quote: "ZUSD"}},
+		{pair: XXDGXXBT, expected: Info{Name: "XXDGXXBT", Altname: "XDGXBT", WSName: "XDG/XBT", Base: "XXDG", Quote: "XXBT"}},
+	}
+	for _, c := range cases {
+		info, ok := c.pair.Info()
+		assert(true, ok, t)
+		assert(c.expected, info, t)
+		assert(c.expected.Name, c.pair.String(), t)
+
+		for _, name := range []string{info.Name, info.Altname, info.WSName} {
+			pair, ok := Lookup(name)
+			assert(true, ok, t)
+			assert(c.pair, pair, t)
+		}
+	}
+
+	pair, ok := Lookup("FOOBAR")
+	assert(false, ok, t)
+	assert(Unknown, pair, t)
+	assert("AssetPair(100000)", AssetPair(100000).String(), t)
+}
+
+func TestAssetPair_BaseQuote(t *testing.T) {
+	base, ok := XXBTZUSD.Base()
+	assert(true, ok, t)
+	assert(asset.XXBT, base, t)
+
+	quote, ok := XXBTZUSD.Quote()
+	assert(true, ok, t)
+	assert(asset.ZUSD, quote, t)
+}
+
+func TestRegister(t *testing.T) {
+	pair := Register(Info{Name: "DOTUSD", Altname: "DOTUSD", WSName: "DOT/USD", Base: "DOT", Quote: "ZUSD"})
+
+	if int(pair) < len(pairNames) {
+		t.Fatalf("%s: new pair has the value of a generated pair", t.Name())
+	}
+
+	assert("DOTUSD", pair.String(), t)
+	assert(true, Valid("DOT/USD"), t)
+	assert(&pair, Find("DOT/USD"), t)
+
+	// Registering a known pair updates it, keeping fields left empty.
+	assert(pair, Register(Info{Name: "DOTUSD", WSName: "DOT/ZUSD"}), t)
+
+	info, _ := pair.Info()
+	assert(Info{Name: "DOTUSD", Altname: "DOTUSD", WSName: "DOT/ZUSD", Base: "DOT", Quote: "ZUSD"}, info, t)
+
+	// Generated pairs are updated in place.
+	assert(XXBTZUSD, Register(Info{Name: "XXBTZUSD", Altname: "XBTUSD"}), t)
+
+	found, ok := FromSymbol("DOTUSD")
+	assert(true, ok, t)
+	assert(pair, found, t)
+
+	assert(Unknown, Register(Info{}), t)
+}
+
+func TestUnknown(t *testing.T) {
+	_, ok := Unknown.Info()
+	assert(false, ok, t)
+	assert("", Unknown.String(), t)
+
+	// The generated values are unchanged.
+	var zero AssetPair
+	assert(XETHZEUR, zero, t)
+	assert(XETHZEUR, All()[0], t)
+
+	pair, ok := FromSymbol("FOO/BAR")
+	assert(false, ok, t)
+	assert(Unknown, pair, t)
+}
+
+func TestAssetPair_JSON(t *testing.T) {
+	var v struct {
+		Altname AssetPair `json:"altname"`
+		Value   AssetPair `json:"value"`
+		Unknown AssetPair `json:"unknown"`
+	}
+
+	err := json.Unmarshal([]byte(`{"altname":"XBTUSD","value":12,"unknown":"ADAEUR"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(XXBTZUSD, v.Altname, t)
+	assert(XETHXXBT, v.Value, t)
+	assert(Unknown, v.Unknown, t)
+
+	// Decoding does not register unknown names.
+	_, ok := Lookup("ADAEUR")
+	assert(false, ok, t)
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(`{"altname":"XXBTZUSD","value":"XETHXXBT","unknown":""}`, string(b), t)
+
+	for _, invalid := range []string{`{"altname":true}`, `{"value":-1}`, `{"value":100000}`} {
+		if err = json.Unmarshal([]byte(invalid), &v); err == nil {
+			t.Fatalf("%s: expected error for %s", t.Name(), invalid)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package pairs
+
+import (
+	"strings"
+
+	"github.com/danmrichards/gokraken/asset"
+)
+
+// symbolSeparators are the separators accepted between the base and quote of
+// a pair symbol.
+const symbolSeparators = "/-_"
+
+// Symbol returns the pair as BASE/QUOTE using the common tickers of its
+// assets, e.g. BTC/USD for XXBTZUSD. If the assets of the pair are not known,
+// its Kraken name is returned.
+func (i AssetPair) Symbol() string {
+	base, ok := i.Base()
+	if !ok {
+		return i.String()
+	}
+
+	quote, ok := i.Quote()
+	if !ok {
+		return i.String()
+	}
+
+	return base.Symbol() + "/" + quote.Symbol()
+}
+
+// FromSymbol returns the pair with the given BASE/QUOTE symbol, e.g. XXBTZUSD
+// for BTC/USD or BTC-USD. The base and quote may be given as common tickers,
+// Kraken names or altnames. Kraken names, altnames and wsnames of pairs are
+// also resolved.
+func FromSymbol(symbol string) (AssetPair, bool) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	if pair, ok := Lookup(symbol); ok {
+		return pair, true
+	}
+
+	sep := strings.IndexAny(symbol, symbolSeparators)
+	if sep < 0 {
+		return Unknown, false
+	}
+
+	base, ok := asset.FromSymbol(symbol[:sep])
+	if !ok {
+		return Unknown, false
+	}
+
+	quote, ok := asset.FromSymbol(symbol[sep+1:])
+	if !ok {
+		return Unknown, false
+	}
+
+	return find(base.String(), quote.String())
+}
+
+// find returns the first registered pair with the given base and quote asset
+// names.
+func find(base, quote string) (AssetPair, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for i, info := range registry.pairs {
+		if info.Base == base && info.Quote == quote {
+			return AssetPair(i), true
+		}
+	}
+
+	return Unknown, false
+}
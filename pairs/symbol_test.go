@@ -0,0 +1,40 @@
+package pairs
+
+import "testing"
+
+func TestAssetPair_Symbol(t *testing.T) {
+	cases := []struct {
+		pair   AssetPair
+		symbol string
+	}{
+		{pair: XXBTZUSD, symbol: "BTC/USD"},
+		{pair: XXDGXXBT, symbol: "DOGE/BTC"},
+		{pair: XETHXXBT, symbol: "ETH/BTC"},
+		{pair: DASHXBT, symbol: "DASH/BTC"},
+		{pair: USDTZUSD, symbol: "USDT/USD"},
+		{pair: BCHEUR, symbol: "BCH/EUR"},
+	}
+	for _, c := range cases {
+		assert(c.symbol, c.pair.Symbol(), t)
+
+		pair, ok := FromSymbol(c.symbol)
+		assert(true, ok, t)
+		assert(c.pair, pair, t)
+	}
+}
+
+func TestFromSymbol(t *testing.T) {
+	for _, symbol := range []string{"BTC/USD", "btc-usd", "BTC_USD", "XBT/USD", "XXBT/ZUSD", "XBTUSD", "XXBTZUSD"} {
+		pair, ok := FromSymbol(symbol)
+		assert(true, ok, t)
+		assert(XXBTZUSD, pair, t)
+	}
+
+	for _, symbol := range []string{"BTC", "BTC/FOO", "FOO/USD", "USD/BTC"} {
+		_, ok := FromSymbol(symbol)
+		assert(false, ok, t)
+	}
+
+	// Pairs with unknown assets fall back to their Kraken name.
+	assert("AssetPair(100000)", AssetPair(100000).Symbol(), t)
+}
@@ -1,5 +1,11 @@
 package gokraken
 
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
 // OpenPositionsResource is the API resource for the Kraken API open positions.
 const OpenPositionsResource = "OpenPositions"
 
@@ -9,19 +15,61 @@ type OpenPositionsResponse map[string]Position
 
 // Position represents a Kraken open position.
 type Position struct {
-	OrderTxid string  `json:"ordertxid"`  // Order responsible for execution of trade.
-	Pair      string  `json:"pair"`       // Asset pair.
-	Time      int64   `json:"time"`       // Unix timestamp of trade.
-	Type      string  `json:"type"`       // Type of order used to open position (buy/sell).
-	OrderType string  `json:"ordertype"`  // Order type used to open position.
-	Cost      float64 `json:"cost"`       // Opening cost of position (quote currency unless viqc set in oflags).
-	Fee       float64 `json:"fee"`        // Opening fee of position (quote currency).
-	Vol       float64 `json:"vol"`        // Position volume (base currency unless viqc set in oflags).
-	VolClosed float64 `json:"vol_closed"` // Position volume closed (base currency unless viqc set in oflags).
-	Margin    float64 `json:"margin"`     // Initial margin (quote currency).
-	Value     float64 `json:"value"`      // Current value of remaining position (if docalcs requested.  quote currency).
-	Net       float64 `json:"net"`        // Unrealized profit/loss of remaining position (if docalcs requested.  quote currency, quote currency scale).
-	Misc      string  `json:"misc"`       // Comma delimited list of miscellaneous info.
-	OFlags    string  `json:"oflags"`     // Comma delimited list of order flags.
-	Viqc      float64 `json:"viqc"`       // Volume in quote currency.
+	OrderTxid string       `json:"ordertxid"`  // Order responsible for execution of trade.
+	Pair      string       `json:"pair"`       // Asset pair.
+	Time      time.Time    `json:"time"`       // Time of trade.
+	Type      TradeBuySell `json:"type"`       // Type of order used to open position (buy/sell).
+	OrderType OrderType    `json:"ordertype"`  // Order type used to open position.
+	Cost      Decimal      `json:"cost"`       // Opening cost of position (quote currency unless viqc set in oflags).
+	Fee       Decimal      `json:"fee"`        // Opening fee of position (quote currency).
+	Vol       Decimal      `json:"vol"`        // Position volume (base currency unless viqc set in oflags).
+	VolClosed Decimal      `json:"vol_closed"` // Position volume closed (base currency unless viqc set in oflags).
+	Margin    Decimal      `json:"margin"`     // Initial margin (quote currency).
+	Value     Decimal      `json:"value"`      // Current value of remaining position (if docalcs requested.  quote currency).
+	Net       Decimal      `json:"net"`        // Unrealized profit/loss of remaining position (if docalcs requested.  quote currency, quote currency scale).
+	Misc      []string     `json:"misc"`       // List of miscellaneous info.
+	OFlags    []OrderFlag  `json:"oflags"`     // List of order flags.
+	Viqc      Decimal      `json:"viqc"`       // Volume in quote currency.
+}
+
+// UnmarshalJSON parses the JSON-encoded data and stores the result
+// in the value pointed to by p, converting its Unix timestamp and comma
+// delimited lists.
+func (p *Position) UnmarshalJSON(data []byte) (err error) {
+	type position Position
+	aux := struct {
+		*position
+		Time   json.Number `json:"time"`
+		Misc   string      `json:"misc"`
+		OFlags string      `json:"oflags"`
+	}{
+		position: (*position)(p),
+	}
+
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	p.Misc = SplitList(aux.Misc)
+	p.OFlags = ParseOrderFlags(aux.OFlags)
+	p.Time, err = parseUnixTime(aux.Time)
+
+	return
+}
+
+// MarshalJSON encodes the position in the format used by Kraken, so that it
+// round trips through UnmarshalJSON.
+func (p Position) MarshalJSON() ([]byte, error) {
+	type position Position
+	return json.Marshal(struct {
+		position
+		Time   json.Number `json:"time"`
+		Misc   string      `json:"misc"`
+		OFlags string      `json:"oflags"`
+	}{
+		position: position(p),
+		Time:     unixNumber(p.Time),
+		Misc:     strings.Join(p.Misc, ","),
+		OFlags:   JoinOrderFlags(p.OFlags),
+	})
 }
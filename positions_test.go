@@ -0,0 +1,58 @@
+package gokraken
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPosition_UnmarshalJSON(t *testing.T) {
+	// Recorded from the OpenPositions endpoint.
+	payload := []byte(`{"ordertxid":"OLWNFG-LLH4R-D6SFFP","posstatus":"open","pair":"XXBTZUSD","time":1605280097.8294,"type":"sell","ordertype":"limit","cost":"104610.52842","fee":"289.06565","vol":"8.82412861","vol_closed":"0.20200000","margin":"20922.10568","value":"258797.5","net":"+154186.9728","terms":"0.0100% per 4 hours","rollovertm":"1616672637","misc":"","oflags":"viqc"}`)
+
+	var position Position
+	if err := json.Unmarshal(payload, &position); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(Position{
+		OrderTxid: "OLWNFG-LLH4R-D6SFFP",
+		Pair:      "XXBTZUSD",
+		Time:      time.Unix(1605280097, 829400000),
+		Type:      TradeSell,
+		OrderType: OrderTypeLimit,
+		Cost:      dec("104610.52842"),
+		Fee:       dec("289.06565"),
+		Vol:       dec("8.82412861"),
+		VolClosed: dec("0.202"),
+		Margin:    dec("20922.10568"),
+		Value:     dec("258797.5"),
+		Net:       dec("154186.9728"),
+		OFlags:    []OrderFlag{OrderFlagViqc},
+	}, position, t)
+}
+
+func TestPosition_MarshalJSON(t *testing.T) {
+	position := Position{
+		OrderTxid: "OLWNFG-LLH4R-D6SFFP",
+		Pair:      "XXBTZUSD",
+		Time:      time.Unix(1605280097, 829400000),
+		Type:      TradeSell,
+		OrderType: OrderTypeLimit,
+		Cost:      dec("104610.52842"),
+		Misc:      []string{"closing"},
+		OFlags:    []OrderFlag{OrderFlagViqc, OrderFlagPost},
+	}
+
+	b, err := json.Marshal(position)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Position
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(position, decoded, t)
+}
@@ -0,0 +1,113 @@
+package gokraken
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// relationAbove adds the amount to the last traded price.
+	relationAbove = "+"
+
+	// relationBelow subtracts the amount from the last traded price.
+	relationBelow = "-"
+
+	// relationAuto adds or subtracts the amount from the last traded price
+	// depending on the side and type of the order.
+	relationAuto = "#"
+)
+
+// OrderPrice is the price of an order, either an absolute price or an amount
+// relative to the last traded price of the pair, which may be a percentage.
+// Its String method returns it in the syntax of the Kraken API, such as
+// 30000.5, +150, -2% or #1.5.
+//
+// The zero value is no price.
+type OrderPrice struct {
+	amount   Decimal
+	relation string
+	percent  bool
+}
+
+// PriceAt returns an absolute price.
+func PriceAt(amount Decimal) OrderPrice {
+	return OrderPrice{amount: amount}
+}
+
+// PricePlus returns a price the given amount above the last traded price.
+func PricePlus(amount Decimal) OrderPrice {
+	return OrderPrice{amount: amount, relation: relationAbove}
+}
+
+// PriceMinus returns a price the given amount below the last traded price.
+func PriceMinus(amount Decimal) OrderPrice {
+	return OrderPrice{amount: amount, relation: relationBelow}
+}
+
+// PriceOffset returns a price the given amount above or below the last traded
+// price, with Kraken choosing the direction from the side and type of the
+// order.
+func PriceOffset(amount Decimal) OrderPrice {
+	return OrderPrice{amount: amount, relation: relationAuto}
+}
+
+// ParseOrderPrice parses a price in the syntax of the Kraken API.
+func ParseOrderPrice(s string) (p OrderPrice, err error) {
+	str := strings.TrimSpace(s)
+
+	if strings.HasPrefix(str, relationAbove) || strings.HasPrefix(str, relationBelow) || strings.HasPrefix(str, relationAuto) {
+		p.relation, str = str[:1], str[1:]
+	}
+
+	if strings.HasSuffix(str, "%") {
+		p.percent, str = true, str[:len(str)-1]
+	}
+
+	if str == "" || strings.ContainsAny(str[:1], "+-") || (p.percent && p.relation == "") {
+		return OrderPrice{}, fmt.Errorf("invalid price %q", s)
+	}
+
+	if p.amount, err = ParseDecimal(str); err != nil {
+		return OrderPrice{}, fmt.Errorf("invalid price %q", s)
+	}
+
+	return
+}
+
+// Percent returns the price with its amount as a percentage of the last traded
+// price. Only relative prices may be percentages.
+func (p OrderPrice) Percent() OrderPrice {
+	p.percent = true
+	return p
+}
+
+// Amount returns the absolute price or the amount relative to the last traded
+// price.
+func (p OrderPrice) Amount() Decimal {
+	return p.amount
+}
+
+// IsRelative returns whether the price is relative to the last traded price.
+func (p OrderPrice) IsRelative() bool {
+	return p.relation != ""
+}
+
+// IsPercent returns whether the amount of the price is a percentage.
+func (p OrderPrice) IsPercent() bool {
+	return p.percent
+}
+
+// IsZero returns whether the price is unset.
+func (p OrderPrice) IsZero() bool {
+	return p.amount.IsZero() && p.relation == "" && !p.percent
+}
+
+// String returns the price in the syntax of the Kraken API.
+func (p OrderPrice) String() string {
+	s := p.relation + p.amount.String()
+	if p.percent {
+		s += "%"
+	}
+
+	return s
+}
@@ -0,0 +1,40 @@
+package gokraken
+
+import "testing"
+
+func TestParseOrderPrice(t *testing.T) {
+	cases := []struct {
+		price    string
+		expected OrderPrice
+	}{
+		{price: "30000.5", expected: PriceAt(dec("30000.5"))},
+		{price: "+150", expected: PricePlus(dec("150"))},
+		{price: "-2%", expected: PriceMinus(dec("2")).Percent()},
+		{price: "#1.5", expected: PriceOffset(dec("1.5"))},
+		{price: "#0.25%", expected: PriceOffset(dec("0.25")).Percent()},
+	}
+
+	for _, c := range cases {
+		t.Run(c.price, func(t *testing.T) {
+			price, err := ParseOrderPrice(c.price)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.expected, price, t)
+			assert(c.price, price.String(), t)
+		})
+	}
+
+	for _, invalid := range []string{"", "+", "%", "2%", "+-2", "--2", "#abc", "+2%%"} {
+		if _, err := ParseOrderPrice(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestOrderPrice_IsZero(t *testing.T) {
+	assert(true, OrderPrice{}.IsZero(), t)
+	assert(false, PriceAt(dec("1")).IsZero(), t)
+	assert(false, PricePlus(dec("0")).IsZero(), t)
+}
@@ -0,0 +1,214 @@
+package gokraken
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// TierStarter is the Kraken starter verification tier.
+	TierStarter VerificationTier = iota
+
+	// TierIntermediate is the Kraken intermediate verification tier.
+	TierIntermediate
+
+	// TierPro is the Kraken pro verification tier.
+	TierPro
+)
+
+var (
+	// apiCounterLimits are the maximum API call counter values and decay rates
+	// (per second) for each verification tier.
+	// https://support.kraken.com/hc/en-us/articles/206548367
+	apiCounterLimits = map[VerificationTier]counterLimit{
+		TierStarter:      {max: 15, decay: 0.33},
+		TierIntermediate: {max: 20, decay: 0.5},
+		TierPro:          {max: 20, decay: 1},
+	}
+
+	// orderCounterLimits are the maximum matching engine counter values and
+	// decay rates (per second) for each verification tier.
+	// https://support.kraken.com/hc/en-us/articles/360045239571
+	orderCounterLimits = map[VerificationTier]counterLimit{
+		TierStarter:      {max: 60, decay: 1},
+		TierIntermediate: {max: 125, decay: 2.34},
+		TierPro:          {max: 180, decay: 3.75},
+	}
+
+	// resourceCosts are the API call counter costs of resources which do not
+	// cost the default of 1.
+	resourceCosts = map[string]float64{
+		LedgersResource:       2,
+		QueryLedgersResource:  2,
+		TradesHistoryResource: 2,
+	}
+
+	// orderResources are resources which are limited by the matching engine
+	// rather than the API call counter.
+	orderResources = map[string]bool{
+		AddOrderResource:      true,
+		AddOrderBatchResource: true,
+		EditOrderResource:     true,
+		CancelOrderResource:   true,
+	}
+)
+
+// costKey is the context key of the cost of a call whose cost depends on the
+// request, such as a batch of orders.
+type costKey struct{}
+
+// withCost returns a context which sets the rate limit cost of the call made
+// with it.
+func withCost(ctx context.Context, cost float64) context.Context {
+	return context.WithValue(ctx, costKey{}, cost)
+}
+
+// callCost returns the rate limit cost of a call to the given resource.
+func callCost(ctx context.Context, resource string) float64 {
+	if cost, ok := ctx.Value(costKey{}).(float64); ok {
+		return cost
+	}
+
+	if cost, ok := resourceCosts[resource]; ok {
+		return cost
+	}
+
+	return 1
+}
+
+// VerificationTier is a Kraken account verification tier, which determines
+// the rate limits applied to the account.
+type VerificationTier int
+
+// counterLimit is the maximum value and decay rate of a rate limit counter.
+type counterLimit struct {
+	max   float64
+	decay float64
+}
+
+// rateCounter is a counter that decays linearly over time.
+type rateCounter struct {
+	counterLimit
+	value float64
+	last  time.Time
+}
+
+// update decays the counter to the given time.
+func (c *rateCounter) update(now time.Time) {
+	if !c.last.IsZero() {
+		c.value = math.Max(0, c.value-now.Sub(c.last).Seconds()*c.decay)
+	}
+	c.last = now
+}
+
+// reserve adds cost to the counter if it fits within the maximum, otherwise
+// it returns how long to wait until it will.
+func (c *rateCounter) reserve(now time.Time, cost float64) (wait time.Duration, ok bool) {
+	c.update(now)
+
+	if c.value+cost <= c.max {
+		c.value += cost
+		return 0, true
+	}
+
+	seconds := (c.value + cost - c.max) / c.decay
+	return time.Duration(math.Ceil(seconds * float64(time.Second))), false
+}
+
+// RateLimiter is a client side model of the Kraken API rate limits.
+//
+// Kraken tracks a call counter per API key which is increased by each private
+// call and decays over time. Ledger and trade history calls cost 2, all other
+// calls cost 1. Order placement, editing and cancellation are instead limited
+// by a separate matching engine counter, where a batch of orders costs one per
+// order. Kraken additionally penalises cancelling
+// recently placed orders, which is not modelled here.
+//
+// A RateLimiter is safe for concurrent use and should be shared by all clients
+// using the same API key.
+// https://support.kraken.com/hc/en-us/articles/206548367
+type RateLimiter struct {
+	mu    sync.Mutex
+	api   rateCounter
+	order rateCounter
+	now   func() time.Time
+}
+
+// NewRateLimiter returns a new RateLimiter for the given verification tier.
+func NewRateLimiter(tier VerificationTier) *RateLimiter {
+	return &RateLimiter{
+		api:   rateCounter{counterLimit: apiCounterLimits[tier]},
+		order: rateCounter{counterLimit: orderCounterLimits[tier]},
+		now:   time.Now,
+	}
+}
+
+// Wait blocks until a call to the given private resource can be made without
+// exceeding the rate limit, or the context is done.
+func (r *RateLimiter) Wait(ctx context.Context, resource string) error {
+	_, err := r.wait(ctx, resource)
+	return err
+}
+
+// wait blocks like Wait and returns how long it was blocked for. A call which
+// costs more than the maximum of its counter can never be made, so fails
+// immediately.
+func (r *RateLimiter) wait(ctx context.Context, resource string) (blocked time.Duration, err error) {
+	if cost, max := callCost(ctx, resource), r.counter(resource).max; cost > max {
+		err = fmt.Errorf("cost %g of %s exceeds the rate limit of %g", cost, resource, max)
+		return
+	}
+
+	start := time.Now()
+
+	for {
+		wait, ok := r.reserve(ctx, resource)
+		if ok {
+			return
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+			return
+		case <-timer.C:
+		}
+
+		blocked = time.Since(start)
+	}
+}
+
+// Exceeded marks the counter for the given resource as full. It should be
+// called when Kraken reports that a rate limit has been exceeded, so that the
+// limiter resynchronises with the server side counter.
+func (r *RateLimiter) Exceeded(resource string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.counter(resource)
+	c.update(r.now())
+	c.value = c.max
+}
+
+// reserve attempts to add the cost of a call to the given resource to its
+// counter.
+func (r *RateLimiter) reserve(ctx context.Context, resource string) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.counter(resource).reserve(r.now(), callCost(ctx, resource))
+}
+
+// counter returns the counter that limits the given resource.
+func (r *RateLimiter) counter(resource string) *rateCounter {
+	if orderResources[resource] {
+		return &r.order
+	}
+
+	return &r.api
+}
@@ -0,0 +1,186 @@
+package gokraken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for rate limiter tests.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	cases := []struct {
+		name         string
+		tier         VerificationTier
+		resource     string
+		calls        int
+		advance      time.Duration
+		expectedOk   bool
+		expectedWait time.Duration
+	}{
+		{
+			name:       "within limit",
+			tier:       TierStarter,
+			resource:   BalanceResource,
+			calls:      14,
+			expectedOk: true,
+		},
+		{
+			name:         "exceeds limit",
+			tier:         TierStarter,
+			resource:     BalanceResource,
+			calls:        15,
+			expectedWait: 3030 * time.Millisecond,
+		},
+		{
+			name:       "decayed",
+			tier:       TierPro,
+			resource:   BalanceResource,
+			calls:      20,
+			advance:    time.Second,
+			expectedOk: true,
+		},
+		{
+			name:         "ledgers cost double",
+			tier:         TierPro,
+			resource:     LedgersResource,
+			calls:        10,
+			expectedWait: 2 * time.Second,
+		},
+		{
+			name:       "orders counted separately",
+			tier:       TierStarter,
+			resource:   AddOrderResource,
+			calls:      59,
+			expectedOk: true,
+		},
+		{
+			name:         "edits counted as orders",
+			tier:         TierStarter,
+			resource:     EditOrderResource,
+			calls:        60,
+			expectedWait: time.Second,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clock := &fakeClock{t: time.Unix(1520287055, 0)}
+
+			r := NewRateLimiter(c.tier)
+			r.now = clock.now
+
+			for i := 0; i < c.calls; i++ {
+				if _, ok := r.reserve(context.Background(), c.resource); !ok {
+					t.Fatalf("%s: call %d was limited", t.Name(), i)
+				}
+			}
+
+			clock.t = clock.t.Add(c.advance)
+
+			wait, ok := r.reserve(context.Background(), c.resource)
+
+			assert(c.expectedOk, ok, t)
+			if !ok && wait.Truncate(time.Millisecond) != c.expectedWait {
+				t.Fatalf("%s: expected wait %s, got %s", t.Name(), c.expectedWait, wait)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_WaitCancelled(t *testing.T) {
+	r := NewRateLimiter(TierStarter)
+	r.Exceeded(BalanceResource)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := r.Wait(ctx, BalanceResource)
+
+	assert(context.DeadlineExceeded, err, t)
+}
+
+func TestRateLimiter_ExceededOrders(t *testing.T) {
+	r := NewRateLimiter(TierStarter)
+	r.Exceeded(EditOrderResource)
+
+	// Only the matching engine counter is filled.
+	_, ok := r.reserve(context.Background(), BalanceResource)
+	assert(true, ok, t)
+
+	_, ok = r.reserve(context.Background(), AddOrderResource)
+	assert(false, ok, t)
+}
+
+func TestRateLimiter_BatchCost(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1520287055, 0)}
+
+	r := NewRateLimiter(TierStarter)
+	r.now = clock.now
+
+	// Each batch of 15 orders costs 15, so four fill the counter.
+	ctx := withCost(context.Background(), 15)
+	for i := 0; i < 4; i++ {
+		if _, ok := r.reserve(ctx, AddOrderBatchResource); !ok {
+			t.Fatalf("%s: batch %d was limited", t.Name(), i)
+		}
+	}
+
+	wait, ok := r.reserve(ctx, AddOrderBatchResource)
+	assert(false, ok, t)
+	assert(15*time.Second, wait, t)
+
+	_, ok = r.reserve(context.Background(), BalanceResource)
+	assert(true, ok, t)
+}
+
+func TestRateLimiter_WaitCostExceedsMax(t *testing.T) {
+	r := NewRateLimiter(TierStarter)
+
+	// The call can never fit, so fails without waiting for the context.
+	err := r.Wait(withCost(context.Background(), 61), AddOrderBatchResource)
+
+	assert("cost 61 of AddOrderBatch exceeds the rate limit of 60", err.Error(), t)
+}
+
+func TestKraken_CallRateLimited(t *testing.T) {
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":["EAPI:Rate limit exceeded"]}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+	k.Limiter = NewRateLimiter(TierStarter)
+
+	// The first call is sent and the rate limit error fills the limiter.
+	if _, err := k.UserData.Balance(context.Background()); err == nil {
+		t.Fatalf("%s: expected rate limit error", t.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// The second call is held by the limiter until the context expires.
+	_, err := k.UserData.Balance(ctx)
+
+	assert(context.DeadlineExceeded, err, t)
+	assert(int32(1), atomic.LoadInt32(&calls), t)
+}
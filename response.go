@@ -8,9 +8,37 @@ type Response struct {
 	Result interface{} `json:"result"`
 }
 
+// Err returns the first error message from a Kraken API response as an
+// *APIError, or nil if the response contains no errors. Warnings are ignored.
+func (r *Response) Err() error {
+	for _, msg := range r.Error {
+		if e := ParseAPIError(msg); !e.Warning() {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// Warnings returns the warning messages from a Kraken API response.
+func (r *Response) Warnings() (warnings []*APIError) {
+	for _, msg := range r.Error {
+		if e := ParseAPIError(msg); e.Warning() {
+			warnings = append(warnings, e)
+		}
+	}
+
+	return
+}
+
 // ExtractResult extracts the result from a Kraken API response into the
-// destination parameter.
+// destination parameter. If the response contains an error it is returned
+// instead.
 func (r *Response) ExtractResult(dst interface{}) error {
+	if err := r.Err(); err != nil {
+		return err
+	}
+
 	resultJSON, err := json.Marshal(r.Result)
 	if err != nil {
 		return err
@@ -1,6 +1,9 @@
 package gokraken
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestResponse_ExtractResult(t *testing.T) {
 	exampleResp := Response{
@@ -15,3 +18,28 @@ func TestResponse_ExtractResult(t *testing.T) {
 
 	assert(exampleResp.Result, dst, t)
 }
+
+func TestResponse_ExtractResultError(t *testing.T) {
+	exampleResp := Response{
+		Error: []string{"EOrder:Unknown order"},
+	}
+
+	var dst map[string]interface{}
+	err := exampleResp.ExtractResult(&dst)
+
+	if !errors.Is(err, ErrUnknownOrder) {
+		t.Fatalf("%s: expected %v, got %v", t.Name(), ErrUnknownOrder, err)
+	}
+}
+
+func TestResponse_Warnings(t *testing.T) {
+	exampleResp := Response{
+		Error: []string{"WGeneral:Unknown field", "EAPI:Invalid nonce"},
+	}
+
+	assert([]*APIError{ParseAPIError("WGeneral:Unknown field")}, exampleResp.Warnings(), t)
+
+	if !errors.Is(exampleResp.Err(), ErrInvalidNonce) {
+		t.Fatalf("%s: expected %v, got %v", t.Name(), ErrInvalidNonce, exampleResp.Err())
+	}
+}
@@ -0,0 +1,130 @@
+package gokraken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+var (
+	// DefaultRetryPolicy is a retry policy suitable for most clients.
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+	}
+
+	// nonIdempotentResources are resources which must not be retried after an
+	// ambiguous failure, as the failed attempt may have been processed.
+	nonIdempotentResources = map[string]bool{
+		AddOrderResource:      true,
+		AddOrderBatchResource: true,
+		AddExportResource:     true,
+		EditOrderResource:     true,
+		WithdrawResource:      true,
+	}
+)
+
+// HTTPError is returned when the Kraken API responds with a server error
+// status instead of a JSON response.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+// Error returns the HTTP status of the response.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected response status: %s", e.Status)
+}
+
+// RetryPolicy controls how failed calls to the Kraken API are retried.
+//
+// Calls are retried after network errors, server error responses and the
+// EService:Unavailable, EService:Busy and EGeneral:Temporary lockout errors.
+// Non-idempotent calls such as AddOrder are only retried when Kraken has
+// confirmed that the request was not processed.
+type RetryPolicy struct {
+	MaxAttempts int           // Maximum number of attempts, including the first.
+	BaseDelay   time.Duration // Delay before the first retry.
+	MaxDelay    time.Duration // Maximum delay between attempts.
+}
+
+// Backoff returns the delay before the given retry attempt. The delay grows
+// exponentially from BaseDelay up to MaxDelay with full jitter applied.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+// wait blocks for the backoff delay of the given attempt. It returns false
+// without waiting if the context would be done before the delay elapses.
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	delay := p.Backoff(attempt)
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// retryable reports whether a call to the given resource which failed with
+// err should be retried.
+func (p *RetryPolicy) retryable(ctx context.Context, resource string, attempt int, err error) bool {
+	if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	if rejected(err) {
+		return true
+	}
+
+	if nonIdempotentResources[resource] {
+		return false
+	}
+
+	return ambiguous(err)
+}
+
+// rejected reports whether err indicates that Kraken did not process the
+// request due to a transient condition.
+func rejected(err error) bool {
+	return errors.Is(err, ErrServiceUnavailable) ||
+		errors.Is(err, ErrServiceBusy) ||
+		errors.Is(err, ErrTemporaryLockout)
+}
+
+// ambiguous reports whether err is a transient failure after which it is not
+// known whether Kraken processed the request.
+func ambiguous(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
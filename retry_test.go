@@ -0,0 +1,236 @@
+package gokraken
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+	}
+
+	cases := []struct {
+		attempt  int
+		maxDelay time.Duration
+	}{
+		{attempt: 1, maxDelay: 100 * time.Millisecond},
+		{attempt: 2, maxDelay: 200 * time.Millisecond},
+		{attempt: 3, maxDelay: 400 * time.Millisecond},
+		{attempt: 10, maxDelay: time.Second},
+	}
+	for _, c := range cases {
+		for i := 0; i < 100; i++ {
+			if delay := p.Backoff(c.attempt); delay < 0 || delay >= c.maxDelay {
+				t.Fatalf("%s: attempt %d delay %s not in [0, %s)", t.Name(), c.attempt, delay, c.maxDelay)
+			}
+		}
+	}
+}
+
+func TestKraken_CallRetry(t *testing.T) {
+	cases := []struct {
+		name          string
+		responses     []int
+		body          string
+		resource      string
+		expectedCalls int
+		expectError   bool
+	}{
+		{
+			name:          "server error",
+			responses:     []int{http.StatusBadGateway, http.StatusOK},
+			body:          `{"error":[],"result":{}}`,
+			resource:      BalanceResource,
+			expectedCalls: 2,
+		},
+		{
+			name:          "service unavailable",
+			responses:     []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			body:          `{"error":["EService:Unavailable"]}`,
+			resource:      BalanceResource,
+			expectedCalls: 3,
+			expectError:   true,
+		},
+		{
+			name:          "not retryable",
+			responses:     []int{http.StatusOK},
+			body:          `{"error":["EOrder:Insufficient funds"]}`,
+			resource:      BalanceResource,
+			expectedCalls: 1,
+			expectError:   true,
+		},
+		{
+			name:          "non-idempotent server error",
+			responses:     []int{http.StatusBadGateway},
+			resource:      AddOrderResource,
+			expectedCalls: 1,
+			expectError:   true,
+		},
+		{
+			name:          "non-idempotent rejected",
+			responses:     []int{http.StatusOK, http.StatusOK},
+			body:          `{"error":["EService:Busy"]}`,
+			resource:      AddOrderResource,
+			expectedCalls: 2,
+			expectError:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var nonces []string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				body, _ := url.ParseQuery(string(b))
+				nonces = append(nonces, body.Get(APINonceParam))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(c.responses[len(nonces)-1])
+
+				w.Write([]byte(c.body))
+			}))
+
+			defer ts.Close()
+
+			k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+			k.BaseURL = ts.URL
+			k.Retry = &RetryPolicy{
+				MaxAttempts: len(c.responses),
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    time.Millisecond,
+			}
+
+			req, err := k.DialWithAuth(context.Background(), http.MethodPost, c.resource, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = k.Call(req)
+
+			assert(c.expectError, err != nil, t)
+			assert(c.expectedCalls, len(nonces), t)
+
+			for i := 1; i < len(nonces); i++ {
+				if nonces[i] == nonces[i-1] {
+					t.Fatalf("%s: nonce reused on retry", t.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestKraken_CallRedialError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+	k.Retry = &RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+	}
+
+	req, err := k.DialWithAuth(context.Background(), http.MethodPost, BalanceResource, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bodyErr := errors.New("body gone")
+	req.GetBody = func() (io.ReadCloser, error) {
+		return nil, bodyErr
+	}
+
+	_, err = k.Call(req)
+	assert(true, errors.Is(err, bodyErr), t)
+}
+
+func TestTrading_AddOrderRetry(t *testing.T) {
+	cases := []struct {
+		name           string
+		openOrders     string
+		expectedResult *AddOrderResponse
+		expectedAdds   int
+	}{
+		{
+			name:       "not placed",
+			openOrders: `{"error":[],"result":{"open":{},"count":0}}`,
+			expectedResult: &AddOrderResponse{
+				TxIDs: []string{"OQCLML-BW3P3-BUCMWZ"},
+			},
+			expectedAdds: 2,
+		},
+		{
+			name:       "placed",
+			openOrders: `{"error":[],"result":{"open":{"OUF4EM-FRGI2-MQMWZD":{"userref":1234}},"count":1}}`,
+			expectedResult: &AddOrderResponse{
+				TxIDs: []string{"OUF4EM-FRGI2-MQMWZD"},
+			},
+			expectedAdds: 1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var adds int
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				switch r.URL.Path {
+				case "/0/private/AddOrder":
+					adds++
+					if adds == 1 {
+						w.WriteHeader(http.StatusGatewayTimeout)
+						return
+					}
+
+					w.Write([]byte(`{"error":[],"result":{"txid":["OQCLML-BW3P3-BUCMWZ"]}}`))
+				case "/0/private/OpenOrders":
+					w.Write([]byte(c.openOrders))
+				case "/0/private/ClosedOrders":
+					w.Write([]byte(`{"error":[],"result":{"closed":{},"count":0}}`))
+				}
+			}))
+
+			defer ts.Close()
+
+			k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+			k.BaseURL = ts.URL
+			k.Retry = &RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    time.Millisecond,
+			}
+
+			order := UserOrder{
+				Pair:      pairs.BCHEUR,
+				Type:      TradeSell,
+				OrderType: OrderTypeMarket,
+				Volume:    dec("1.23"),
+				UserRef:   1234,
+			}
+
+			res, err := k.Trading.AddOrder(context.Background(), order)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.expectedResult, res, t)
+			assert(c.expectedAdds, adds, t)
+		})
+	}
+}
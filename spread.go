@@ -24,6 +24,6 @@ type SpreadResponse struct {
 // SpreadData is the spread of data for trades.
 type SpreadData struct {
 	Timestamp time.Time
-	Bid       float64
-	Ask       float64
+	Bid       Decimal
+	Ask       Decimal
 }
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// Cache keeps series of market data in a store up to date, fetching only the
+// data after the cursor stored with each series.
+type Cache struct {
+	Market *gokraken.Market
+	Store  Store
+}
+
+// NewCache returns a new Cache which fetches market data from Kraken into a
+// store.
+func NewCache(market *gokraken.Market, store Store) *Cache {
+	return &Cache{
+		Market: market,
+		Store:  store,
+	}
+}
+
+// UpdateTrades fetches the trades of a pair since the stored cursor, page by
+// page until it reaches the latest trade, and appends them to the store. It
+// returns the number of trades appended.
+//
+// Trades are fetched from start when nothing has been stored for the pair,
+// as otherwise the whole trade history would be walked. It must be set for
+// the first update and is ignored once there is a cursor.
+func (c *Cache) UpdateTrades(ctx context.Context, pair pairs.AssetPair, start time.Time) (n int, err error) {
+	series := TradesSeries(pair)
+
+	since, err := c.Store.Cursor(series)
+	if err != nil {
+		return
+	}
+
+	if since == 0 {
+		if start.IsZero() {
+			err = fmt.Errorf("no trades stored for %s, a start time is required", pair)
+			return
+		}
+
+		since = start.UnixNano()
+	}
+
+	for {
+		var res *gokraken.TradesResponse
+		res, err = c.Market.Trades(ctx, gokraken.TradesRequest{Pair: pair, Since: since})
+		if err != nil {
+			return
+		}
+
+		if len(res.Trades) == 0 || res.Last == since {
+			return
+		}
+
+		if err = c.Store.AppendTrades(series, res.Trades, res.Last); err != nil {
+			return
+		}
+
+		n += len(res.Trades)
+		since = res.Last
+	}
+}
+
+// UpdateOhlc fetches the OHLC data of a pair at an interval in minutes since
+// the stored cursor and appends it to the store. Kraken only returns the last
+// 720 intervals, so a series which has not been updated for longer has a gap.
+func (c *Cache) UpdateOhlc(ctx context.Context, pair pairs.AssetPair, interval int) (n int, err error) {
+	series := OhlcSeries(pair, interval)
+
+	since, err := c.Store.Cursor(series)
+	if err != nil {
+		return
+	}
+
+	res, err := c.Market.Ohlc(ctx, gokraken.OhlcRequest{Pair: pair, Interval: interval, Since: since})
+	if err != nil || len(res.Data) == 0 {
+		return
+	}
+
+	if err = c.Store.AppendOhlc(series, res.Data, res.Last); err != nil {
+		return
+	}
+
+	return len(res.Data), nil
+}
+
+// UpdateSpread fetches the spread data of a pair since the stored cursor and
+// appends it to the store.
+func (c *Cache) UpdateSpread(ctx context.Context, pair pairs.AssetPair) (n int, err error) {
+	series := SpreadSeries(pair)
+
+	since, err := c.Store.Cursor(series)
+	if err != nil {
+		return
+	}
+
+	res, err := c.Market.Spread(ctx, gokraken.SpreadRequest{Pair: pair, Since: since})
+	if err != nil || len(res.Data) == 0 {
+		return
+	}
+
+	if err = c.Store.AppendSpread(series, res.Data, res.Last); err != nil {
+		return
+	}
+
+	return len(res.Data), nil
+}
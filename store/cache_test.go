@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+func TestCache_UpdateTrades(t *testing.T) {
+	// Three trades, served two at a time.
+	times := []int64{1520035100, 1520035200, 1520035300}
+
+	var sinces []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ := url.ParseQuery(string(b))
+		sinces = append(sinces, body.Get("since"))
+		since, _ := strconv.ParseInt(body.Get("since"), 10, 64)
+
+		page := make([]interface{}, 0)
+		last := since
+		for _, sec := range times {
+			if ns := sec * int64(time.Second); ns > since && len(page) < 2 {
+				page = append(page, []interface{}{"100.5", "1", float64(sec), "b", "m", ""})
+				last = ns
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":  []string{},
+			"result": map[string]interface{}{"XXBTZUSD": page, "last": strconv.FormatInt(last, 10)},
+		})
+	}))
+	defer ts.Close()
+
+	k := gokraken.New()
+	k.BaseURL = ts.URL
+
+	s, _ := tempStore(CSV, t)
+	c := NewCache(k.Market, s)
+
+	// The first update needs a time to start from.
+	_, err := c.UpdateTrades(context.Background(), pairs.XXBTZUSD, time.Time{})
+	assert("no trades stored for XXBTZUSD, a start time is required", err.Error(), t)
+
+	n, err := c.UpdateTrades(context.Background(), pairs.XXBTZUSD, time.Unix(1520035000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(3, n, t)
+	assert([]string{"1520035000000000000", "1520035200000000000", "1520035300000000000"}, sinces, t)
+
+	// Updating again only fetches trades after the stored cursor.
+	times = append(times, 1520035400)
+	n, err = c.UpdateTrades(context.Background(), pairs.XXBTZUSD, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(1, n, t)
+	assert("1520035300000000000", sinces[3], t)
+
+	trades, err := s.Trades(TradesSeries(pairs.XXBTZUSD), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(4, len(trades), t)
+	assert(time.Unix(1520035400, 0), trades[3].Timestamp, t)
+}
+
+func TestCache_UpdateOhlc(t *testing.T) {
+	var since string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ := url.ParseQuery(string(b))
+		since = body.Get("since")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error":[],"result":{"XXBTZUSD":[[1520031600,"1","2","0.5","1.5","1.2","10",4],[1520035200,"1.5","1.5","1.5","1.5","1.5","1",1]],"last":1520031600}}`))
+	}))
+	defer ts.Close()
+
+	k := gokraken.New()
+	k.BaseURL = ts.URL
+
+	s, _ := tempStore(CSV, t)
+	c := NewCache(k.Market, s)
+
+	for i := 0; i < 2; i++ {
+		n, err := c.UpdateOhlc(context.Background(), pairs.XXBTZUSD, 60)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert(2, n, t)
+	}
+	assert("1520031600", since, t)
+
+	data, err := s.Ohlc(OhlcSeries(pairs.XXBTZUSD, 60), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(2, len(data), t)
+	assert(dec("10"), data[0].Volume, t)
+}
@@ -0,0 +1,14 @@
+package store
+
+import (
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/internal/testutil"
+)
+
+// Test helper for asserting values are equal.
+var assert = testutil.Assert
+
+// Test helper for creating decimal values.
+func dec(s string) gokraken.Decimal {
+	return gokraken.MustParseDecimal(s)
+}
@@ -0,0 +1,466 @@
+package store
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+)
+
+const (
+	// dayLayout is the layout of the names of the daily segment files.
+	dayLayout = "2006-01-02"
+
+	// cursorFile is the name of the file holding the cursor of a series.
+	cursorFile = "cursor"
+
+	// journalFile is the name of the file recording the state of a series
+	// before an append which has not completed.
+	journalFile = "journal"
+)
+
+var (
+	// CSV is the format of comma separated files.
+	CSV = Format{Ext: "csv", Comma: ','}
+
+	// Plain is the format of plain text files, with fields separated by tabs.
+	Plain = Format{Ext: "txt", Comma: '\t'}
+)
+
+// Format is the format of the files of a FileStore.
+type Format struct {
+	Ext   string // File extension, without the dot.
+	Comma rune   // Field separator.
+}
+
+// FileStore is a Store which keeps each series in a directory of append-only
+// files, one per UTC day, e.g. XXBTZUSD/trades/2018-03-02.csv.
+//
+// Each append is journaled, so that an append which is interrupted, such as by
+// a crash, is rolled back the next time the series is used. Rows are then
+// neither duplicated when the append is retried from the previous cursor nor
+// left partly written.
+type FileStore struct {
+	dir    string
+	format Format
+	mu     sync.RWMutex
+}
+
+// NewFileStore returns a new FileStore which keeps its files under the given
+// directory, in the given format.
+func NewFileStore(dir string, format Format) *FileStore {
+	return &FileStore{
+		dir:    dir,
+		format: format,
+	}
+}
+
+// path returns the directory of a series.
+func (s *FileStore) path(series Series) string {
+	name := string(series.Kind)
+	if series.Kind == KindOhlc {
+		name = fmt.Sprintf("%s-%d", series.Kind, series.Interval)
+	}
+
+	return filepath.Join(s.dir, series.Pair.String(), name)
+}
+
+// Cursor returns the cursor stored with a series, or zero if nothing has been
+// appended to it.
+func (s *FileStore) Cursor(series Series) (int64, error) {
+	if err := s.recover(series); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	last, _, err := readCursor(filepath.Join(s.path(series), cursorFile))
+	return last, err
+}
+
+// AppendTrades appends trades to a trades series and stores its cursor.
+func (s *FileStore) AppendTrades(series Series, trades []gokraken.Trade, last int64) error {
+	if err := checkKind(series, KindTrades); err != nil {
+		return err
+	}
+
+	times := make([]time.Time, len(trades))
+	rows := make([][]string, len(trades))
+	for i, trade := range trades {
+		times[i], rows[i] = trade.Timestamp, encodeTrade(trade)
+	}
+
+	return s.append(series, times, rows, last)
+}
+
+// AppendOhlc appends OHLC data to an OHLC series and stores its cursor.
+func (s *FileStore) AppendOhlc(series Series, data []gokraken.OhlcData, last int64) error {
+	if err := checkKind(series, KindOhlc); err != nil {
+		return err
+	}
+
+	times := make([]time.Time, len(data))
+	rows := make([][]string, len(data))
+	for i, d := range data {
+		times[i], rows[i] = d.Timestamp, encodeOhlc(d)
+	}
+
+	return s.append(series, times, rows, last)
+}
+
+// AppendSpread appends spread data to a spread series and stores its cursor.
+func (s *FileStore) AppendSpread(series Series, data []gokraken.SpreadData, last int64) error {
+	if err := checkKind(series, KindSpread); err != nil {
+		return err
+	}
+
+	times := make([]time.Time, len(data))
+	rows := make([][]string, len(data))
+	for i, d := range data {
+		times[i], rows[i] = d.Timestamp, encodeSpread(d)
+	}
+
+	return s.append(series, times, rows, last)
+}
+
+// Trades returns the trades of a series from the given time up to, but not
+// including, the given end. A zero time leaves that side open.
+func (s *FileStore) Trades(series Series, from, to time.Time) (trades []gokraken.Trade, err error) {
+	if err = checkKind(series, KindTrades); err != nil {
+		return
+	}
+
+	err = s.read(series, from, to, func(record []string) error {
+		trade, err := decodeTrade(record)
+		trades = append(trades, trade)
+		return err
+	})
+	return
+}
+
+// Ohlc returns the OHLC data of a series from the given time up to, but not
+// including, the given end. A zero time leaves that side open. Where OHLC data
+// was appended more than once for the same time, the last is returned.
+func (s *FileStore) Ohlc(series Series, from, to time.Time) (data []gokraken.OhlcData, err error) {
+	if err = checkKind(series, KindOhlc); err != nil {
+		return
+	}
+
+	index := make(map[int64]int)
+	err = s.read(series, from, to, func(record []string) error {
+		d, err := decodeOhlc(record)
+		if err != nil {
+			return err
+		}
+
+		if i, ok := index[d.Timestamp.UnixNano()]; ok {
+			data[i] = d
+			return nil
+		}
+
+		index[d.Timestamp.UnixNano()] = len(data)
+		data = append(data, d)
+		return nil
+	})
+	return
+}
+
+// Spread returns the spread data of a series from the given time up to, but
+// not including, the given end. A zero time leaves that side open.
+func (s *FileStore) Spread(series Series, from, to time.Time) (data []gokraken.SpreadData, err error) {
+	if err = checkKind(series, KindSpread); err != nil {
+		return
+	}
+
+	err = s.read(series, from, to, func(record []string) error {
+		d, err := decodeSpread(record)
+		data = append(data, d)
+		return err
+	})
+	return
+}
+
+// journal records the state of a series before an append.
+type journal struct {
+	Cursor    int64            `json:"cursor"`
+	HasCursor bool             `json:"has_cursor"`
+	Sizes     map[string]int64 `json:"sizes"` // By file name, -1 if it did not exist.
+}
+
+// append appends rows to the daily files of a series, by the given times, then
+// stores its cursor.
+//
+// The sizes of the files and the cursor are first written to a journal, which
+// is removed once the rows and the cursor are written. If the append fails, or
+// the process stops before it completes, the files and the cursor are restored
+// from the journal, so that the append can be retried from the previous
+// cursor.
+func (s *FileStore) append(series Series, times []time.Time, rows [][]string, last int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.path(series)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := rollback(dir); err != nil {
+		return err
+	}
+
+	// Group the rows into daily files.
+	var names []string
+	groups := make(map[string][][]string)
+	for i, row := range rows {
+		name := filepath.Join(dir, times[i].UTC().Format(dayLayout)+"."+s.format.Ext)
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], row)
+	}
+
+	if err := writeJournal(dir, names); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := s.appendFile(name, groups[name]); err != nil {
+			return joinRollback(err, dir)
+		}
+	}
+
+	if err := writeCursor(filepath.Join(dir, cursorFile), last); err != nil {
+		return joinRollback(err, dir)
+	}
+
+	return os.Remove(filepath.Join(dir, journalFile))
+}
+
+// recover rolls back an interrupted append to a series.
+func (s *FileStore) recover(series Series) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return rollback(s.path(series))
+}
+
+// writeJournal records the cursor of the series in dir and the sizes of the
+// given files.
+func writeJournal(dir string, names []string) (err error) {
+	j := journal{Sizes: make(map[string]int64, len(names))}
+
+	j.Cursor, j.HasCursor, err = readCursor(filepath.Join(dir, cursorFile))
+	if err != nil {
+		return
+	}
+
+	for _, name := range names {
+		info, err := os.Stat(name)
+		switch {
+		case os.IsNotExist(err):
+			j.Sizes[filepath.Base(name)] = -1
+		case err != nil:
+			return err
+		default:
+			j.Sizes[filepath.Base(name)] = info.Size()
+		}
+	}
+
+	b, err := json.Marshal(j)
+	if err != nil {
+		return
+	}
+
+	return writeFile(filepath.Join(dir, journalFile), b)
+}
+
+// rollback restores the files and cursor of the series in dir from its
+// journal, if an append did not complete.
+func rollback(dir string) error {
+	name := filepath.Join(dir, journalFile)
+
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var j journal
+	if err = json.Unmarshal(b, &j); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	for file, size := range j.Sizes {
+		if size < 0 {
+			err = os.Remove(filepath.Join(dir, file))
+		} else {
+			err = os.Truncate(filepath.Join(dir, file), size)
+		}
+
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if j.HasCursor {
+		err = writeCursor(filepath.Join(dir, cursorFile), j.Cursor)
+	} else {
+		err = os.Remove(filepath.Join(dir, cursorFile))
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return os.Remove(name)
+}
+
+// joinRollback rolls back a failed append, adding any error doing so to err.
+func joinRollback(err error, dir string) error {
+	if rbErr := rollback(dir); rbErr != nil {
+		return fmt.Errorf("%v (rollback failed: %v)", err, rbErr)
+	}
+
+	return err
+}
+
+// appendFile appends rows to a file, creating it if required.
+func (s *FileStore) appendFile(name string, rows [][]string) error {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	w.Comma = s.format.Comma
+	if err = w.WriteAll(rows); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// readCursor reads the cursor file of a series, returning whether it exists.
+func readCursor(name string) (int64, bool, error) {
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	last, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	return last, err == nil, err
+}
+
+// writeCursor replaces the cursor file of a series.
+func writeCursor(name string, last int64) error {
+	return writeFile(name, []byte(strconv.FormatInt(last, 10)+"\n"))
+}
+
+// writeFile replaces a file by writing a temporary file and renaming it.
+func writeFile(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
+
+// read calls fn with each record of a series from the given time up to, but
+// not including, the given end, oldest first.
+func (s *FileStore) read(series Series, from, to time.Time, fn func(record []string) error) error {
+	if err := s.recover(series); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dir := s.path(series)
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if filepath.Ext(name) != "."+s.format.Ext {
+			continue
+		}
+
+		day, err := time.Parse(dayLayout, strings.TrimSuffix(name, filepath.Ext(name)))
+		if err != nil {
+			continue
+		}
+
+		// Skip the files of days outside of the range.
+		if !from.IsZero() && !day.AddDate(0, 0, 1).After(from) {
+			continue
+		}
+		if !to.IsZero() && !day.Before(to) {
+			continue
+		}
+
+		if err = s.readFile(filepath.Join(dir, name), from, to, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readFile calls fn with each record of a file in the given range.
+func (s *FileStore) readFile(name string, from, to time.Time, fn func(record []string) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = s.format.Comma
+	r.FieldsPerRecord = -1
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		t, err := gokraken.ParseUnixTime(record[0])
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		if (!from.IsZero() && t.Before(from)) || (!to.IsZero() && !t.Before(to)) {
+			continue
+		}
+
+		if err = fn(record); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// tempStore returns a FileStore in a new temporary directory.
+func tempStore(format Format, t *testing.T) (*FileStore, string) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "gokraken-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return NewFileStore(dir, format), dir
+}
+
+func TestFileStore_Trades(t *testing.T) {
+	trades := []gokraken.Trade{
+		{
+			Price:         dec("700000.1"),
+			Volume:        dec("0.0005"),
+			Timestamp:     time.Unix(1520035199, 766900000),
+			BuySell:       gokraken.TradeSell,
+			MarketLimit:   gokraken.TradeLimit,
+			Miscellaneous: "",
+		},
+		{
+			Price:         dec("700001"),
+			Volume:        dec("1.5"),
+			Timestamp:     time.Unix(1520035200, 0),
+			BuySell:       gokraken.TradeBuy,
+			MarketLimit:   gokraken.TradeMarket,
+			Miscellaneous: "a, \"b\"\tc",
+		},
+	}
+
+	for _, format := range []Format{CSV, Plain} {
+		t.Run(format.Ext, func(t *testing.T) {
+			s, dir := tempStore(format, t)
+			series := TradesSeries(pairs.XXBTZUSD)
+
+			cursor, err := s.Cursor(series)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert(int64(0), cursor, t)
+
+			if err = s.AppendTrades(series, trades, 1520035200000000000); err != nil {
+				t.Fatal(err)
+			}
+
+			// The trades are split into a file per UTC day.
+			for _, name := range []string{"2018-03-02", "2018-03-03"} {
+				if _, err = os.Stat(filepath.Join(dir, "XXBTZUSD", "trades", name+"."+format.Ext)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cursor, err = s.Cursor(series)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert(int64(1520035200000000000), cursor, t)
+
+			res, err := s.Trades(series, time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert(trades, res, t)
+
+			res, err = s.Trades(series, time.Unix(1520035199, 766900001), time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert(trades[1:], res, t)
+
+			res, err = s.Trades(series, time.Time{}, time.Unix(1520035200, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert(trades[:1], res, t)
+		})
+	}
+}
+
+func TestFileStore_Ohlc(t *testing.T) {
+	s, _ := tempStore(CSV, t)
+	series := OhlcSeries(pairs.XXBTZUSD, 60)
+
+	first := []gokraken.OhlcData{
+		{Timestamp: time.Unix(1520031600, 0), Open: dec("1"), High: dec("2"), Low: dec("0.5"), Close: dec("1.5"), Vwap: dec("1.2"), Volume: dec("10"), Count: 4},
+		{Timestamp: time.Unix(1520035200, 0), Open: dec("1.5"), High: dec("1.5"), Low: dec("1.5"), Close: dec("1.5"), Vwap: dec("1.5"), Volume: dec("1"), Count: 1},
+	}
+	if err := s.AppendOhlc(series, first, 1520031600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The interval in progress is fetched again once it has closed.
+	second := []gokraken.OhlcData{
+		{Timestamp: time.Unix(1520035200, 0), Open: dec("1.5"), High: dec("3"), Low: dec("1.5"), Close: dec("3"), Vwap: dec("2"), Volume: dec("5"), Count: 3},
+	}
+	if err := s.AppendOhlc(series, second, 1520035200); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.Ohlc(series, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert([]gokraken.OhlcData{first[0], second[0]}, res, t)
+
+	// Other intervals are separate series.
+	res, err = s.Ohlc(OhlcSeries(pairs.XXBTZUSD, 5), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(0, len(res), t)
+}
+
+func TestFileStore_Spread(t *testing.T) {
+	s, _ := tempStore(Plain, t)
+	series := SpreadSeries(pairs.XXBTZUSD)
+
+	data := []gokraken.SpreadData{
+		{Timestamp: time.Unix(1520035200, 0), Bid: dec("9000.1"), Ask: dec("9000.2")},
+	}
+	if err := s.AppendSpread(series, data, 1520035200); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.Spread(series, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(data, res, t)
+
+	err = s.AppendTrades(series, nil, 0)
+	assert("series XXBTZUSD/spread is not a trades series", err.Error(), t)
+}
+
+func TestFileStore_InterruptedAppend(t *testing.T) {
+	s, dir := tempStore(CSV, t)
+	series := TradesSeries(pairs.XXBTZUSD)
+
+	trades := []gokraken.Trade{
+		{Price: dec("700000.1"), Volume: dec("0.0005"), Timestamp: time.Unix(1520035199, 0), BuySell: gokraken.TradeSell, MarketLimit: gokraken.TradeLimit},
+	}
+	if err := s.AppendTrades(series, trades, 1520035199000000000); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate an append which stopped after writing the cursor, a complete
+	// row and part of a row, including a new daily file.
+	seriesDir := filepath.Join(dir, "XXBTZUSD", "trades")
+	names := []string{filepath.Join(seriesDir, "2018-03-02.csv"), filepath.Join(seriesDir, "2018-03-03.csv")}
+	if err := writeJournal(seriesDir, names); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(names[0], os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("1520035199.5,700000.2,0.1,b,l,\n1520035199.6,7000")
+	f.Close()
+
+	if err = ioutil.WriteFile(names[1], []byte("1520035200,700001,1.5,b,m,\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = writeCursor(filepath.Join(seriesDir, cursorFile), 1520035200000000000); err != nil {
+		t.Fatal(err)
+	}
+
+	// The append is rolled back, so that it can be retried from the previous
+	// cursor without duplicating rows.
+	cursor, err := s.Cursor(series)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(int64(1520035199000000000), cursor, t)
+
+	res, err := s.Trades(series, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(trades, res, t)
+
+	_, err = os.Stat(names[1])
+	assert(true, os.IsNotExist(err), t)
+
+	_, err = os.Stat(filepath.Join(seriesDir, journalFile))
+	assert(true, os.IsNotExist(err), t)
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/danmrichards/gokraken"
+)
+
+// Records are written one per line, starting with the time of the record in
+// Unix seconds, e.g.:
+//
+//	trades: time, price, volume, buy/sell, market/limit, miscellaneous
+//	ohlc:   time, open, high, low, close, vwap, volume, count
+//	spread: time, bid, ask
+
+// checkFields returns an error if a record does not have n fields.
+func checkFields(record []string, n int) error {
+	if len(record) != n {
+		return fmt.Errorf("invalid record %q: expected %d fields", record, n)
+	}
+
+	return nil
+}
+
+// encodeTrade returns the fields of a trade record.
+func encodeTrade(trade gokraken.Trade) []string {
+	return []string{
+		gokraken.FormatUnixTime(trade.Timestamp),
+		trade.Price.String(),
+		trade.Volume.String(),
+		string(trade.BuySell),
+		string(trade.MarketLimit),
+		trade.Miscellaneous,
+	}
+}
+
+// decodeTrade parses the fields of a trade record.
+func decodeTrade(record []string) (trade gokraken.Trade, err error) {
+	if err = checkFields(record, 6); err != nil {
+		return
+	}
+
+	if trade.Timestamp, err = gokraken.ParseUnixTime(record[0]); err != nil {
+		return
+	}
+
+	if err = gokraken.ParseDecimals(record[1:3], &trade.Price, &trade.Volume); err != nil {
+		return
+	}
+
+	trade.BuySell = gokraken.TradeBuySell(record[3])
+	trade.MarketLimit = gokraken.TradeMarketLimit(record[4])
+	trade.Miscellaneous = record[5]
+	return
+}
+
+// encodeOhlc returns the fields of an OHLC record.
+func encodeOhlc(data gokraken.OhlcData) []string {
+	return []string{
+		gokraken.FormatUnixTime(data.Timestamp),
+		data.Open.String(),
+		data.High.String(),
+		data.Low.String(),
+		data.Close.String(),
+		data.Vwap.String(),
+		data.Volume.String(),
+		strconv.Itoa(data.Count),
+	}
+}
+
+// decodeOhlc parses the fields of an OHLC record.
+func decodeOhlc(record []string) (data gokraken.OhlcData, err error) {
+	if err = checkFields(record, 8); err != nil {
+		return
+	}
+
+	if data.Timestamp, err = gokraken.ParseUnixTime(record[0]); err != nil {
+		return
+	}
+
+	err = gokraken.ParseDecimals(record[1:7], &data.Open, &data.High, &data.Low, &data.Close, &data.Vwap, &data.Volume)
+	if err != nil {
+		return
+	}
+
+	data.Count, err = strconv.Atoi(record[7])
+	return
+}
+
+// encodeSpread returns the fields of a spread record.
+func encodeSpread(data gokraken.SpreadData) []string {
+	return []string{
+		gokraken.FormatUnixTime(data.Timestamp),
+		data.Bid.String(),
+		data.Ask.String(),
+	}
+}
+
+// decodeSpread parses the fields of a spread record.
+func decodeSpread(record []string) (data gokraken.SpreadData, err error) {
+	if err = checkFields(record, 3); err != nil {
+		return
+	}
+
+	if data.Timestamp, err = gokraken.ParseUnixTime(record[0]); err != nil {
+		return
+	}
+
+	err = gokraken.ParseDecimals(record[1:3], &data.Bid, &data.Ask)
+	return
+}
@@ -0,0 +1,100 @@
+// Package store persists series of Kraken market data, so that they can be
+// cached and updated incrementally from the Last cursor of each response.
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+const (
+	// KindTrades is the kind of a series of trades.
+	KindTrades Kind = "trades"
+
+	// KindOhlc is the kind of a series of OHLC data.
+	KindOhlc Kind = "ohlc"
+
+	// KindSpread is the kind of a series of spread data.
+	KindSpread Kind = "spread"
+)
+
+// Kind is the kind of data held by a series.
+type Kind string
+
+// Series identifies a series of market data for an asset pair.
+type Series struct {
+	Kind     Kind
+	Pair     pairs.AssetPair
+	Interval int // Interval of OHLC data in minutes.
+}
+
+// TradesSeries returns the series of trades of an asset pair.
+func TradesSeries(pair pairs.AssetPair) Series {
+	return Series{Kind: KindTrades, Pair: pair}
+}
+
+// OhlcSeries returns the series of OHLC data of an asset pair at an interval
+// in minutes.
+func OhlcSeries(pair pairs.AssetPair, interval int) Series {
+	return Series{Kind: KindOhlc, Pair: pair, Interval: interval}
+}
+
+// SpreadSeries returns the series of spread data of an asset pair.
+func SpreadSeries(pair pairs.AssetPair) Series {
+	return Series{Kind: KindSpread, Pair: pair}
+}
+
+// String returns the name of the series, e.g. XXBTZUSD/ohlc-60.
+func (s Series) String() string {
+	if s.Kind == KindOhlc {
+		return fmt.Sprintf("%s/%s-%d", s.Pair, s.Kind, s.Interval)
+	}
+
+	return fmt.Sprintf("%s/%s", s.Pair, s.Kind)
+}
+
+// Store persists series of market data. Each series keeps the cursor of the
+// last response appended to it, to be passed as the Since of the next request.
+//
+// Records are appended oldest first. The last OHLC data of a response is for
+// the interval in progress, so OHLC data appended again for the same time
+// replaces the data stored before.
+type Store interface {
+	// Cursor returns the cursor stored with a series, or zero if nothing has
+	// been appended to it.
+	Cursor(series Series) (int64, error)
+
+	// AppendTrades appends trades to a trades series and stores its cursor.
+	AppendTrades(series Series, trades []gokraken.Trade, last int64) error
+
+	// AppendOhlc appends OHLC data to an OHLC series and stores its cursor.
+	AppendOhlc(series Series, data []gokraken.OhlcData, last int64) error
+
+	// AppendSpread appends spread data to a spread series and stores its
+	// cursor.
+	AppendSpread(series Series, data []gokraken.SpreadData, last int64) error
+
+	// Trades returns the trades of a series from the given time up to, but
+	// not including, the given end. A zero time leaves that side open.
+	Trades(series Series, from, to time.Time) ([]gokraken.Trade, error)
+
+	// Ohlc returns the OHLC data of a series from the given time up to, but
+	// not including, the given end. A zero time leaves that side open.
+	Ohlc(series Series, from, to time.Time) ([]gokraken.OhlcData, error)
+
+	// Spread returns the spread data of a series from the given time up to,
+	// but not including, the given end. A zero time leaves that side open.
+	Spread(series Series, from, to time.Time) ([]gokraken.SpreadData, error)
+}
+
+// checkKind returns an error if a series is not of the given kind.
+func checkKind(series Series, kind Kind) error {
+	if series.Kind != kind {
+		return fmt.Errorf("series %s is not a %s series", series, kind)
+	}
+
+	return nil
+}
@@ -1,5 +1,12 @@
 package gokraken
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // TimeResource is the API resource for the Kraken API server time.
 const TimeResource = "Time"
 
@@ -9,3 +16,40 @@ type TimeResponse struct {
 	UnixTime int64  `json:"unixtime"`
 	Rfc1123  string `json:"rfc1123"`
 }
+
+// ParseUnixTime parses a Unix timestamp in seconds with an optional fractional
+// part, such as 1616667796.8802, as used throughout the Kraken APIs. Unlike
+// parsing it as a float, no precision is lost. Digits beyond nanoseconds are
+// truncated.
+func ParseUnixTime(s string) (time.Time, error) {
+	secStr, fracStr := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		secStr, fracStr = s[:i], s[i+1:]
+	}
+
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil || strings.Trim(fracStr, "0123456789") != "" {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+
+	var nsec int64
+	if fracStr != "" {
+		if nsec, err = strconv.ParseInt((fracStr + "000000000")[:9], 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", s)
+		}
+	}
+
+	return time.Unix(sec, nsec), nil
+}
+
+// FormatUnixTime formats a time as Unix seconds, with as many decimal places
+// as it needs. It is the inverse of ParseUnixTime.
+func FormatUnixTime(t time.Time) string {
+	ns := t.UnixNano()
+	s := strconv.FormatInt(ns/1e9, 10)
+	if frac := ns % 1e9; frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%09d", frac), "0")
+	}
+
+	return s
+}
@@ -0,0 +1,54 @@
+package gokraken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUnixTime(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected time.Time
+	}{
+		{input: "1534614057.321597", expected: time.Unix(1534614057, 321597000)},
+		{input: "1534614057", expected: time.Unix(1534614057, 0)},
+		{input: "1534614057.1234567891", expected: time.Unix(1534614057, 123456789)},
+		{input: "0", expected: time.Unix(0, 0)},
+	}
+	for _, c := range cases {
+		actual, err := ParseUnixTime(c.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(c.expected, actual, t)
+	}
+
+	for _, input := range []string{"", "abc", "1534614057.-5", "1534614057.5e3"} {
+		if _, err := ParseUnixTime(input); err == nil {
+			t.Fatalf("%s: expected error for %q", t.Name(), input)
+		}
+	}
+}
+
+func TestFormatUnixTime(t *testing.T) {
+	cases := []struct {
+		input    time.Time
+		expected string
+	}{
+		{input: time.Unix(1534614057, 321597000), expected: "1534614057.321597"},
+		{input: time.Unix(1534614057, 0), expected: "1534614057"},
+		{input: time.Unix(1534614057, 1), expected: "1534614057.000000001"},
+	}
+	for _, c := range cases {
+		s := FormatUnixTime(c.input)
+		assert(c.expected, s, t)
+
+		parsed, err := ParseUnixTime(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(c.input, parsed, t)
+	}
+}
@@ -1,6 +1,8 @@
 package gokraken
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/danmrichards/gokraken/pairs"
@@ -61,8 +63,8 @@ type TradesResponse struct {
 
 // Trade is a trade of asset.
 type Trade struct {
-	Price         float64
-	Volume        float64
+	Price         Decimal
+	Volume        Decimal
 	Timestamp     time.Time
 	BuySell       TradeBuySell
 	MarketLimit   TradeMarketLimit
@@ -102,15 +104,53 @@ type QueryTradesResponse map[string]UserTrade
 type UserTrade struct {
 	OrderTxid string       `json:"ordertxid"`
 	Pair      string       `json:"pair"`
-	Time      int64        `json:"time"`
+	Time      time.Time    `json:"time"`
 	Type      TradeBuySell `json:"type"`
 	OrderType OrderType    `json:"ordertype"`
-	Price     float64      `json:"price"`
-	Cost      float64      `json:"cost"`
-	Fee       float64      `json:"fee"`
-	Vol       float64      `json:"vol"`
-	Margin    float64      `json:"margin"`
-	Misc      string       `json:"misc"`
+	Price     Decimal      `json:"price"`
+	Cost      Decimal      `json:"cost"`
+	Fee       Decimal      `json:"fee"`
+	Vol       Decimal      `json:"vol"`
+	Margin    Decimal      `json:"margin"`
+	Misc      []string     `json:"misc"`
+}
+
+// UnmarshalJSON parses the JSON-encoded data and stores the result
+// in the value pointed to by u, converting its Unix timestamp and comma
+// delimited list of miscellaneous info.
+func (u *UserTrade) UnmarshalJSON(data []byte) (err error) {
+	type userTrade UserTrade
+	aux := struct {
+		*userTrade
+		Time json.Number `json:"time"`
+		Misc string      `json:"misc"`
+	}{
+		userTrade: (*userTrade)(u),
+	}
+
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	u.Misc = SplitList(aux.Misc)
+	u.Time, err = parseUnixTime(aux.Time)
+
+	return
+}
+
+// MarshalJSON encodes the trade in the format used by Kraken, so that it
+// round trips through UnmarshalJSON.
+func (u UserTrade) MarshalJSON() ([]byte, error) {
+	type userTrade UserTrade
+	return json.Marshal(struct {
+		userTrade
+		Time json.Number `json:"time"`
+		Misc string      `json:"misc"`
+	}{
+		userTrade: userTrade(u),
+		Time:      unixNumber(u.Time),
+		Misc:      strings.Join(u.Misc, ","),
+	})
 }
 
 // TradeVolumeResponse represents the response from the TradeVolume endpoint
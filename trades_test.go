@@ -0,0 +1,55 @@
+package gokraken
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestUserTrade_UnmarshalJSON(t *testing.T) {
+	// Recorded from the TradesHistory endpoint.
+	payload := []byte(`{"ordertxid":"OQCLML-BW3P3-BUCMWZ","postxid":"TKH2SE-M7IF5-CFI7LT","pair":"XXBTZUSD","time":1688667796.8802,"type":"buy","ordertype":"limit","price":"30010.00000","cost":"600.20000","fee":"0.00000","vol":"0.02000000","margin":"0.00000","misc":"closing","trade_id":40274859,"maker":true}`)
+
+	var trade UserTrade
+	if err := json.Unmarshal(payload, &trade); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(UserTrade{
+		OrderTxid: "OQCLML-BW3P3-BUCMWZ",
+		Pair:      "XXBTZUSD",
+		Time:      time.Unix(1688667796, 880200000),
+		Type:      TradeBuy,
+		OrderType: OrderTypeLimit,
+		Price:     dec("30010"),
+		Cost:      dec("600.2"),
+		Fee:       dec("0"),
+		Vol:       dec("0.02"),
+		Margin:    dec("0"),
+		Misc:      []string{"closing"},
+	}, trade, t)
+}
+
+func TestUserTrade_MarshalJSON(t *testing.T) {
+	trade := UserTrade{
+		OrderTxid: "OQCLML-BW3P3-BUCMWZ",
+		Pair:      "XXBTZUSD",
+		Time:      time.Unix(1688667796, 880200000),
+		Type:      TradeBuy,
+		OrderType: OrderTypeLimit,
+		Price:     dec("30010"),
+		Misc:      []string{"closing", "initiated"},
+	}
+
+	b, err := json.Marshal(trade)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded UserTrade
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(trade, decoded, t)
+}
@@ -2,10 +2,15 @@ package gokraken
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/danmrichards/gokraken/pairs"
 )
 
 // Trading is responsible for communicating with all the private user trading
@@ -15,61 +20,223 @@ type Trading struct {
 }
 
 // AddOrder adds a standard order via the Kraken API.
+//
+// If the client has a retry policy and the order has a UserRef, an attempt
+// which fails without it being known whether Kraken placed the order is only
+// retried after confirming that no order with the UserRef exists. The UserRef
+// must therefore be unique to the order for retries to be safe.
+//
+// If the client has a validator, the order is validated first and a
+// ValidationError is returned without calling Kraken if it is invalid.
 // https://www.kraken.com/en-gb/help/api#add-standard-order
 func (t *Trading) AddOrder(ctx context.Context, order UserOrder) (res *AddOrderResponse, err error) {
-	body := url.Values{
-		"pair":      {order.Pair.String()},
-		"type":      {string(order.Type)},
-		"ordertype": {string(order.OrderType)},
-		"volume":    {strconv.FormatFloat(order.Volume, 'f', 4, 64)},
+	if t.Client.Validator != nil {
+		if order, err = t.Client.Validator.Validate(ctx, order); err != nil {
+			return
+		}
+	}
+
+	body := order.Values()
+	placed := time.Now()
+
+	res, err = t.addOrder(ctx, body)
+
+	retry := t.Client.Retry
+	if retry == nil || order.UserRef == 0 {
+		return
+	}
+
+	for attempt := 1; attempt < retry.MaxAttempts && ambiguous(err) && ctx.Err() == nil; attempt++ {
+		txids, lookupErr := t.ordersByUserRef(ctx, int64(order.UserRef), placed)
+		if lookupErr != nil {
+			return
+		}
+
+		// The failed attempt was placed, so report it rather than retrying.
+		if len(txids) > 0 {
+			res, err = &AddOrderResponse{TxIDs: txids}, nil
+			return
+		}
+
+		if !retry.wait(ctx, attempt) {
+			return
+		}
+
+		t.Client.logf("retrying %s after attempt %d failed: %s", AddOrderResource, attempt, err)
+		res, err = t.addOrder(ctx, body)
+	}
+
+	return
+}
+
+// addOrder makes a single AddOrder call to the Kraken API.
+func (t *Trading) addOrder(ctx context.Context, body url.Values) (res *AddOrderResponse, err error) {
+	req, err := t.Client.DialWithAuth(ctx, http.MethodPost, AddOrderResource, body)
+	if err != nil {
+		return
+	}
+
+	krakenResp, err := t.Client.Call(req)
+	if err != nil {
+		return
+	}
+
+	err = krakenResp.ExtractResult(&res)
+	return
+}
+
+// ordersByUserRef returns the txids of open orders and orders closed since
+// the given time which have the given user reference id.
+func (t *Trading) ordersByUserRef(ctx context.Context, userRef int64, since time.Time) (txids []string, err error) {
+	open, err := t.Client.UserData.OpenOrders(ctx, false, userRef)
+	if err != nil {
+		return
+	}
+
+	for txid := range open.Open {
+		txids = append(txids, txid)
 	}
 
-	if order.Price != 0 {
-		body.Add("price", strconv.FormatFloat(order.Price, 'f', 4, 64))
+	// Allow for clock skew between this host and Kraken.
+	start := since.Add(-time.Minute)
+
+	closed, err := t.Client.UserData.ClosedOrders(ctx, ClosedOrdersRequest{
+		UserRef: userRef,
+		Start:   &start,
+	})
+	if err != nil {
+		return
 	}
 
-	if order.Price2 != 0 {
-		body.Add("price2", strconv.FormatFloat(order.Price2, 'f', 4, 64))
+	for txid := range closed.Closed {
+		txids = append(txids, txid)
 	}
 
-	if order.Leverage != "" {
-		body.Add("leverage", order.Leverage)
+	return
+}
+
+// AddOrderBatch adds between 2 and 15 orders for the same asset pair with a
+// single call to the Kraken API. Kraken places or rejects each order on its
+// own, so the results must be checked for orders which failed. If any order
+// has Validate set, the batch is only validated.
+//
+// The orders are checked to be for the given pair and, if the client has a
+// validator, validated first. A ValidationError naming the invalid orders is
+// returned without calling Kraken if any are invalid.
+// https://docs.kraken.com/rest/#operation/addOrderBatch
+func (t *Trading) AddOrderBatch(ctx context.Context, pair pairs.AssetPair, orders []UserOrder) (res *AddOrderBatchResponse, err error) {
+	if orders, err = t.validateBatch(ctx, pair, orders); err != nil {
+		return
 	}
 
-	if len(order.OFlags) > 0 {
-		flagStrings := make([]string, len(order.OFlags))
-		for index := range order.OFlags {
-			flagStrings[index] = string(order.OFlags[index])
+	body := url.Values{
+		"pair": {pair.String()},
+	}
+
+	for i, order := range orders {
+		if order.Validate {
+			body.Set("validate", "true")
 		}
 
-		body.Add("oflags", strings.Join(flagStrings, ","))
+		for key, values := range order.Values() {
+			if key == "pair" || key == "validate" {
+				continue
+			}
+
+			// Nested keys such as close[ordertype] become orders[i][close][ordertype].
+			if j := strings.IndexByte(key, '['); j >= 0 {
+				key = key[:j] + "]" + key[j:]
+			} else {
+				key += "]"
+			}
+
+			body[fmt.Sprintf("orders[%d][%s", i, key)] = values
+		}
 	}
 
-	if order.StartTm != "" {
-		body.Add("starttm", order.StartTm)
+	// The batch costs one per order against the matching engine rate limit.
+	req, err := t.Client.DialWithAuth(withCost(ctx, float64(len(orders))), http.MethodPost, AddOrderBatchResource, body)
+	if err != nil {
+		return
 	}
 
-	if order.ExpireTm != "" {
-		body.Add("expiretm", order.ExpireTm)
+	krakenResp, err := t.Client.Call(req)
+	if err != nil {
+		return
 	}
 
-	if order.Validate {
-		body.Add("validate", "true")
+	err = krakenResp.ExtractResult(&res)
+	return
+}
+
+// validateBatch checks the size of a batch and that each order is for the
+// pair of the batch, validating the orders if the client has a validator.
+func (t *Trading) validateBatch(ctx context.Context, pair pairs.AssetPair, orders []UserOrder) ([]UserOrder, error) {
+	if len(orders) < 2 || len(orders) > 15 {
+		return orders, ValidationError{{Field: "Orders", Message: fmt.Sprintf("must have between 2 and 15 orders, got %d", len(orders))}}
 	}
 
-	if string(order.CloseOrderType) != "" {
-		body.Add("close[ordertype]", string(order.CloseOrderType))
+	var errs ValidationError
+	validated := make([]UserOrder, len(orders))
+	for i, order := range orders {
+		validated[i] = order
+		prefix := fmt.Sprintf("Orders[%d].", i)
+
+		if order.Pair != pair {
+			errs = append(errs, FieldError{
+				Field:   prefix + "Pair",
+				Message: fmt.Sprintf("%s does not match the batch pair %s", order.Pair, pair),
+			})
+			continue
+		}
+
+		if t.Client.Validator == nil {
+			continue
+		}
+
+		var err error
+		validated[i], err = t.Client.Validator.Validate(ctx, order)
+
+		var orderErrs ValidationError
+		if !errors.As(err, &orderErrs) {
+			if err != nil {
+				return orders, err
+			}
+			continue
+		}
+
+		for _, fieldErr := range orderErrs {
+			fieldErr.Field = prefix + fieldErr.Field
+			errs = append(errs, fieldErr)
+		}
 	}
 
-	if order.ClosedOrderPrice != 0 {
-		body.Add("close[price]", strconv.FormatFloat(order.ClosedOrderPrice, 'f', 4, 64))
+	if len(errs) > 0 {
+		return orders, errs
 	}
 
-	if order.ClosedOrderPrice2 != 0 {
-		body.Add("close[price2]", strconv.FormatFloat(order.ClosedOrderPrice2, 'f', 4, 64))
+	return validated, nil
+}
+
+// EditOrder edits an open order via the Kraken API. Kraken cancels the order
+// and replaces it with a new order with a new transaction id.
+//
+// A ValidationError is returned without calling Kraken if the edit does not
+// identify an order. If the client has a validator, the changes are validated
+// first too.
+// https://docs.kraken.com/rest/#operation/editOrder
+func (t *Trading) EditOrder(ctx context.Context, edit EditOrderRequest) (res *EditOrderResponse, err error) {
+	if _, err = edit.OrderID(); err != nil {
+		return
 	}
 
-	req, err := t.Client.DialWithAuth(ctx, http.MethodPost, AddOrderResource, body)
+	if t.Client.Validator != nil {
+		if edit, err = t.Client.Validator.ValidateEdit(ctx, edit); err != nil {
+			return
+		}
+	}
+
+	req, err := t.Client.DialWithAuth(ctx, http.MethodPost, EditOrderResource, edit.Values())
 	if err != nil {
 		return
 	}
@@ -85,9 +252,37 @@ func (t *Trading) AddOrder(ctx context.Context, order UserOrder) (res *AddOrderR
 
 // CancelOrder cancels an open order via the Kraken API.
 // https://www.kraken.com/en-gb/help/api#cancel-open-order
+//
+// Deprecated: Kraken transaction ids are not integers; use CancelOrderByTxID
+// or CancelOrderByUserRef.
 func (t *Trading) CancelOrder(ctx context.Context, txid int64) (res *CancelOrderResponse, err error) {
+	return t.cancelOrder(ctx, strconv.FormatInt(txid, 10))
+}
+
+// CancelOrderByTxID cancels the open order with the given transaction id via
+// the Kraken API.
+// https://docs.kraken.com/rest/#operation/cancelOrder
+func (t *Trading) CancelOrderByTxID(ctx context.Context, txid TxID) (res *CancelOrderResponse, err error) {
+	if !txid.Valid() {
+		err = fmt.Errorf("invalid txid %q", string(txid))
+		return
+	}
+
+	return t.cancelOrder(ctx, string(txid))
+}
+
+// CancelOrderByUserRef cancels all open orders with the given user reference
+// id via the Kraken API.
+// https://docs.kraken.com/rest/#operation/cancelOrder
+func (t *Trading) CancelOrderByUserRef(ctx context.Context, userRef int64) (res *CancelOrderResponse, err error) {
+	return t.cancelOrder(ctx, strconv.FormatInt(userRef, 10))
+}
+
+// cancelOrder cancels the open order with the given transaction id, or the
+// open orders with the given user reference id.
+func (t *Trading) cancelOrder(ctx context.Context, txid string) (res *CancelOrderResponse, err error) {
 	body := url.Values{
-		"txid": {strconv.FormatInt(txid, 10)},
+		"txid": {txid},
 	}
 
 	req, err := t.Client.DialWithAuth(ctx, http.MethodPost, CancelOrderResource, body)
@@ -103,3 +298,62 @@ func (t *Trading) CancelOrder(ctx context.Context, txid int64) (res *CancelOrder
 	err = krakenResp.ExtractResult(&res)
 	return
 }
+
+// CancelAll cancels all open orders via the Kraken API.
+// https://docs.kraken.com/rest/#operation/cancelAllOrders
+func (t *Trading) CancelAll(ctx context.Context) (res *CancelOrderResponse, err error) {
+	req, err := t.Client.DialWithAuth(ctx, http.MethodPost, CancelAllResource, nil)
+	if err != nil {
+		return
+	}
+
+	krakenResp, err := t.Client.Call(req)
+	if err != nil {
+		return
+	}
+
+	err = krakenResp.ExtractResult(&res)
+	return
+}
+
+// CancelAllOrdersAfter cancels all open orders once the timeout elapses,
+// unless it is called again to extend the timeout. A zero timeout disables
+// the timer. The timeout is rounded down to whole seconds.
+// https://docs.kraken.com/rest/#operation/cancelAllOrdersAfter
+func (t *Trading) CancelAllOrdersAfter(ctx context.Context, timeout time.Duration) (res *CancelAllOrdersAfterResponse, err error) {
+	body := url.Values{
+		"timeout": {strconv.Itoa(int(timeout / time.Second))},
+	}
+
+	req, err := t.Client.DialWithAuth(ctx, http.MethodPost, CancelAllOrdersAfterResource, body)
+	if err != nil {
+		return
+	}
+
+	krakenResp, err := t.Client.Call(req)
+	if err != nil {
+		return
+	}
+
+	var tmp struct {
+		CurrentTime string `json:"currentTime"`
+		TriggerTime string `json:"triggerTime"`
+	}
+	if err = krakenResp.ExtractResult(&tmp); err != nil {
+		return
+	}
+
+	res = &CancelAllOrdersAfterResponse{}
+	if res.CurrentTime, err = time.Parse(time.RFC3339, tmp.CurrentTime); err != nil {
+		return nil, err
+	}
+
+	// Kraken sends a trigger time of 0 when the timer is disabled.
+	if tmp.TriggerTime != "0" {
+		if res.TriggerTime, err = time.Parse(time.RFC3339, tmp.TriggerTime); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
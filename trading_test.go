@@ -2,20 +2,26 @@ package gokraken
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/danmrichards/gokraken/pairs"
 )
 
 func TestTrading_AddOrder(t *testing.T) {
-	mockResponse := []byte(`{"error":[],"result":{"descr":{"pair":0,"close":"4321","leverage":"","order":"1234","ordertype":"","price":"","price2":"","type":""},"txid":["2345","3456"]}}`)
+	mockResponse := []byte(`{"error":[],"result":{"descr":{"pair":"ETHEUR","close":"4321","leverage":"","order":"1234","ordertype":"","price":"","price2":"","type":""},"txid":["2345","3456"]}}`)
 
 	expectedResult := &AddOrderResponse{
 		Description: OrderDescription{
-			Order: "1234",
-			Close: "4321",
+			AssetPair: pairs.XETHZEUR,
+			Order:     "1234",
+			Close:     "4321",
 		},
 		TxIDs: []string{"2345", "3456"},
 	}
@@ -36,7 +42,7 @@ func TestTrading_AddOrder(t *testing.T) {
 		Pair:      pairs.BCHEUR,
 		Type:      TradeSell,
 		OrderType: OrderTypeMarket,
-		Volume:    1.23,
+		Volume:    dec("1.23"),
 	}
 
 	res, err := k.Trading.AddOrder(context.Background(), order)
@@ -74,3 +80,294 @@ func TestTrading_CancelOrder(t *testing.T) {
 
 	assert(expectedResult, res, t)
 }
+
+func TestTrading_CancelOrderByTxID(t *testing.T) {
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"count":1}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.Trading.CancelOrderByTxID(context.Background(), "OQCLML-BW3P3-BUCMWZ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(&CancelOrderResponse{Count: 1}, res, t)
+	assert("OQCLML-BW3P3-BUCMWZ", body.Get("txid"), t)
+
+	body = nil
+	if _, err = k.Trading.CancelOrderByTxID(context.Background(), "1234"); err == nil {
+		t.Fatal("expected an invalid txid error")
+	}
+	if body != nil {
+		t.Fatal("expected no request for an invalid txid")
+	}
+}
+
+func TestTrading_CancelOrderByUserRef(t *testing.T) {
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"count":3}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.Trading.CancelOrderByUserRef(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(&CancelOrderResponse{Count: 3}, res, t)
+	assert("42", body.Get("txid"), t)
+}
+
+func TestTrading_CancelAll(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"count":4}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.Trading.CancelAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(&CancelOrderResponse{Count: 4}, res, t)
+}
+
+func TestTrading_CancelAllOrdersAfter(t *testing.T) {
+	cases := []struct {
+		name             string
+		timeout          time.Duration
+		mockResponse     []byte
+		expectedResponse *CancelAllOrdersAfterResponse
+	}{
+		{
+			name:         "armed",
+			timeout:      time.Minute,
+			mockResponse: []byte(`{"error":[],"result":{"currentTime":"2021-03-24T17:41:56Z","triggerTime":"2021-03-24T17:42:56Z"}}`),
+			expectedResponse: &CancelAllOrdersAfterResponse{
+				CurrentTime: time.Date(2021, 3, 24, 17, 41, 56, 0, time.UTC),
+				TriggerTime: time.Date(2021, 3, 24, 17, 42, 56, 0, time.UTC),
+			},
+		},
+		{
+			name:         "disabled",
+			mockResponse: []byte(`{"error":[],"result":{"currentTime":"2021-03-24T17:41:56Z","triggerTime":"0"}}`),
+			expectedResponse: &CancelAllOrdersAfterResponse{
+				CurrentTime: time.Date(2021, 3, 24, 17, 41, 56, 0, time.UTC),
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var timeout string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				body, _ := url.ParseQuery(string(b))
+				timeout = body.Get("timeout")
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+
+				w.Write(c.mockResponse)
+			}))
+
+			defer ts.Close()
+
+			k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+			k.BaseURL = ts.URL
+
+			res, err := k.Trading.CancelAllOrdersAfter(context.Background(), c.timeout)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.expectedResponse, res, t)
+			assert(strconv.Itoa(int(c.timeout.Seconds())), timeout, t)
+		})
+	}
+}
+
+func TestTrading_EditOrder(t *testing.T) {
+	mockResponse := []byte(`{"error":[],"result":{"status":"ok","txid":"OFVXHJ-KPQ3B-VS7ELA","originaltxid":"OHYO67-6LP66-HMQ437","volume":"0.00030000","price":"19500.0","price2":"32500.0","orders_cancelled":1,"descr":{"order":"buy 0.00030000 XXBTZGBP @ limit 19500.0"}}}`)
+
+	expectedResult := &EditOrderResponse{
+		Description: OrderDescription{
+			Order: "buy 0.00030000 XXBTZGBP @ limit 19500.0",
+		},
+		TxID:            "OFVXHJ-KPQ3B-VS7ELA",
+		OriginalTxID:    "OHYO67-6LP66-HMQ437",
+		Status:          "ok",
+		OrdersCancelled: 1,
+		Volume:          dec("0.0003"),
+		Price:           dec("19500"),
+		Price2:          dec("32500"),
+	}
+
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write(mockResponse)
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.Trading.EditOrder(context.Background(), EditOrderRequest{
+		UserRef:  123,
+		Pair:     pairs.XXBTZGBP,
+		Price:    PriceAt(dec("19500")),
+		Price2:   PricePlus(dec("2")).Percent(),
+		Volume:   dec("0.0003"),
+		OFlags:   []OrderFlag{OrderFlagPost},
+		Deadline: time.Date(2021, 4, 1, 0, 18, 45, 500000000, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(expectedResult, res, t)
+	assert("123", body.Get("txid"), t)
+	assert("XXBTZGBP", body.Get("pair"), t)
+	assert("19500", body.Get("price"), t)
+	assert("0.0003", body.Get("volume"), t)
+	assert("post", body.Get("oflags"), t)
+	assert("2021-04-01T00:18:45.5Z", body.Get("deadline"), t)
+	assert("+2%", body.Get("price2"), t)
+}
+
+func TestTrading_EditOrderNoOrder(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	_, err := k.Trading.EditOrder(context.Background(), EditOrderRequest{Pair: pairs.XXBTZGBP, Price: PriceAt(dec("19500"))})
+	assert(ValidationError{{Field: "TxID", Message: "no order to edit, set TxID or UserRef"}}, err, t)
+
+	_, err = k.Trading.EditOrder(context.Background(), EditOrderRequest{TxID: "123", Pair: pairs.XXBTZGBP})
+	assert(ValidationError{{Field: "TxID", Message: `invalid txid "123"`}}, err, t)
+	assert(0, calls, t)
+
+	_, ok := EditOrderRequest{Pair: pairs.XXBTZGBP}.Values()["txid"]
+	assert(false, ok, t)
+}
+
+func TestTrading_AddOrderBatch(t *testing.T) {
+	mockResponse := []byte(`{"error":[],"result":{"orders":[{"descr":{"order":"buy 1.20000000 XBTUSD @ limit 30000.0"},"txid":"OUF4EM-FRGI2-MQMWZD"},{"error":"EOrder:Insufficient funds"}]}}`)
+
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write(mockResponse)
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+	k.Limiter = NewRateLimiter(TierStarter)
+
+	orders := []UserOrder{
+		{
+			Pair:      pairs.XXBTZUSD,
+			Type:      TradeBuy,
+			OrderType: OrderTypeLimit,
+			Price:     PriceAt(dec("30000")),
+			Volume:    dec("1.2"),
+			UserRef:   7,
+		},
+		{
+			Pair:             pairs.XXBTZUSD,
+			Type:             TradeSell,
+			OrderType:        OrderTypeLimit,
+			Price:            PriceAt(dec("31000")),
+			Volume:           dec("900"),
+			CloseOrderType:   OrderTypeStopLoss,
+			ClosedOrderPrice: PriceAt(dec("32000")),
+		},
+	}
+
+	res, err := k.Trading.AddOrderBatch(context.Background(), pairs.XXBTZUSD, orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert("XXBTZUSD", body.Get("pair"), t)
+	assert("limit", body.Get("orders[0][ordertype]"), t)
+	assert("30000", body.Get("orders[0][price]"), t)
+	assert("1.2", body.Get("orders[0][volume]"), t)
+	assert("7", body.Get("orders[0][userref]"), t)
+	assert("sell", body.Get("orders[1][type]"), t)
+	assert("stop-loss", body.Get("orders[1][close][ordertype]"), t)
+	assert("32000", body.Get("orders[1][close][price]"), t)
+	assert("", body.Get("orders[0][pair]"), t)
+
+	// The batch costs one per order against the matching engine counter.
+	assert(float64(2), k.Limiter.order.value, t)
+
+	assert(2, len(res.Orders), t)
+	assert("OUF4EM-FRGI2-MQMWZD", res.Orders[0].TxID, t)
+	assert(nil, res.Orders[0].Err(), t)
+	assert(true, errors.Is(res.Orders[1].Err(), ErrInsufficientFunds), t)
+}
+
+func TestTrading_AddOrderBatchValidation(t *testing.T) {
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+
+	order := UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeMarket, Volume: dec("1")}
+
+	_, err := k.Trading.AddOrderBatch(context.Background(), pairs.XXBTZUSD, []UserOrder{order})
+	assert(ValidationError{{Field: "Orders", Message: "must have between 2 and 15 orders, got 1"}}, err, t)
+
+	other := order
+	other.Pair = pairs.XETHZUSD
+
+	_, err = k.Trading.AddOrderBatch(context.Background(), pairs.XXBTZUSD, []UserOrder{order, other})
+	assert(ValidationError{{Field: "Orders[1].Pair", Message: "XETHZUSD does not match the batch pair XXBTZUSD"}}, err, t)
+}
@@ -0,0 +1,60 @@
+package gokraken
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// txidPattern matches the format of Kraken order, trade and position ids,
+// such as OQCLML-BW3P3-BUCMWZ.
+var txidPattern = regexp.MustCompile(`^[A-Z0-9]{6}-[A-Z0-9]{5}-[A-Z0-9]{6}$`)
+
+// TxID is a Kraken transaction id, identifying an order, trade or position.
+type TxID string
+
+// ParseTxID parses a transaction id, returning an error if it is not in the
+// format used by Kraken.
+func ParseTxID(s string) (TxID, error) {
+	txid := TxID(s)
+	if !txid.Valid() {
+		return "", fmt.Errorf("invalid txid %q", s)
+	}
+
+	return txid, nil
+}
+
+// Valid returns whether the transaction id is in the format used by Kraken.
+func (t TxID) Valid() bool {
+	return txidPattern.MatchString(string(t))
+}
+
+// String returns the transaction id as a string.
+func (t TxID) String() string {
+	return string(t)
+}
+
+// joinTxIDs validates the transaction ids and joins them as a comma separated
+// list.
+func joinTxIDs(txids []TxID) (string, error) {
+	txidStrings := make([]string, len(txids))
+	for i, txid := range txids {
+		if !txid.Valid() {
+			return "", fmt.Errorf("invalid txid %q", string(txid))
+		}
+		txidStrings[i] = string(txid)
+	}
+
+	return strings.Join(txidStrings, ","), nil
+}
+
+// formatInts joins integer transaction ids as a comma separated list.
+func formatInts(txids []int64) string {
+	txidStrings := make([]string, len(txids))
+	for i := range txids {
+		txidStrings[i] = strconv.FormatInt(txids[i], 10)
+	}
+
+	return strings.Join(txidStrings, ",")
+}
@@ -0,0 +1,37 @@
+package gokraken
+
+import "testing"
+
+func TestParseTxID(t *testing.T) {
+	cases := []struct {
+		name  string
+		txid  string
+		valid bool
+	}{
+		{name: "order", txid: "OQCLML-BW3P3-BUCMWZ", valid: true},
+		{name: "trade", txid: "TCWJEG-FL4SZ-3FKGH6", valid: true},
+		{name: "empty", txid: ""},
+		{name: "integer", txid: "1234"},
+		{name: "lower case", txid: "oqclml-bw3p3-bucmwz"},
+		{name: "short group", txid: "OQCLML-BW3P-BUCMWZ"},
+		{name: "no separators", txid: "OQCLMLBW3P3BUCMWZ"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			txid, err := ParseTxID(c.txid)
+			if c.valid {
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert(TxID(c.txid), txid, t)
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected %q to be invalid", c.txid)
+			}
+			assert(false, TxID(c.txid).Valid(), t)
+		})
+	}
+}
@@ -133,7 +133,28 @@ func (u *UserData) ClosedOrders(ctx context.Context, closedReq ClosedOrdersReque
 
 // QueryOrders returns an associative array of order info.
 // https://www.kraken.com/en-gb/help/api#query-orders-info
+//
+// Deprecated: Kraken transaction ids are not integers; use QueryOrdersByTxID.
 func (u *UserData) QueryOrders(ctx context.Context, trades bool, userRef int64, txids ...int64) (res *QueryOrdersResponse, err error) {
+	return u.queryOrders(ctx, trades, userRef, formatInts(txids))
+}
+
+// QueryOrdersByTxID returns an associative array of info on the orders with
+// the given transaction ids, restricted to the given user reference id if it
+// is not zero.
+// https://docs.kraken.com/rest/#operation/getOrdersInfo
+func (u *UserData) QueryOrdersByTxID(ctx context.Context, trades bool, userRef int64, txids ...TxID) (res *QueryOrdersResponse, err error) {
+	txidList, err := joinTxIDs(txids)
+	if err != nil {
+		return
+	}
+
+	return u.queryOrders(ctx, trades, userRef, txidList)
+}
+
+// queryOrders returns an associative array of info on the orders in the comma
+// separated list of transaction ids.
+func (u *UserData) queryOrders(ctx context.Context, trades bool, userRef int64, txids string) (res *QueryOrdersResponse, err error) {
 	body := url.Values{
 		"trades": []string{"false"},
 	}
@@ -142,13 +163,12 @@ func (u *UserData) QueryOrders(ctx context.Context, trades bool, userRef int64,
 		body.Set("trades", "true")
 	}
 
-	if len(txids) > 0 {
-		txidStrings := make([]string, len(txids))
-		for i := range txids {
-			txidStrings[i] = strconv.FormatInt(txids[i], 10)
-		}
+	if userRef != 0 {
+		body.Add("userref", strconv.FormatInt(userRef, 10))
+	}
 
-		body.Add("txid", strings.Join(txidStrings, ","))
+	if txids != "" {
+		body.Add("txid", txids)
 	}
 
 	req, err := u.Client.DialWithAuth(ctx, http.MethodPost, QueryOrdersResource, body)
@@ -209,7 +229,27 @@ func (u *UserData) TradesHistory(ctx context.Context, tradesReq TradesHistoryReq
 
 // QueryTrades returns an associative array of trade info.
 // https://www.kraken.com/en-gb/help/api#query-trades-info
+//
+// Deprecated: Kraken transaction ids are not integers; use QueryTradesByTxID.
 func (u *UserData) QueryTrades(ctx context.Context, trades bool, txids ...int64) (res *QueryTradesResponse, err error) {
+	return u.queryTrades(ctx, trades, formatInts(txids))
+}
+
+// QueryTradesByTxID returns an associative array of info on the trades with
+// the given transaction ids.
+// https://docs.kraken.com/rest/#operation/getTradesInfo
+func (u *UserData) QueryTradesByTxID(ctx context.Context, trades bool, txids ...TxID) (res *QueryTradesResponse, err error) {
+	txidList, err := joinTxIDs(txids)
+	if err != nil {
+		return
+	}
+
+	return u.queryTrades(ctx, trades, txidList)
+}
+
+// queryTrades returns an associative array of info on the trades in the comma
+// separated list of transaction ids.
+func (u *UserData) queryTrades(ctx context.Context, trades bool, txids string) (res *QueryTradesResponse, err error) {
 	body := url.Values{
 		"trades": []string{"false"},
 	}
@@ -218,13 +258,8 @@ func (u *UserData) QueryTrades(ctx context.Context, trades bool, txids ...int64)
 		body.Set("trades", "true")
 	}
 
-	if len(txids) > 0 {
-		txidStrings := make([]string, len(txids))
-		for i := range txids {
-			txidStrings[i] = strconv.FormatInt(txids[i], 10)
-		}
-
-		body.Add("txid", strings.Join(txidStrings, ","))
+	if txids != "" {
+		body.Add("txid", txids)
 	}
 
 	req, err := u.Client.DialWithAuth(ctx, http.MethodPost, TradesInfoResource, body)
@@ -243,7 +278,28 @@ func (u *UserData) QueryTrades(ctx context.Context, trades bool, txids ...int64)
 
 // OpenPositions returns an associative array of open positions info.
 // https://www.kraken.com/en-gb/help/api#get-open-positions
+//
+// Deprecated: Kraken transaction ids are not integers; use OpenPositionsByTxID.
 func (u *UserData) OpenPositions(ctx context.Context, doCalcs bool, txids ...int64) (res OpenPositionsResponse, err error) {
+	return u.openPositions(ctx, doCalcs, formatInts(txids))
+}
+
+// OpenPositionsByTxID returns an associative array of info on the open
+// positions with the given transaction ids, or all open positions if none are
+// given.
+// https://docs.kraken.com/rest/#operation/getOpenPositions
+func (u *UserData) OpenPositionsByTxID(ctx context.Context, doCalcs bool, txids ...TxID) (res OpenPositionsResponse, err error) {
+	txidList, err := joinTxIDs(txids)
+	if err != nil {
+		return
+	}
+
+	return u.openPositions(ctx, doCalcs, txidList)
+}
+
+// openPositions returns an associative array of info on the open positions in
+// the comma separated list of transaction ids.
+func (u *UserData) openPositions(ctx context.Context, doCalcs bool, txids string) (res OpenPositionsResponse, err error) {
 	body := url.Values{
 		"docalcs": []string{"false"},
 	}
@@ -252,13 +308,8 @@ func (u *UserData) OpenPositions(ctx context.Context, doCalcs bool, txids ...int
 		body.Set("docalcs", "true")
 	}
 
-	if len(txids) > 0 {
-		txidStrings := make([]string, len(txids))
-		for i := range txids {
-			txidStrings[i] = strconv.FormatInt(txids[i], 10)
-		}
-
-		body.Add("txid", strings.Join(txidStrings, ","))
+	if txids != "" {
+		body.Add("txid", txids)
 	}
 
 	req, err := u.Client.DialWithAuth(ctx, http.MethodPost, OpenPositionsResource, body)
@@ -278,6 +329,13 @@ func (u *UserData) OpenPositions(ctx context.Context, doCalcs bool, txids ...int
 // Ledgers returns an associative array of ledgers info.
 // https://www.kraken.com/en-gb/help/api#get-ledgers-info
 func (u *UserData) Ledgers(ctx context.Context, ledgersReq LedgersRequest) (res LedgersResponse, err error) {
+	res, _, err = u.ledgers(ctx, ledgersReq)
+	return
+}
+
+// ledgers returns a page of ledgers info and the total number of ledger
+// entries matching the request.
+func (u *UserData) ledgers(ctx context.Context, ledgersReq LedgersRequest) (res LedgersResponse, count int, err error) {
 	body := url.Values{
 		"aclass": []string{string(AssetCurrency)},
 	}
@@ -289,7 +347,7 @@ func (u *UserData) Ledgers(ctx context.Context, ledgersReq LedgersRequest) (res
 	if len(ledgersReq.Assets) > 0 {
 		assetStrings := make([]string, len(ledgersReq.Assets))
 		for index := range ledgersReq.Assets {
-			assetStrings[index] = string(ledgersReq.Assets[index])
+			assetStrings[index] = ledgersReq.Assets[index].String()
 		}
 
 		body.Add("asset", strings.Join(assetStrings, ","))
@@ -321,7 +379,13 @@ func (u *UserData) Ledgers(ctx context.Context, ledgersReq LedgersRequest) (res
 		return
 	}
 
-	err = krakenResp.ExtractResult(&res)
+	var page struct {
+		Ledger LedgersResponse `json:"ledger"`
+		Count  int             `json:"count"`
+	}
+
+	err = krakenResp.ExtractResult(&page)
+	res, count = page.Ledger, page.Count
 	return
 }
 
@@ -386,3 +450,21 @@ func (u *UserData) TradeVolume(ctx context.Context, feeInfo bool, pairs ...pairs
 	err = krakenResp.ExtractResult(&res)
 	return
 }
+
+// WebSocketsToken returns a token for subscribing to private feeds on the
+// Kraken WebSocket API.
+// https://www.kraken.com/en-gb/features/api#ws-auth
+func (u *UserData) WebSocketsToken(ctx context.Context) (res *WebSocketsTokenResponse, err error) {
+	req, err := u.Client.DialWithAuth(ctx, http.MethodPost, WebSocketsTokenResource, nil)
+	if err != nil {
+		return
+	}
+
+	krakenResp, err := u.Client.Call(req)
+	if err != nil {
+		return
+	}
+
+	err = krakenResp.ExtractResult(&res)
+	return
+}
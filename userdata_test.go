@@ -3,8 +3,10 @@ package gokraken
 import (
 	"context"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -14,15 +16,15 @@ import (
 
 func TestUserData_Balance(t *testing.T) {
 	mockResponse := Response{
-		Result: map[asset.Currency]float64{
-			asset.BCH:  1.23,
-			asset.DASH: 2.34,
+		Result: map[asset.Currency]Decimal{
+			asset.BCH:  dec("1.23"),
+			asset.DASH: dec("2.34"),
 		},
 	}
 
 	expectedResult := BalanceResponse{
-		asset.BCH:  1.23,
-		asset.DASH: 2.34,
+		asset.BCH:  dec("1.23"),
+		asset.DASH: dec("2.34"),
 	}
 
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -50,15 +52,15 @@ func TestUserData_TradeBalance(t *testing.T) {
 	mockResponse := []byte(`{"result": {"eb":1.23,"tb":1.23,"m":1.23,"n":1.23,"c":1.23,"v":1.23,"e":1.23,"mf":1.23,"ml":1.23}}`)
 
 	expectedResult := &TradeBalanceResponse{
-		EquivalentBalance: 1.23,
-		TradeBalance:      1.23,
-		MarginAmount:      1.23,
-		UnrealizedNet:     1.23,
-		Cost:              1.23,
-		Valuation:         1.23,
-		Equity:            1.23,
-		FreeMargin:        1.23,
-		MarginLevel:       1.23,
+		EquivalentBalance: dec("1.23"),
+		TradeBalance:      dec("1.23"),
+		MarginAmount:      dec("1.23"),
+		UnrealizedNet:     dec("1.23"),
+		Cost:              dec("1.23"),
+		Valuation:         dec("1.23"),
+		Equity:            dec("1.23"),
+		FreeMargin:        dec("1.23"),
+		MarginLevel:       dec("1.23"),
 	}
 
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -82,24 +84,24 @@ func TestUserData_TradeBalance(t *testing.T) {
 }
 
 func TestUserData_OpenOrders(t *testing.T) {
-	mockResponse := []byte(`{"result": {"open":{"1234":{"refid":"4321","userref":123456,"status":"open","opentm":1520287055,"starttm":1520287055,"expiretm":1520287055,"descr":{"pair":45,"close":"","leverage":"","order":"","ordertype":"market","price":"","price2":"","type":""},"vol":"","vol_exec":"1.23","cost":"1.23","fee":"0","price":"0","stopprice.string":0,"limitprice":"0","misc":"","oflags":"","closetm":1520287055,"reason":""}},"count":1}}`)
+	mockResponse := []byte(`{"result": {"open":{"1234":{"refid":"4321","userref":123456,"status":"open","opentm":1520287055,"starttm":1520287055,"expiretm":1520287055,"descr":{"pair":"BCHEUR","close":"","leverage":"","order":"","ordertype":"market","price":"","price2":"","type":""},"vol":"","vol_exec":"1.23","cost":"1.23","fee":"0","price":"0","stopprice":"0","limitprice":"0","misc":"","oflags":"","closetm":1520287055,"reason":""}},"count":1}}`)
 
 	expectedResult := &OpenOrdersResponse{
 		Open: map[string]Order{
 			"1234": {
 				ReferenceID: "4321",
 				UserRef:     123456,
-				Status:      "open",
-				OpenTime:    1520287055,
-				StartTime:   1520287055,
-				ExpireTime:  1520287055,
+				Status:      OrderStatusOpen,
+				OpenTime:    time.Unix(1520287055, 0),
+				StartTime:   time.Unix(1520287055, 0),
+				ExpireTime:  time.Unix(1520287055, 0),
 				Description: OrderDescription{
 					AssetPair: pairs.BCHEUR,
 					OrderType: OrderTypeMarket,
 				},
-				VolumeExecuted: 1.23,
-				Cost:           1.23,
-				CloseTime:      1520287055,
+				VolumeExecuted: dec("1.23"),
+				Cost:           dec("1.23"),
+				CloseTime:      time.Unix(1520287055, 0),
 			},
 		},
 		Count: 1,
@@ -126,24 +128,24 @@ func TestUserData_OpenOrders(t *testing.T) {
 }
 
 func TestUserData_ClosedOrders(t *testing.T) {
-	mockResponse := []byte(`{"result": {"closed":{"1234":{"refid":"4321","userref":123456,"status":"open","opentm":1520287055,"starttm":1520287055,"expiretm":1520287055,"descr":{"pair":45,"close":"","leverage":"","order":"","ordertype":"market","price":"","price2":"","type":""},"vol":"","vol_exec":"1.23","cost":"1.23","fee":"0","price":"0","stopprice.string":0,"limitprice":"0","misc":"","oflags":"","closetm":1520287055,"reason":""}},"count":1}}`)
+	mockResponse := []byte(`{"result": {"closed":{"1234":{"refid":"4321","userref":123456,"status":"open","opentm":1520287055,"starttm":1520287055,"expiretm":1520287055,"descr":{"pair":"BCHEUR","close":"","leverage":"","order":"","ordertype":"market","price":"","price2":"","type":""},"vol":"","vol_exec":"1.23","cost":"1.23","fee":"0","price":"0","stopprice":"0","limitprice":"0","misc":"","oflags":"","closetm":1520287055,"reason":""}},"count":1}}`)
 
 	expectedResult := &ClosedOrdersResponse{
 		Closed: map[string]Order{
 			"1234": {
 				ReferenceID: "4321",
 				UserRef:     123456,
-				Status:      "open",
-				OpenTime:    1520287055,
-				StartTime:   1520287055,
-				ExpireTime:  1520287055,
+				Status:      OrderStatusOpen,
+				OpenTime:    time.Unix(1520287055, 0),
+				StartTime:   time.Unix(1520287055, 0),
+				ExpireTime:  time.Unix(1520287055, 0),
 				Description: OrderDescription{
 					AssetPair: pairs.BCHEUR,
 					OrderType: OrderTypeMarket,
 				},
-				VolumeExecuted: 1.23,
-				Cost:           1.23,
-				CloseTime:      1520287055,
+				VolumeExecuted: dec("1.23"),
+				Cost:           dec("1.23"),
+				CloseTime:      time.Unix(1520287055, 0),
 			},
 		},
 		Count: 1,
@@ -180,23 +182,23 @@ func TestUserData_ClosedOrders(t *testing.T) {
 }
 
 func TestUserData_QueryOrders(t *testing.T) {
-	mockResponse := []byte(`{"result":{"1234":{"refid":"4321","userref":123456,"status":"open","opentm":1520287055,"starttm":1520287055,"expiretm":1520287055,"descr":{"pair":45,"close":"","leverage":"","order":"","ordertype":"market","price":"","price2":"","type":""},"vol":"","vol_exec":"1.23","cost":"1.23","fee":"0","price":"0","stopprice.string":0,"limitprice":"0","misc":"","oflags":"","closetm":1520287055,"reason":""}}}`)
+	mockResponse := []byte(`{"result":{"1234":{"refid":"4321","userref":123456,"status":"open","opentm":1520287055,"starttm":1520287055,"expiretm":1520287055,"descr":{"pair":"BCHEUR","close":"","leverage":"","order":"","ordertype":"market","price":"","price2":"","type":""},"vol":"","vol_exec":"1.23","cost":"1.23","fee":"0","price":"0","stopprice":"0","limitprice":"0","misc":"","oflags":"","closetm":1520287055,"reason":""}}}`)
 
 	expectedResult := &QueryOrdersResponse{
 		"1234": {
 			ReferenceID: "4321",
 			UserRef:     123456,
-			Status:      "open",
-			OpenTime:    1520287055,
-			StartTime:   1520287055,
-			ExpireTime:  1520287055,
+			Status:      OrderStatusOpen,
+			OpenTime:    time.Unix(1520287055, 0),
+			StartTime:   time.Unix(1520287055, 0),
+			ExpireTime:  time.Unix(1520287055, 0),
 			Description: OrderDescription{
 				AssetPair: pairs.BCHEUR,
 				OrderType: OrderTypeMarket,
 			},
-			VolumeExecuted: 1.23,
-			Cost:           1.23,
-			CloseTime:      1520287055,
+			VolumeExecuted: dec("1.23"),
+			Cost:           dec("1.23"),
+			CloseTime:      time.Unix(1520287055, 0),
 		},
 	}
 
@@ -220,6 +222,42 @@ func TestUserData_QueryOrders(t *testing.T) {
 	assert(expectedResult, res, t)
 }
 
+func TestUserData_QueryOrdersByTxID(t *testing.T) {
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"OQCLML-BW3P3-BUCMWZ":{"userref":42,"status":"open"}}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.UserData.QueryOrdersByTxID(context.Background(), true, 42, "OQCLML-BW3P3-BUCMWZ", "OB5VMB-B4U2U-DK2WRW")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(&QueryOrdersResponse{"OQCLML-BW3P3-BUCMWZ": {UserRef: 42, Status: OrderStatusOpen}}, res, t)
+	assert("OQCLML-BW3P3-BUCMWZ,OB5VMB-B4U2U-DK2WRW", body.Get("txid"), t)
+	assert("42", body.Get("userref"), t)
+	assert("true", body.Get("trades"), t)
+
+	body = nil
+	if _, err = k.UserData.QueryOrdersByTxID(context.Background(), false, 0, "OQCLML-BW3P3-BUCMWZ", "654321"); err == nil {
+		t.Fatal("expected an invalid txid error")
+	}
+	if body != nil {
+		t.Fatal("expected no request for an invalid txid")
+	}
+}
+
 func TestUserData_TradesHistory(t *testing.T) {
 	mockResponse := []byte(`{"result":{"trades":{"1234":{"ordertxid":"4321","pair":"BCHEUR","time":1520633741,"type":"buy","ordertype":"market","price":1.23,"cost":1.23,"fee":1.23,"vol":1.23,"margin":1.23,"misc":"foo,bar,baz"}},"count":1}}`)
 
@@ -228,15 +266,15 @@ func TestUserData_TradesHistory(t *testing.T) {
 			"1234": {
 				OrderTxid: "4321",
 				Pair:      pairs.BCHEUR.String(),
-				Time:      1520633741,
+				Time:      time.Unix(1520633741, 0),
 				Type:      TradeBuy,
 				OrderType: OrderTypeMarket,
-				Price:     1.23,
-				Cost:      1.23,
-				Fee:       1.23,
-				Vol:       1.23,
-				Margin:    1.23,
-				Misc:      "foo,bar,baz",
+				Price:     dec("1.23"),
+				Cost:      dec("1.23"),
+				Fee:       dec("1.23"),
+				Vol:       dec("1.23"),
+				Margin:    dec("1.23"),
+				Misc:      []string{"foo", "bar", "baz"},
 			},
 		},
 		Count: 1,
@@ -277,15 +315,15 @@ func TestUserData_QueryTrades(t *testing.T) {
 		"1234": {
 			OrderTxid: "4321",
 			Pair:      pairs.BCHEUR.String(),
-			Time:      1520633741,
+			Time:      time.Unix(1520633741, 0),
 			Type:      TradeBuy,
 			OrderType: OrderTypeMarket,
-			Price:     1.23,
-			Cost:      1.23,
-			Fee:       1.23,
-			Vol:       1.23,
-			Margin:    1.23,
-			Misc:      "foo,bar,baz",
+			Price:     dec("1.23"),
+			Cost:      dec("1.23"),
+			Fee:       dec("1.23"),
+			Vol:       dec("1.23"),
+			Margin:    dec("1.23"),
+			Misc:      []string{"foo", "bar", "baz"},
 		},
 	}
 
@@ -309,6 +347,37 @@ func TestUserData_QueryTrades(t *testing.T) {
 	assert(expectedResult, res, t)
 }
 
+func TestUserData_QueryTradesByTxID(t *testing.T) {
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"TCWJEG-FL4SZ-3FKGH6":{"ordertxid":"OQCLML-BW3P3-BUCMWZ"}}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.UserData.QueryTradesByTxID(context.Background(), false, "TCWJEG-FL4SZ-3FKGH6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(&QueryTradesResponse{"TCWJEG-FL4SZ-3FKGH6": {OrderTxid: "OQCLML-BW3P3-BUCMWZ"}}, res, t)
+	assert("TCWJEG-FL4SZ-3FKGH6", body.Get("txid"), t)
+	assert("false", body.Get("trades"), t)
+
+	if _, err = k.UserData.QueryTradesByTxID(context.Background(), false, "tcwjeg-fl4sz-3fkgh6"); err == nil {
+		t.Fatal("expected an invalid txid error")
+	}
+}
+
 func TestUserData_OpenPositions(t *testing.T) {
 	mockResponse := []byte(`{"result":{"1234":{"ordertxid":"4321","pair":"BCHEUR","time":1520633741,"type":"buy","ordertype":"market","cost":1.23,"fee":1.23,"vol":1.23,"vol_closed":1.23,"margin":1.23,"value":1.23,"net":1.23,"misc":"foo,bar,baz","oflags":"qux,quux","viqc":1.23}}}`)
 
@@ -316,19 +385,19 @@ func TestUserData_OpenPositions(t *testing.T) {
 		"1234": {
 			OrderTxid: "4321",
 			Pair:      pairs.BCHEUR.String(),
-			Time:      1520633741,
-			Type:      string(TradeBuy),
-			OrderType: string(OrderTypeMarket),
-			Cost:      1.23,
-			Fee:       1.23,
-			Vol:       1.23,
-			VolClosed: 1.23,
-			Margin:    1.23,
-			Value:     1.23,
-			Net:       1.23,
-			Misc:      "foo,bar,baz",
-			OFlags:    "qux,quux",
-			Viqc:      1.23,
+			Time:      time.Unix(1520633741, 0),
+			Type:      TradeBuy,
+			OrderType: OrderTypeMarket,
+			Cost:      dec("1.23"),
+			Fee:       dec("1.23"),
+			Vol:       dec("1.23"),
+			VolClosed: dec("1.23"),
+			Margin:    dec("1.23"),
+			Value:     dec("1.23"),
+			Net:       dec("1.23"),
+			Misc:      []string{"foo", "bar", "baz"},
+			OFlags:    []OrderFlag{"qux", "quux"},
+			Viqc:      dec("1.23"),
 		},
 	}
 
@@ -352,19 +421,53 @@ func TestUserData_OpenPositions(t *testing.T) {
 	assert(expectedResult, res, t)
 }
 
+func TestUserData_OpenPositionsByTxID(t *testing.T) {
+	var body url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ = url.ParseQuery(string(b))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(`{"error":[],"result":{"TF5GVO-T7ZZ2-6NBKBI":{"ordertxid":"OQCLML-BW3P3-BUCMWZ"}}}`))
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.UserData.OpenPositionsByTxID(context.Background(), true, "TF5GVO-T7ZZ2-6NBKBI")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(OpenPositionsResponse{"TF5GVO-T7ZZ2-6NBKBI": {OrderTxid: "OQCLML-BW3P3-BUCMWZ"}}, res, t)
+	assert("TF5GVO-T7ZZ2-6NBKBI", body.Get("txid"), t)
+	assert("true", body.Get("docalcs"), t)
+
+	if _, err = k.UserData.OpenPositionsByTxID(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["txid"]; ok {
+		t.Fatal("expected no txid for all open positions")
+	}
+}
+
 func TestUserData_Ledgers(t *testing.T) {
-	mockResponse := []byte(`{"result":{"1234":{"refid":"4321","time":1520633741,"type":"all","aclass":"currency","asset":"DASH","amount":1.23,"fee":1.23,"balance":1.23}}}`)
+	mockResponse := []byte(`{"result":{"ledger":{"1234":{"refid":"4321","time":1520633741.5623,"type":"all","aclass":"currency","asset":"DASH","amount":1.23,"fee":1.23,"balance":1.23}},"count":1}}`)
 
 	expectedResult := LedgersResponse{
 		"1234": {
 			Refid:   "4321",
-			Time:    1520633741,
+			Time:    time.Unix(1520633741, 562300000),
 			Type:    string(LedgerTypeAll),
 			Aclass:  string(AssetCurrency),
 			Asset:   asset.DASH.String(),
-			Amount:  1.23,
-			Fee:     1.23,
-			Balance: 1.23,
+			Amount:  dec("1.23"),
+			Fee:     dec("1.23"),
+			Balance: dec("1.23"),
 		},
 	}
 
@@ -405,13 +508,13 @@ func TestUserData_QueryLedgers(t *testing.T) {
 	expectedResult := LedgersResponse{
 		"1234": {
 			Refid:   "4321",
-			Time:    1520633741,
+			Time:    time.Unix(1520633741, 0),
 			Type:    string(LedgerTypeAll),
 			Aclass:  string(AssetCurrency),
 			Asset:   asset.DASH.String(),
-			Amount:  1.23,
-			Fee:     1.23,
-			Balance: 1.23,
+			Amount:  dec("1.23"),
+			Fee:     dec("1.23"),
+			Balance: dec("1.23"),
 		},
 	}
 
@@ -482,3 +585,31 @@ func TestUserData_TradeVolume(t *testing.T) {
 
 	assert(expectedResult, res, t)
 }
+
+func TestUserData_WebSocketsToken(t *testing.T) {
+	mockResponse := []byte(`{"error":[],"result":{"token":"1Dwc4lzSwNWOAwkMdqhssNNFhs1ed606d1WcF3XfEMw","expires":900}}`)
+
+	expectedResult := &WebSocketsTokenResponse{
+		Token:   "1Dwc4lzSwNWOAwkMdqhssNNFhs1ed606d1WcF3XfEMw",
+		Expires: 900,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write(mockResponse)
+	}))
+
+	defer ts.Close()
+
+	k := NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = ts.URL
+
+	res, err := k.UserData.WebSocketsToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(expectedResult, res, t)
+}
@@ -0,0 +1,304 @@
+package gokraken
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// FieldError describes an invalid field of an order.
+type FieldError struct {
+	Field   string // Name of the UserOrder or EditOrderRequest field.
+	Message string
+}
+
+// Error returns the field and the reason it is invalid.
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when an order fails validation, listing every
+// invalid field of the order.
+type ValidationError []FieldError
+
+// Error returns all of the field errors.
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fieldErr := range e {
+		msgs[i] = fieldErr.Error()
+	}
+
+	return "invalid order: " + strings.Join(msgs, "; ")
+}
+
+// OrderValidator checks orders against the precision, minimums and leverage
+// of their asset pair before they are sent to Kraken, rather than having
+// Kraken reject them.
+//
+// The asset pair data is loaded from Market.AssetPairs when the first order
+// is validated and then cached. Refresh reloads it.
+type OrderValidator struct {
+	Market *Market
+
+	// Round rounds values instead of rejecting the order when they have more
+	// decimal places than the pair allows or, for leverage, is not offered by
+	// the pair. Prices are rounded to the nearest tick, volumes down to the
+	// nearest lot and leverage down to the nearest level offered.
+	Round bool
+
+	mu   sync.Mutex
+	data AssetPairsResponse
+}
+
+// NewOrderValidator returns a new OrderValidator which loads asset pair data
+// from the given market.
+func NewOrderValidator(market *Market, round bool) *OrderValidator {
+	return &OrderValidator{
+		Market: market,
+		Round:  round,
+	}
+}
+
+// Refresh reloads the asset pair data, for example after Kraken has listed
+// new pairs or changed the minimums of existing ones.
+func (v *OrderValidator) Refresh(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.load(ctx)
+}
+
+// load loads the asset pair data. The caller must hold the lock.
+func (v *OrderValidator) load(ctx context.Context) error {
+	data, err := v.Market.AssetPairs(ctx, AssetPairsInfo)
+	if err != nil {
+		return fmt.Errorf("could not load asset pairs: %s", err)
+	}
+
+	v.data = data
+	return nil
+}
+
+// pairData returns the data of a pair, loading the data of all pairs if it
+// has not been loaded yet.
+func (v *OrderValidator) pairData(ctx context.Context, pair pairs.AssetPair) (data AssetPairData, ok bool, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.data == nil {
+		if err = v.load(ctx); err != nil {
+			return
+		}
+	}
+
+	data, ok = v.data[pair]
+	return
+}
+
+// Validate checks an order against the data of its asset pair and returns the
+// order with any rounding applied. If the order is invalid, the error is a
+// ValidationError.
+func (v *OrderValidator) Validate(ctx context.Context, order UserOrder) (UserOrder, error) {
+	data, ok, err := v.pairData(ctx, order.Pair)
+	if err != nil {
+		return order, err
+	}
+
+	if !ok {
+		return order, ValidationError{{Field: "Pair", Message: "unknown asset pair"}}
+	}
+
+	var errs ValidationError
+	errs = append(errs, v.validatePrices(&order, data)...)
+	errs = append(errs, v.validateVolume(&order, data)...)
+	errs = append(errs, v.validateLeverage(&order, data)...)
+
+	if len(errs) > 0 {
+		return order, errs
+	}
+
+	return order, nil
+}
+
+// ValidateEdit checks the changes of an order edit against the data of its
+// asset pair, as Validate does for new orders, and returns the edit with any
+// rounding applied. Fields left unchanged are not checked. If the edit is
+// invalid, the error is a ValidationError.
+func (v *OrderValidator) ValidateEdit(ctx context.Context, edit EditOrderRequest) (EditOrderRequest, error) {
+	data, ok, err := v.pairData(ctx, edit.Pair)
+	if err != nil {
+		return edit, err
+	}
+
+	if !ok {
+		return edit, ValidationError{{Field: "Pair", Message: "unknown asset pair"}}
+	}
+
+	// The type of the order is not known, so the minimum cost is only checked
+	// for volumes in quote currency.
+	order := UserOrder{
+		Pair:   edit.Pair,
+		Price:  edit.Price,
+		Price2: edit.Price2,
+		Volume: edit.Volume,
+		OFlags: edit.OFlags,
+	}
+
+	var errs ValidationError
+	errs = append(errs, v.validatePrices(&order, data)...)
+	if !edit.Volume.IsZero() {
+		errs = append(errs, v.validateVolume(&order, data)...)
+	}
+
+	edit.Price, edit.Price2, edit.Volume = order.Price, order.Price2, order.Volume
+
+	if len(errs) > 0 {
+		return edit, errs
+	}
+
+	return edit, nil
+}
+
+// validatePrices checks the prices of an order against the price precision
+// of the pair.
+func (v *OrderValidator) validatePrices(order *UserOrder, data AssetPairData) (errs ValidationError) {
+	prices := []struct {
+		field string
+		price *OrderPrice
+	}{
+		{"Price", &order.Price},
+		{"Price2", &order.Price2},
+		{"ClosedOrderPrice", &order.ClosedOrderPrice},
+		{"ClosedOrderPrice2", &order.ClosedOrderPrice2},
+	}
+
+	for _, p := range prices {
+		// Percentages are not amounts of the quote currency, so have no
+		// precision to check.
+		if p.price.IsPercent() || p.price.amount.Scale() <= int32(data.PairDecimals) {
+			continue
+		}
+
+		if v.Round {
+			p.price.amount = p.price.amount.Round(int32(data.PairDecimals))
+			continue
+		}
+
+		errs = append(errs, FieldError{
+			Field:   p.field,
+			Message: fmt.Sprintf("%s has more than %d decimal places", p.price.amount, data.PairDecimals),
+		})
+	}
+
+	return
+}
+
+// validateVolume checks the volume of an order against the lot precision and
+// the minimum volume and cost of the pair.
+func (v *OrderValidator) validateVolume(order *UserOrder, data AssetPairData) (errs ValidationError) {
+	if order.Volume.Sign() <= 0 {
+		// Settling a position with a zero volume settles all of it.
+		if order.OrderType != OrderTypeSettlePosition || order.Volume.Sign() < 0 {
+			errs = append(errs, FieldError{Field: "Volume", Message: "must be greater than zero"})
+		}
+		return
+	}
+
+	// Volume in quote currency is a cost, so is not in lots of the base.
+	viqc := false
+	for _, flag := range order.OFlags {
+		viqc = viqc || flag == OrderFlagViqc
+	}
+
+	if !viqc && order.Volume.Scale() > int32(data.LotDecimals) {
+		if v.Round {
+			order.Volume = order.Volume.Truncate(int32(data.LotDecimals))
+		} else {
+			errs = append(errs, FieldError{
+				Field:   "Volume",
+				Message: fmt.Sprintf("%s has more than %d decimal places", order.Volume, data.LotDecimals),
+			})
+			return
+		}
+	}
+
+	if !viqc && order.Volume.Cmp(data.OrderMin) < 0 {
+		errs = append(errs, FieldError{
+			Field:   "Volume",
+			Message: fmt.Sprintf("%s is below the minimum order volume of %s", order.Volume, data.OrderMin),
+		})
+		return
+	}
+
+	// The cost is only known up front for limit orders at an absolute price
+	// and volumes in quote currency.
+	var cost Decimal
+	switch {
+	case viqc:
+		cost = order.Volume
+	case order.OrderType == OrderTypeLimit && !order.Price.IsRelative():
+		cost = order.Price.Amount().Mul(order.Volume)
+	default:
+		return
+	}
+
+	if cost.Cmp(data.CostMin) < 0 {
+		errs = append(errs, FieldError{
+			Field:   "Volume",
+			Message: fmt.Sprintf("cost %s is below the minimum order cost of %s", cost, data.CostMin),
+		})
+	}
+
+	return
+}
+
+// validateLeverage checks the leverage of an order is offered by the pair for
+// the type of order.
+func (v *OrderValidator) validateLeverage(order *UserOrder, data AssetPairData) (errs ValidationError) {
+	if order.Leverage == "" || order.Leverage == "none" {
+		return
+	}
+
+	// Leverage may be given as a ratio, e.g. "2:1".
+	leverage, err := strconv.ParseFloat(strings.SplitN(order.Leverage, ":", 2)[0], 64)
+	if err != nil {
+		errs = append(errs, FieldError{Field: "Leverage", Message: fmt.Sprintf("invalid leverage %q", order.Leverage)})
+		return
+	}
+
+	levels := data.LeverageSell
+	if order.Type == TradeBuy {
+		levels = data.LeverageBuy
+	}
+
+	if len(levels) == 0 {
+		errs = append(errs, FieldError{Field: "Leverage", Message: fmt.Sprintf("leverage is not offered for %s orders", order.Type)})
+		return
+	}
+
+	var best float64
+	for _, level := range levels {
+		if level == leverage {
+			return
+		}
+
+		if level < leverage && level > best {
+			best = level
+		}
+	}
+
+	if v.Round && best > 0 {
+		order.Leverage = strconv.FormatFloat(best, 'f', -1, 64)
+		return
+	}
+
+	errs = append(errs, FieldError{
+		Field:   "Leverage",
+		Message: fmt.Sprintf("leverage %s is not offered for %s orders, offered: %v", order.Leverage, order.Type, levels),
+	})
+	return
+}
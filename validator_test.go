@@ -0,0 +1,267 @@
+package gokraken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// assetPairsResponse is the asset pair data of XXBTZUSD used by the validator
+// tests.
+const assetPairsResponse = `{"error":[],"result":{"XXBTZUSD":{"altname":"XBTUSD","pair_decimals":1,"lot_decimals":8,"leverage_buy":[2,3,5],"leverage_sell":[2,3],"ordermin":"0.0001","costmin":"0.5"}}}`
+
+func TestOrderValidator_Validate(t *testing.T) {
+	var assetPairsCalls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		atomic.AddInt32(&assetPairsCalls, 1)
+		w.Write([]byte(assetPairsResponse))
+	}))
+
+	defer ts.Close()
+
+	k := New()
+	k.BaseURL = ts.URL
+
+	v := NewOrderValidator(k.Market, false)
+
+	cases := []struct {
+		name     string
+		order    UserOrder
+		expected error
+	}{
+		{
+			name:  "valid order",
+			order: UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeLimit, Price: PriceAt(dec("30000.1")), Volume: dec("0.01"), Leverage: "3:1"},
+		},
+		{
+			name:  "valid market order",
+			order: UserOrder{Pair: pairs.XXBTZUSD, Type: TradeSell, OrderType: OrderTypeMarket, Volume: dec("0.0001")},
+		},
+		{
+			name:     "unknown pair",
+			order:    UserOrder{Pair: pairs.BCHEUR, Type: TradeBuy, OrderType: OrderTypeMarket, Volume: dec("1")},
+			expected: ValidationError{{Field: "Pair", Message: "unknown asset pair"}},
+		},
+		{
+			name:  "too many decimal places",
+			order: UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeStopLossLimit, Price: PriceAt(dec("30000.15")), Price2: PriceAt(dec("30000.25")), Volume: dec("0.123456789")},
+			expected: ValidationError{
+				{Field: "Price", Message: "30000.15 has more than 1 decimal places"},
+				{Field: "Price2", Message: "30000.25 has more than 1 decimal places"},
+				{Field: "Volume", Message: "0.123456789 has more than 8 decimal places"},
+			},
+		},
+		{
+			name:  "relative limit order",
+			order: UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeLimit, Price: PriceMinus(dec("0.55")).Percent(), Volume: dec("0.0001")},
+		},
+		{
+			name:     "relative price with too many decimal places",
+			order:    UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeTrailingStop, Price: PricePlus(dec("10.05")), Volume: dec("0.01")},
+			expected: ValidationError{{Field: "Price", Message: "10.05 has more than 1 decimal places"}},
+		},
+		{
+			name:     "zero volume",
+			order:    UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeMarket},
+			expected: ValidationError{{Field: "Volume", Message: "must be greater than zero"}},
+		},
+		{
+			name:     "below minimum volume",
+			order:    UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeMarket, Volume: dec("0.00005")},
+			expected: ValidationError{{Field: "Volume", Message: "0.00005 is below the minimum order volume of 0.0001"}},
+		},
+		{
+			name:     "below minimum cost",
+			order:    UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeLimit, Price: PriceAt(dec("1000")), Volume: dec("0.0002")},
+			expected: ValidationError{{Field: "Volume", Message: "cost 0.2 is below the minimum order cost of 0.5"}},
+		},
+		{
+			name:     "below minimum cost in quote currency",
+			order:    UserOrder{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeMarket, Volume: dec("0.25"), OFlags: []OrderFlag{OrderFlagViqc}},
+			expected: ValidationError{{Field: "Volume", Message: "cost 0.25 is below the minimum order cost of 0.5"}},
+		},
+		{
+			name:     "leverage not offered",
+			order:    UserOrder{Pair: pairs.XXBTZUSD, Type: TradeSell, OrderType: OrderTypeMarket, Volume: dec("1"), Leverage: "5"},
+			expected: ValidationError{{Field: "Leverage", Message: "leverage 5 is not offered for sell orders, offered: [2 3]"}},
+		},
+		{
+			name:     "invalid leverage",
+			order:    UserOrder{Pair: pairs.XXBTZUSD, Type: TradeSell, OrderType: OrderTypeMarket, Volume: dec("1"), Leverage: "x"},
+			expected: ValidationError{{Field: "Leverage", Message: `invalid leverage "x"`}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			order, err := v.Validate(context.Background(), c.order)
+			assert(c.expected, err, t)
+			assert(c.order, order, t)
+		})
+	}
+
+	// The asset pair data is only loaded once.
+	assert(int32(1), atomic.LoadInt32(&assetPairsCalls), t)
+
+	if err := v.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(int32(2), atomic.LoadInt32(&assetPairsCalls), t)
+}
+
+func TestOrderValidator_Round(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(assetPairsResponse))
+	}))
+
+	defer ts.Close()
+
+	k := New()
+	k.BaseURL = ts.URL
+
+	v := NewOrderValidator(k.Market, true)
+
+	order := UserOrder{
+		Pair:      pairs.XXBTZUSD,
+		Type:      TradeSell,
+		OrderType: OrderTypeStopLossLimit,
+		Price:     PriceAt(dec("30000.15")),
+		Price2:    PriceAt(dec("29999.94")),
+		Volume:    dec("0.123456789"),
+		Leverage:  "4",
+	}
+
+	order, err := v.Validate(context.Background(), order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(PriceAt(dec("30000.2")), order.Price, t)
+	assert(PriceAt(dec("29999.9")), order.Price2, t)
+	assert(dec("0.12345678"), order.Volume, t)
+	assert("3", order.Leverage, t)
+
+	// Rounding does not raise a volume to the minimum.
+	order.Volume = dec("0.000001")
+	_, err = v.Validate(context.Background(), order)
+	assert(ValidationError{{Field: "Volume", Message: "0.000001 is below the minimum order volume of 0.0001"}}, err, t)
+}
+
+func TestTrading_AddOrderValidation(t *testing.T) {
+	var addOrderCalls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if strings.HasSuffix(r.URL.Path, AddOrderResource) {
+			atomic.AddInt32(&addOrderCalls, 1)
+			w.Write([]byte(`{"error":[],"result":{"descr":{"order":"buy 0.01 XBTUSD @ limit 30000.1"},"txid":["OUF4EM-FRGI2-MQMWZD"]}}`))
+			return
+		}
+
+		w.Write([]byte(assetPairsResponse))
+	}))
+
+	defer ts.Close()
+
+	k := NewClient(
+		WithCredentials("api_key", "cHJpdmF0ZV9rZXk="),
+		WithBaseURL(ts.URL),
+		WithOrderValidation(false),
+	)
+
+	order := UserOrder{
+		Pair:      pairs.XXBTZUSD,
+		Type:      TradeBuy,
+		OrderType: OrderTypeLimit,
+		Price:     PriceAt(dec("30000.15")),
+		Volume:    dec("0.01"),
+	}
+
+	_, err := k.Trading.AddOrder(context.Background(), order)
+	assert(ValidationError{{Field: "Price", Message: "30000.15 has more than 1 decimal places"}}, err, t)
+	assert("invalid order: Price: 30000.15 has more than 1 decimal places", err.Error(), t)
+	assert(int32(0), atomic.LoadInt32(&addOrderCalls), t)
+
+	order.Price = PriceAt(dec("30000.1"))
+	if _, err = k.Trading.AddOrder(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(int32(1), atomic.LoadInt32(&addOrderCalls), t)
+}
+
+func TestOrderValidator_ValidateEdit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(assetPairsResponse))
+	}))
+
+	defer ts.Close()
+
+	k := New()
+	k.BaseURL = ts.URL
+
+	v := NewOrderValidator(k.Market, false)
+
+	// Unchanged fields are not checked.
+	edit := EditOrderRequest{TxID: "OUF4EM-FRGI2-MQMWZD", Pair: pairs.XXBTZUSD, Price: PriceAt(dec("30000.1"))}
+	res, err := v.ValidateEdit(context.Background(), edit)
+	assert(nil, err, t)
+	assert(edit, res, t)
+
+	edit.Price, edit.Volume = PriceAt(dec("30000.15")), dec("0.00005")
+	_, err = v.ValidateEdit(context.Background(), edit)
+	assert(ValidationError{
+		{Field: "Price", Message: "30000.15 has more than 1 decimal places"},
+		{Field: "Volume", Message: "0.00005 is below the minimum order volume of 0.0001"},
+	}, err, t)
+
+	v.Round = true
+	edit.Volume = dec("0.123456789")
+	res, err = v.ValidateEdit(context.Background(), edit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(PriceAt(dec("30000.2")), res.Price, t)
+	assert(dec("0.12345678"), res.Volume, t)
+}
+
+func TestTrading_AddOrderBatchValidator(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(assetPairsResponse))
+	}))
+
+	defer ts.Close()
+
+	k := NewClient(
+		WithCredentials("api_key", "cHJpdmF0ZV9rZXk="),
+		WithBaseURL(ts.URL),
+		WithOrderValidation(false),
+	)
+
+	orders := []UserOrder{
+		{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeLimit, Price: PriceAt(dec("30000.1")), Volume: dec("0.01")},
+		{Pair: pairs.XXBTZUSD, Type: TradeBuy, OrderType: OrderTypeLimit, Price: PriceAt(dec("29000.15")), Volume: dec("0.01")},
+	}
+
+	_, err := k.Trading.AddOrderBatch(context.Background(), pairs.XXBTZUSD, orders)
+	assert(ValidationError{{Field: "Orders[1].Price", Message: "29000.15 has more than 1 decimal places"}}, err, t)
+}
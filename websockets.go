@@ -0,0 +1,12 @@
+package gokraken
+
+// WebSocketsTokenResource is the API resource for the Kraken API WebSockets
+// authentication token.
+const WebSocketsTokenResource = "GetWebSocketsToken"
+
+// WebSocketsTokenResponse represents the response from the GetWebSocketsToken
+// endpoint of the Kraken API.
+type WebSocketsTokenResponse struct {
+	Token   string `json:"token"`   // Token to authenticate private WebSocket subscriptions.
+	Expires int    `json:"expires"` // Seconds until the token expires if it is not used.
+}
@@ -18,8 +18,8 @@ const (
 // WithdrawInfoResponse represents withdrawal information.
 type WithdrawInfoResponse struct {
 	Method string  `json:"method"`
-	Limit  float64 `json:"limit"`
-	Fee    float64 `json:"fee"`
+	Limit  Decimal `json:"limit"`
+	Fee    Decimal `json:"fee"`
 }
 
 // WithdrawResponse represents the response to a withdraw funds request.
@@ -38,8 +38,8 @@ type WithdrawStatus struct {
 	RefID      string             `json:"refid"`
 	TxID       string             `json:"txid"`
 	Info       string             `json:"info"`
-	Amount     float64            `json:"float64"`
-	Fee        float64            `json:"fee"`
+	Amount     Decimal            `json:"amount"`
+	Fee        Decimal            `json:"fee"`
 	Time       int64              `json:"time"`
 	Status     string             `json:"status"`
 	StatusProp WithdrawStatusProp `json:"status-prop"`
@@ -0,0 +1,694 @@
+// Package ws implements a client for the Kraken WebSocket API.
+// https://docs.kraken.com/websockets/
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// PublicURL is the URL of the public Kraken WebSocket API.
+	PublicURL = "wss://ws.kraken.com"
+
+	// DefaultPingInterval is the default interval between pings sent to check
+	// the connection is alive.
+	DefaultPingInterval = 10 * time.Second
+
+	// DefaultReconnectDelay is the default maximum delay between reconnection
+	// attempts.
+	DefaultReconnectDelay = 30 * time.Second
+
+	// bufferSize is the size of the buffers of the update channels.
+	bufferSize = 128
+)
+
+// ErrClosed is returned when using a client which has been closed.
+var ErrClosed = errors.New("websocket client closed")
+
+// subscriptionKey identifies the subscription of a single pair to a channel.
+type subscriptionKey struct {
+	name     Channel
+	interval int
+	depth    int
+	pair     pairs.AssetPair
+}
+
+// Client is a client for the Kraken WebSocket API. It delivers channel
+// messages on Go channels, which must be drained by the caller, and
+// automatically reconnects and resubscribes if the connection is lost.
+type Client struct {
+	reqID int64 // Accessed atomically, so kept 64-bit aligned.
+
+	URL            string            // URL of the WebSocket API.
+	Dialer         *websocket.Dialer // Dialer used to connect.
+	PingInterval   time.Duration     // Interval between pings.
+	ReconnectDelay time.Duration     // Maximum delay between reconnection attempts.
+
+	// REST is the client used to obtain authentication tokens and resync
+	// private channels. It is only required for private channels.
+	REST *gokraken.Kraken
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	subs    map[subscriptionKey]struct{}
+	pending map[int64]chan json.RawMessage
+	started bool
+	closed  bool
+
+	token     string
+	seqs      map[Channel]int64
+	stale     map[Channel]bool
+	lastTrade time.Time
+
+	writeMu sync.Mutex
+
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	tickers chan TickerUpdate
+	ohlc    chan OhlcUpdate
+	trades  chan TradeUpdate
+	spreads chan SpreadUpdate
+	books   chan BookUpdate
+	status  chan SystemStatus
+	errs    chan error
+
+	ownTrades  chan OwnTradesUpdate
+	openOrders chan OpenOrdersUpdate
+}
+
+// New returns a new Client for the public Kraken WebSocket API.
+func New() *Client {
+	return NewWithURL(PublicURL)
+}
+
+// NewWithURL returns a new Client for the Kraken WebSocket API at url.
+func NewWithURL(url string) *Client {
+	return &Client{
+		URL:            url,
+		Dialer:         websocket.DefaultDialer,
+		PingInterval:   DefaultPingInterval,
+		ReconnectDelay: DefaultReconnectDelay,
+		subs:           make(map[subscriptionKey]struct{}),
+		pending:        make(map[int64]chan json.RawMessage),
+		seqs:           make(map[Channel]int64),
+		stale:          make(map[Channel]bool),
+		done:           make(chan struct{}),
+		tickers:        make(chan TickerUpdate, bufferSize),
+		ohlc:           make(chan OhlcUpdate, bufferSize),
+		trades:         make(chan TradeUpdate, bufferSize),
+		spreads:        make(chan SpreadUpdate, bufferSize),
+		books:          make(chan BookUpdate, bufferSize),
+		status:         make(chan SystemStatus, bufferSize),
+		errs:           make(chan error, bufferSize),
+		ownTrades:      make(chan OwnTradesUpdate, bufferSize),
+		openOrders:     make(chan OpenOrdersUpdate, bufferSize),
+	}
+}
+
+// Tickers returns the channel of ticker updates.
+func (c *Client) Tickers() <-chan TickerUpdate {
+	return c.tickers
+}
+
+// Ohlc returns the channel of ohlc updates.
+func (c *Client) Ohlc() <-chan OhlcUpdate {
+	return c.ohlc
+}
+
+// Trades returns the channel of trade updates.
+func (c *Client) Trades() <-chan TradeUpdate {
+	return c.trades
+}
+
+// Spreads returns the channel of spread updates.
+func (c *Client) Spreads() <-chan SpreadUpdate {
+	return c.spreads
+}
+
+// Books returns the channel of order book updates.
+func (c *Client) Books() <-chan BookUpdate {
+	return c.books
+}
+
+// Status returns the channel of system status updates.
+func (c *Client) Status() <-chan SystemStatus {
+	return c.status
+}
+
+// Errors returns the channel of asynchronous errors, such as messages which
+// could not be decoded or failed reconnection attempts. Errors are dropped if
+// the channel is not drained.
+func (c *Client) Errors() <-chan error {
+	return c.errs
+}
+
+// Connect connects to the Kraken WebSocket API and starts processing
+// messages. If a REST client is set, an authentication token is obtained for
+// subscribing to private channels. A client can only be connected once, after
+// which it reconnects by itself.
+func (c *Client) Connect(ctx context.Context) (err error) {
+	c.mu.Lock()
+	switch {
+	case c.closed:
+		err = ErrClosed
+	case c.started:
+		err = errors.New("websocket client already connected")
+	}
+	c.started = err == nil
+	c.mu.Unlock()
+
+	if err != nil {
+		return
+	}
+
+	// A failed attempt leaves the client free to connect again.
+	defer func() {
+		if err != nil {
+			c.mu.Lock()
+			c.started = false
+			c.mu.Unlock()
+		}
+	}()
+
+	if c.REST != nil {
+		if err = c.authenticate(ctx); err != nil {
+			return
+		}
+	}
+
+	conn, _, err := c.Dialer.DialContext(ctx, c.URL, nil)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		conn.Close()
+		return ErrClosed
+	}
+	c.conn = conn
+	c.mu.Unlock()
+
+	c.wg.Add(2)
+	go c.readLoop(conn)
+	go c.pingLoop()
+
+	return nil
+}
+
+// Close closes the connection and the update channels.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	conn := c.conn
+	c.mu.Unlock()
+
+	close(c.done)
+
+	var err error
+	if conn != nil {
+		c.writeMu.Lock()
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		c.writeMu.Unlock()
+
+		err = conn.Close()
+	}
+
+	c.wg.Wait()
+
+	close(c.tickers)
+	close(c.ohlc)
+	close(c.trades)
+	close(c.spreads)
+	close(c.books)
+	close(c.status)
+	close(c.errs)
+	close(c.ownTrades)
+	close(c.openOrders)
+
+	return err
+}
+
+// Subscribe subscribes to a channel and waits for Kraken to confirm the
+// subscription of each pair. Subscriptions are restored automatically after
+// reconnecting.
+func (c *Client) Subscribe(ctx context.Context, sub Subscription) error {
+	c.mu.Lock()
+	for _, key := range sub.keys() {
+		c.subs[key] = struct{}{}
+	}
+	c.mu.Unlock()
+
+	err := c.request(ctx, EventSubscribe, sub)
+	if err != nil {
+		c.mu.Lock()
+		for _, key := range sub.keys() {
+			delete(c.subs, key)
+		}
+		c.mu.Unlock()
+	}
+
+	return err
+}
+
+// Unsubscribe unsubscribes from a channel and waits for Kraken to confirm.
+func (c *Client) Unsubscribe(ctx context.Context, sub Subscription) error {
+	c.mu.Lock()
+	for _, key := range sub.keys() {
+		delete(c.subs, key)
+	}
+	delete(c.seqs, sub.Name)
+	delete(c.stale, sub.Name)
+	c.mu.Unlock()
+
+	return c.request(ctx, EventUnsubscribe, sub)
+}
+
+// ResyncBook requests a new snapshot of the book of a pair by unsubscribing
+// from and resubscribing to the book channel with the given depth. It does not
+// wait for Kraken to respond, so it can be used as the Resync function of an
+// order book updated by the goroutine receiving from Books. The snapshot is
+// delivered on Books like any other.
+func (c *Client) ResyncBook(pair pairs.AssetPair, depth int) error {
+	sub := Subscription{Name: ChannelBook, Pairs: []pairs.AssetPair{pair}, Depth: depth}
+
+	if err := c.send(sub.event(EventUnsubscribe, c.nextReqID())); err != nil {
+		return err
+	}
+
+	return c.send(sub.event(EventSubscribe, c.nextReqID()))
+}
+
+// request sends a subscribe or unsubscribe event and waits for the status of
+// each pair, or of the channel if it has no pairs.
+func (c *Client) request(ctx context.Context, eventName string, sub Subscription) error {
+	reqID := c.nextReqID()
+
+	keys := sub.keys()
+	responses := c.register(reqID, len(keys))
+	defer c.unregister(reqID)
+
+	if err := c.send(c.subscriptionEvent(eventName, sub, reqID)); err != nil {
+		return err
+	}
+
+	for range keys {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.done:
+			return ErrClosed
+		case msg := <-responses:
+			var status subscriptionStatus
+			if err := json.Unmarshal(msg, &status); err != nil {
+				return fmt.Errorf("could not decode subscription status: %s", err)
+			}
+
+			if status.Status == "error" && status.Pair == "" {
+				return fmt.Errorf("could not %s %s: %s", eventName, sub.Name, status.ErrorMessage)
+			} else if status.Status == "error" {
+				return fmt.Errorf("could not %s %s for %s: %s", eventName, sub.Name, status.Pair, status.ErrorMessage)
+			}
+		}
+	}
+
+	return nil
+}
+
+// register returns a channel on which the given number of responses to the
+// request with the given id are delivered.
+func (c *Client) register(reqID int64, n int) chan json.RawMessage {
+	responses := make(chan json.RawMessage, n)
+
+	c.mu.Lock()
+	c.pending[reqID] = responses
+	c.mu.Unlock()
+
+	return responses
+}
+
+// unregister stops delivering responses to the request with the given id.
+func (c *Client) unregister(reqID int64) {
+	c.mu.Lock()
+	delete(c.pending, reqID)
+	c.mu.Unlock()
+}
+
+// send writes a JSON message to the connection.
+func (c *Client) send(v interface{}) error {
+	c.mu.Lock()
+	conn, closed := c.conn, c.closed
+	c.mu.Unlock()
+
+	if closed {
+		return ErrClosed
+	}
+
+	if conn == nil {
+		return errors.New("websocket client not connected")
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return conn.WriteJSON(v)
+}
+
+// nextReqID returns a new request id.
+func (c *Client) nextReqID() int64 {
+	return atomic.AddInt64(&c.reqID, 1)
+}
+
+// pingLoop periodically pings Kraken so that a dead connection is detected by
+// the read deadline.
+func (c *Client) pingLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.send(event{Event: EventPing, ReqID: c.nextReqID()}); err != nil {
+				c.reportError(fmt.Errorf("could not send ping: %s", err))
+			}
+		}
+	}
+}
+
+// readLoop reads messages from the connection, reconnecting when it fails.
+func (c *Client) readLoop(conn *websocket.Conn) {
+	defer c.wg.Done()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(2 * c.PingInterval))
+
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			conn.Close()
+
+			if conn = c.reconnect(err); conn == nil {
+				return
+			}
+			continue
+		}
+
+		if err = c.handle(msg); err != nil {
+			c.reportError(err)
+		}
+	}
+}
+
+// reconnect reconnects to the Kraken WebSocket API after a connection error
+// and resubscribes to all channels. It returns nil if the client is closed.
+func (c *Client) reconnect(cause error) *websocket.Conn {
+	delay := 100 * time.Millisecond
+
+	for {
+		select {
+		case <-c.done:
+			return nil
+		default:
+		}
+
+		c.reportError(fmt.Errorf("websocket connection lost, reconnecting: %s", cause))
+
+		err := c.reauthenticate()
+
+		var conn *websocket.Conn
+		if err == nil {
+			conn, _, err = c.Dialer.Dial(c.URL, nil)
+		}
+
+		if err == nil {
+			c.mu.Lock()
+			if c.closed {
+				c.mu.Unlock()
+				conn.Close()
+				return nil
+			}
+			c.conn = conn
+			c.markStale()
+			c.mu.Unlock()
+
+			if err = c.resubscribe(); err == nil {
+				return conn
+			}
+			conn.Close()
+		}
+		cause = err
+
+		select {
+		case <-c.done:
+			return nil
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > c.ReconnectDelay {
+			delay = c.ReconnectDelay
+		}
+	}
+}
+
+// resubscribe sends subscribe events for all current subscriptions.
+func (c *Client) resubscribe() error {
+	c.mu.Lock()
+	subs := make([]Subscription, 0, len(c.subs))
+	for key := range c.subs {
+		sub := Subscription{
+			Name:     key.name,
+			Interval: key.interval,
+			Depth:    key.depth,
+		}
+
+		if !key.name.private() {
+			sub.Pairs = []pairs.AssetPair{key.pair}
+		}
+
+		subs = append(subs, sub)
+	}
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		if err := c.send(c.subscriptionEvent(EventSubscribe, sub, c.nextReqID())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handle processes a single message from Kraken.
+func (c *Client) handle(msg []byte) error {
+	if len(msg) > 0 && msg[0] == '{' {
+		return c.handleEvent(msg)
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(msg, &raw); err != nil {
+		return fmt.Errorf("could not decode message: %s", err)
+	}
+
+	// Private channel messages are [payload, channelName, {"sequence": n}].
+	if len(raw) == 3 && len(raw[2]) > 0 && raw[2][0] == '{' {
+		return c.handlePrivate(raw)
+	}
+
+	// Channel messages are [channelID, payload..., channelName, pair].
+	if len(raw) < 4 {
+		return fmt.Errorf("unexpected message: %s", msg)
+	}
+
+	var channelName, pairName string
+	if err := json.Unmarshal(raw[len(raw)-2], &channelName); err != nil {
+		return fmt.Errorf("could not decode channel name: %s", err)
+	}
+
+	if err := json.Unmarshal(raw[len(raw)-1], &pairName); err != nil {
+		return fmt.Errorf("could not decode pair: %s", err)
+	}
+
+	pair, ok := FindPair(pairName)
+	if !ok {
+		return fmt.Errorf("unknown pair %s", pairName)
+	}
+
+	return c.handleChannel(channelName, pair, raw[1:len(raw)-2])
+}
+
+// handleEvent processes an event message from Kraken.
+func (c *Client) handleEvent(msg []byte) error {
+	var e struct {
+		Event string `json:"event"`
+		ReqID int64  `json:"reqid"`
+	}
+	if err := json.Unmarshal(msg, &e); err != nil {
+		return fmt.Errorf("could not decode event: %s", err)
+	}
+
+	// Deliver responses to the requests waiting for them.
+	c.mu.Lock()
+	pending, ok := c.pending[e.ReqID]
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case pending <- msg:
+		default:
+		}
+
+		return nil
+	}
+
+	switch e.Event {
+	case EventSystemStatus:
+		var status SystemStatus
+		if err := json.Unmarshal(msg, &status); err != nil {
+			return fmt.Errorf("could not decode system status: %s", err)
+		}
+
+		select {
+		case c.status <- status:
+		case <-c.done:
+		}
+	case EventSubscriptionStatus:
+		var status subscriptionStatus
+		if err := json.Unmarshal(msg, &status); err != nil {
+			return fmt.Errorf("could not decode subscription status: %s", err)
+		}
+
+		if status.Status == "error" {
+			return fmt.Errorf("subscription error for %s: %s", status.Pair, status.ErrorMessage)
+		}
+	}
+
+	return nil
+}
+
+// handleChannel processes the payloads of a channel message.
+func (c *Client) handleChannel(channelName string, pair pairs.AssetPair, payloads []json.RawMessage) error {
+	name, n := parseChannelName(channelName)
+
+	switch name {
+	case ChannelTicker:
+		ticker, err := decodeTicker(payloads[0])
+		if err != nil {
+			return err
+		}
+
+		select {
+		case c.tickers <- TickerUpdate{Pair: pair, Ticker: ticker}:
+		case <-c.done:
+		}
+	case ChannelOhlc:
+		ohlc, end, err := decodeOhlc(payloads[0])
+		if err != nil {
+			return fmt.Errorf("could not decode ohlc: %s", err)
+		}
+
+		select {
+		case c.ohlc <- OhlcUpdate{Pair: pair, Interval: n, Ohlc: ohlc, EndTime: end}:
+		case <-c.done:
+		}
+	case ChannelTrade:
+		trades, err := decodeTrades(payloads[0])
+		if err != nil {
+			return fmt.Errorf("could not decode trades: %s", err)
+		}
+
+		select {
+		case c.trades <- TradeUpdate{Pair: pair, Trades: trades}:
+		case <-c.done:
+		}
+	case ChannelSpread:
+		spread, err := decodeSpread(payloads[0])
+		if err != nil {
+			return fmt.Errorf("could not decode spread: %s", err)
+		}
+
+		select {
+		case c.spreads <- SpreadUpdate{Pair: pair, Spread: spread}:
+		case <-c.done:
+		}
+	case ChannelBook:
+		update := BookUpdate{Pair: pair, Depth: n}
+		if err := decodeBook(payloads, &update); err != nil {
+			return err
+		}
+
+		select {
+		case c.books <- update:
+		case <-c.done:
+		}
+	default:
+		return fmt.Errorf("unknown channel %s", channelName)
+	}
+
+	return nil
+}
+
+// reportError sends an error on the errors channel if there is room.
+func (c *Client) reportError(err error) {
+	select {
+	case c.errs <- err:
+	default:
+	}
+}
+
+// keys returns the per pair keys of the subscription. Subscriptions to
+// private channels, which have no pairs, have a single key.
+func (s Subscription) keys() []subscriptionKey {
+	if len(s.Pairs) == 0 {
+		return []subscriptionKey{{name: s.Name}}
+	}
+
+	keys := make([]subscriptionKey, len(s.Pairs))
+	for i, pair := range s.Pairs {
+		keys[i] = subscriptionKey{
+			name:     s.Name,
+			interval: s.Interval,
+			depth:    s.Depth,
+			pair:     pair,
+		}
+	}
+
+	return keys
+}
+
+// event returns the subscribe or unsubscribe event for the subscription.
+func (s Subscription) event(name string, reqID int64) event {
+	pairNames := make([]string, len(s.Pairs))
+	for i, pair := range s.Pairs {
+		pairNames[i] = PairName(pair)
+	}
+
+	return event{
+		Event: name,
+		ReqID: reqID,
+		Pair:  pairNames,
+		Subscription: &subscriptionSpec{
+			Name:     s.Name,
+			Interval: s.Interval,
+			Depth:    s.Depth,
+		},
+	}
+}
@@ -0,0 +1,651 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+	"github.com/gorilla/websocket"
+)
+
+// mockServer is a fake Kraken WebSocket API. It confirms subscriptions and
+// replies to each one with the configured channel messages.
+type mockServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	events      []map[string]interface{}
+	connections int
+	conns       []*websocket.Conn
+
+	// messages are sent after confirming a subscription to a channel.
+	messages map[Channel][]string
+
+	// replies are sent in response to other events, by event name.
+	replies map[string]string
+
+	// onConnect is called for each new connection.
+	onConnect func(conn *websocket.Conn, n int)
+}
+
+func newMockServer(messages map[Channel][]string) *mockServer {
+	s := &mockServer{messages: messages}
+
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s.mu.Lock()
+		s.connections++
+		s.conns = append(s.conns, conn)
+		n := s.connections
+		s.mu.Unlock()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"connectionID":8628615390848610000,"event":"systemStatus","status":"online","version":"1.0.0"}`))
+
+		if s.onConnect != nil {
+			s.onConnect(conn, n)
+		}
+
+		for {
+			var e map[string]interface{}
+			if err := conn.ReadJSON(&e); err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.events = append(s.events, e)
+			s.mu.Unlock()
+
+			s.reply(conn, e)
+		}
+	}))
+
+	return s
+}
+
+// reply responds to an event from the client.
+func (s *mockServer) reply(conn *websocket.Conn, e map[string]interface{}) {
+	switch e["event"] {
+	case EventPing:
+		conn.WriteJSON(map[string]interface{}{"event": EventPong, "reqid": e["reqid"]})
+	case EventSubscribe, EventUnsubscribe:
+		sub := e["subscription"].(map[string]interface{})
+		name := Channel(sub["name"].(string))
+
+		// Private subscriptions have no pairs and a single status.
+		pairNames, _ := e["pair"].([]interface{})
+		if len(pairNames) == 0 {
+			pairNames = []interface{}{nil}
+		}
+
+		for _, pair := range pairNames {
+			status := map[string]interface{}{
+				"event":        EventSubscriptionStatus,
+				"reqid":        e["reqid"],
+				"status":       "subscribed",
+				"subscription": sub,
+			}
+
+			if pair != nil {
+				status["pair"] = pair
+			}
+
+			if e["event"] == EventUnsubscribe {
+				status["status"] = "unsubscribed"
+			}
+
+			if depth, ok := sub["depth"].(float64); ok && depth == 42 {
+				status["status"] = "error"
+				status["errorMessage"] = "Subscription depth not supported"
+			}
+
+			conn.WriteJSON(status)
+		}
+
+		if e["event"] == EventSubscribe {
+			for _, msg := range s.messages[name] {
+				conn.WriteMessage(websocket.TextMessage, []byte(msg))
+			}
+		}
+	default:
+		name, _ := e["event"].(string)
+		if reply, ok := s.replies[name]; ok {
+			var r map[string]interface{}
+			json.Unmarshal([]byte(reply), &r)
+			r["reqid"] = e["reqid"]
+
+			conn.WriteJSON(r)
+		}
+	}
+}
+
+// drop closes all open connections.
+func (s *mockServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+// subscribeEvents returns the subscribe events received.
+func (s *mockServer) subscribeEvents() (events []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.events {
+		if e["event"] == EventSubscribe {
+			events = append(events, e)
+		}
+	}
+
+	return
+}
+
+// wsURL returns the WebSocket URL of the server.
+func (s *mockServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	cases := []struct {
+		name     string
+		sub      Subscription
+		message  string
+		receive  func(c *Client) interface{}
+		expected interface{}
+	}{
+		{
+			name: "ticker",
+			sub: Subscription{
+				Name:  ChannelTicker,
+				Pairs: []pairs.AssetPair{pairs.XXBTZUSD},
+			},
+			message: `[0,{"a":["5525.40000",1,"1.000"],"b":["5525.10000",1,"1.000"],"c":["5525.10000","0.00398963"],"v":["2634.11501494","3591.17907851"],"p":["5631.44067","5653.78939"],"t":[11493,16267],"l":["5505.00000","5505.00000"],"h":["5783.00000","5783.00000"],"o":["5760.70000","5763.40000"]},"ticker","XBT/USD"]`,
+			receive: func(c *Client) interface{} {
+				return <-c.Tickers()
+			},
+			expected: TickerUpdate{
+				Pair: pairs.XXBTZUSD,
+				Ticker: gokraken.TickerInfo{
+					A: []string{"5525.40000", "1", "1.000"},
+					B: []string{"5525.10000", "1", "1.000"},
+					C: []string{"5525.10000", "0.00398963"},
+					V: []string{"2634.11501494", "3591.17907851"},
+					P: []string{"5631.44067", "5653.78939"},
+					T: []int{11493, 16267},
+					L: []string{"5505.00000", "5505.00000"},
+					H: []string{"5783.00000", "5783.00000"},
+					O: "5760.70000",
+				},
+			},
+		},
+		{
+			name: "ohlc",
+			sub: Subscription{
+				Name:     ChannelOhlc,
+				Pairs:    []pairs.AssetPair{pairs.XETHZEUR},
+				Interval: 5,
+			},
+			message: `[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2],"ohlc-5","ETH/EUR"]`,
+			receive: func(c *Client) interface{} {
+				return <-c.Ohlc()
+			},
+			expected: OhlcUpdate{
+				Pair:     pairs.XETHZEUR,
+				Interval: 5,
+				Ohlc: gokraken.OhlcData{
+					Timestamp: time.Unix(1542057314, 748456000),
+					Open:      dec("3586.7"),
+					High:      dec("3586.7"),
+					Low:       dec("3586.6"),
+					Close:     dec("3586.6"),
+					Vwap:      dec("3586.68894"),
+					Volume:    dec("0.03373"),
+					Count:     2,
+				},
+				EndTime: time.Unix(1542057360, 435743000),
+			},
+		},
+		{
+			name: "trade",
+			sub: Subscription{
+				Name:  ChannelTrade,
+				Pairs: []pairs.AssetPair{pairs.XXBTZUSD},
+			},
+			message: `[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""],["6060.00000","0.02455000","1534614057.324998","b","m",""]],"trade","XBT/USD"]`,
+			receive: func(c *Client) interface{} {
+				return <-c.Trades()
+			},
+			expected: TradeUpdate{
+				Pair: pairs.XXBTZUSD,
+				Trades: []gokraken.Trade{
+					{
+						Price:       dec("5541.2"),
+						Volume:      dec("0.15850568"),
+						Timestamp:   time.Unix(1534614057, 321597000),
+						BuySell:     gokraken.TradeSell,
+						MarketLimit: gokraken.TradeLimit,
+					},
+					{
+						Price:       dec("6060"),
+						Volume:      dec("0.02455"),
+						Timestamp:   time.Unix(1534614057, 324998000),
+						BuySell:     gokraken.TradeBuy,
+						MarketLimit: gokraken.TradeMarket,
+					},
+				},
+			},
+		},
+		{
+			name: "spread",
+			sub: Subscription{
+				Name:  ChannelSpread,
+				Pairs: []pairs.AssetPair{pairs.XXBTZUSD},
+			},
+			message: `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`,
+			receive: func(c *Client) interface{} {
+				return <-c.Spreads()
+			},
+			expected: SpreadUpdate{
+				Pair: pairs.XXBTZUSD,
+				Spread: gokraken.SpreadData{
+					Timestamp: time.Unix(1542057299, 545897000),
+					Bid:       dec("5698.4"),
+					Ask:       dec("5700"),
+				},
+			},
+		},
+		{
+			name: "book snapshot",
+			sub: Subscription{
+				Name:  ChannelBook,
+				Pairs: []pairs.AssetPair{pairs.XXBTZUSD},
+				Depth: 10,
+			},
+			message: `[0,{"as":[["5541.30000","2.50700000","1534614248.123678"]],"bs":[["5541.20000","1.52900000","1534614248.765567"]]},"book-10","XBT/USD"]`,
+			receive: func(c *Client) interface{} {
+				return <-c.Books()
+			},
+			expected: BookUpdate{
+				Pair:     pairs.XXBTZUSD,
+				Depth:    10,
+				Snapshot: true,
+				Asks: []gokraken.DepthItem{
+					{Price: dec("5541.3"), Volume: dec("2.507"), Timestamp: time.Unix(1534614248, 123678000)},
+				},
+				Bids: []gokraken.DepthItem{
+					{Price: dec("5541.2"), Volume: dec("1.529"), Timestamp: time.Unix(1534614248, 765567000)},
+				},
+			},
+		},
+		{
+			name: "book update",
+			sub: Subscription{
+				Name:  ChannelBook,
+				Pairs: []pairs.AssetPair{pairs.XXBTZUSD},
+				Depth: 10,
+			},
+			message: `[1234,{"a":[["5541.30000","2.50700000","1534614248.456738","r"]]},{"b":[["5541.30000","0.00000000","1534614335.345903"]],"c":"974942666"},"book-10","XBT/USD"]`,
+			receive: func(c *Client) interface{} {
+				return <-c.Books()
+			},
+			expected: BookUpdate{
+				Pair:  pairs.XXBTZUSD,
+				Depth: 10,
+				Asks: []gokraken.DepthItem{
+					{Price: dec("5541.3"), Volume: dec("2.507"), Timestamp: time.Unix(1534614248, 456738000)},
+				},
+				Bids: []gokraken.DepthItem{
+					{Price: dec("5541.3"), Volume: dec("0"), Timestamp: time.Unix(1534614335, 345903000)},
+				},
+				Checksum: 974942666,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newMockServer(map[Channel][]string{
+				c.sub.Name: {c.message},
+			})
+			defer s.Close()
+
+			client := NewWithURL(s.wsURL())
+			defer client.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := client.Connect(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := client.Subscribe(ctx, c.sub); err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.expected, c.receive(client), t)
+		})
+	}
+}
+
+func TestClient_SubscribeError(t *testing.T) {
+	s := newMockServer(nil)
+	defer s.Close()
+
+	client := NewWithURL(s.wsURL())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	err := client.Subscribe(ctx, Subscription{
+		Name:  ChannelBook,
+		Pairs: []pairs.AssetPair{pairs.XXBTZUSD},
+		Depth: 42,
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "Subscription depth not supported") {
+		t.Fatalf("%s: unexpected error %v", t.Name(), err)
+	}
+}
+
+func TestClient_Status(t *testing.T) {
+	s := newMockServer(nil)
+	defer s.Close()
+
+	client := NewWithURL(s.wsURL())
+	defer client.Close()
+
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := SystemStatus{
+		ConnectionID: 8628615390848610000,
+		Status:       "online",
+		Version:      "1.0.0",
+	}
+
+	assert(expected, <-client.Status(), t)
+}
+
+func TestClient_Ping(t *testing.T) {
+	s := newMockServer(nil)
+
+	// Send heartbeats to check they are ignored.
+	s.onConnect = func(conn *websocket.Conn, n int) {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event":"heartbeat"}`))
+	}
+	defer s.Close()
+
+	client := NewWithURL(s.wsURL())
+	client.PingInterval = 10 * time.Millisecond
+	defer client.Close()
+
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pongs keep the connection alive beyond the read deadline.
+	time.Sleep(100 * time.Millisecond)
+
+	s.mu.Lock()
+	pings := len(s.events)
+	connections := s.connections
+	s.mu.Unlock()
+
+	if pings == 0 {
+		t.Fatalf("%s: no pings sent", t.Name())
+	}
+
+	assert(1, connections, t)
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	s := newMockServer(map[Channel][]string{
+		ChannelSpread: {`[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`},
+	})
+	defer s.Close()
+
+	client := NewWithURL(s.wsURL())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := Subscription{
+		Name:  ChannelSpread,
+		Pairs: []pairs.AssetPair{pairs.XXBTZUSD},
+	}
+
+	if err := client.Subscribe(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	<-client.Spreads()
+
+	// Drop the connection; the client should reconnect and resubscribe.
+	s.drop()
+
+	select {
+	case <-client.Spreads():
+	case <-ctx.Done():
+		t.Fatalf("%s: no update after reconnecting", t.Name())
+	}
+
+	assert(2, len(s.subscribeEvents()), t)
+}
+
+func TestClient_Unsubscribe(t *testing.T) {
+	s := newMockServer(nil)
+	defer s.Close()
+
+	client := NewWithURL(s.wsURL())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := Subscription{
+		Name:  ChannelTicker,
+		Pairs: []pairs.AssetPair{pairs.XXBTZUSD, pairs.XETHZEUR},
+	}
+
+	if err := client.Subscribe(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Unsubscribe(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+
+	client.mu.Lock()
+	subs := len(client.subs)
+	client.mu.Unlock()
+
+	assert(0, subs, t)
+}
+
+func TestClient_ConnectTwice(t *testing.T) {
+	s := newMockServer(nil)
+	defer s.Close()
+
+	client := NewWithURL(s.wsURL())
+	defer client.Close()
+
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := client.Connect(context.Background())
+	assert("websocket client already connected", err.Error(), t)
+}
+
+func TestClient_Close(t *testing.T) {
+	s := newMockServer(nil)
+	defer s.Close()
+
+	client := NewWithURL(s.wsURL())
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	client.Close()
+
+	if _, ok := <-client.Tickers(); ok {
+		t.Fatalf("%s: channel not closed", t.Name())
+	}
+
+	err := client.Subscribe(context.Background(), Subscription{Name: ChannelTicker})
+	assert(ErrClosed, err, t)
+}
+
+func TestPairName(t *testing.T) {
+	cases := []struct {
+		pair     pairs.AssetPair
+		expected string
+	}{
+		{pair: pairs.XXBTZUSD, expected: "XBT/USD"},
+		{pair: pairs.XETHXXBT, expected: "ETH/XBT"},
+		{pair: pairs.DASHXBT, expected: "DASH/XBT"},
+		{pair: pairs.EOSETH, expected: "EOS/ETH"},
+		{pair: pairs.USDTZUSD, expected: "USDT/USD"},
+		{pair: pairs.XXDGXXBT, expected: "XDG/XBT"},
+	}
+	for _, c := range cases {
+		assert(c.expected, PairName(c.pair), t)
+
+		pair, ok := FindPair(c.expected)
+		assert(true, ok, t)
+		assert(c.pair, pair, t)
+	}
+}
+
+func TestSubscription_Event(t *testing.T) {
+	sub := Subscription{
+		Name:     ChannelOhlc,
+		Pairs:    []pairs.AssetPair{pairs.XXBTZUSD},
+		Interval: 5,
+	}
+
+	b, err := json.Marshal(sub.event(EventSubscribe, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(`{"event":"subscribe","reqid":1,"pair":["XBT/USD"],"subscription":{"name":"ohlc","interval":5}}`, string(b), t)
+}
+
+func TestClient_ResyncBook(t *testing.T) {
+	s := newMockServer(map[Channel][]string{
+		ChannelBook: {`[0,{"as":[["5541.30000","2.50700000","1534614248.123678"]],"bs":[["5541.20000","1.52900000","1534614248.765567"]]},"book-10","XBT/USD"]`},
+	})
+	defer s.Close()
+
+	client := NewWithURL(s.wsURL())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Subscribe(ctx, Subscription{Name: ChannelBook, Pairs: []pairs.AssetPair{pairs.XXBTZUSD}, Depth: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	book := gokraken.NewOrderBook(pairs.XXBTZUSD, 10, 5, 8)
+	book.Resync = func(ctx context.Context) error {
+		return client.ResyncBook(pairs.XXBTZUSD, 10)
+	}
+
+	if err := (<-client.Books()).ApplyTo(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+
+	update := BookUpdate{
+		Pair:     pairs.XXBTZUSD,
+		Bids:     []gokraken.DepthItem{{Price: dec("5541.2"), Volume: dec("0")}},
+		Checksum: 1,
+	}
+
+	if err := update.ApplyTo(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(true, book.Resyncing(), t)
+
+	// Resubscribing delivers a new snapshot, which reloads the book.
+	if err := (<-client.Books()).ApplyTo(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(false, book.Resyncing(), t)
+	assert(2, len(book.Snapshot().Asks)+len(book.Snapshot().Bids), t)
+
+	s.mu.Lock()
+	events := make([]interface{}, len(s.events))
+	for i, e := range s.events {
+		events[i] = e["event"]
+	}
+	s.mu.Unlock()
+
+	assert([]interface{}{EventSubscribe, EventUnsubscribe, EventSubscribe}, events, t)
+}
+
+func TestBookUpdate_ApplyTo(t *testing.T) {
+	book := gokraken.NewOrderBook(pairs.XXBTZUSD, 10, 5, 8)
+
+	snapshot := BookUpdate{
+		Pair:     pairs.XXBTZUSD,
+		Snapshot: true,
+		Asks:     []gokraken.DepthItem{{Price: dec("5541.3"), Volume: dec("2.507")}},
+		Bids:     []gokraken.DepthItem{{Price: dec("5541.2"), Volume: dec("1.529")}},
+	}
+
+	if err := snapshot.ApplyTo(context.Background(), book); err != nil {
+		t.Fatal(err)
+	}
+
+	update := BookUpdate{
+		Pair:     pairs.XXBTZUSD,
+		Bids:     []gokraken.DepthItem{{Price: dec("5541.2"), Volume: dec("0")}},
+		Checksum: 1,
+	}
+
+	assert(gokraken.ErrChecksumMismatch, update.ApplyTo(context.Background(), book), t)
+
+	expected := gokraken.Depth{
+		Asks: []gokraken.DepthItem{{Price: dec("5541.3"), Volume: dec("2.507")}},
+	}
+
+	assert(expected, book.Snapshot(), t)
+}
@@ -0,0 +1,398 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+const (
+	// ChannelTicker is the channel for ticker information.
+	ChannelTicker Channel = "ticker"
+
+	// ChannelOhlc is the channel for open high low close (candle) data.
+	ChannelOhlc Channel = "ohlc"
+
+	// ChannelTrade is the channel for trades.
+	ChannelTrade Channel = "trade"
+
+	// ChannelSpread is the channel for spread data.
+	ChannelSpread Channel = "spread"
+
+	// ChannelBook is the channel for order book levels.
+	ChannelBook Channel = "book"
+
+	// EventHeartbeat is sent by Kraken when no other messages have been sent
+	// for about a second.
+	EventHeartbeat = "heartbeat"
+
+	// EventPing is sent to Kraken to check the connection is alive.
+	EventPing = "ping"
+
+	// EventPong is sent by Kraken in response to a ping.
+	EventPong = "pong"
+
+	// EventSubscribe is sent to Kraken to subscribe to a channel.
+	EventSubscribe = "subscribe"
+
+	// EventUnsubscribe is sent to Kraken to unsubscribe from a channel.
+	EventUnsubscribe = "unsubscribe"
+
+	// EventSubscriptionStatus is sent by Kraken in response to subscribe and
+	// unsubscribe events.
+	EventSubscriptionStatus = "subscriptionStatus"
+
+	// EventSystemStatus is sent by Kraken on connection and whenever the
+	// system status changes.
+	EventSystemStatus = "systemStatus"
+)
+
+// Channel is the name of a Kraken WebSocket channel.
+type Channel string
+
+// Subscription represents a subscription to a Kraken WebSocket channel.
+type Subscription struct {
+	Name     Channel           // Channel to subscribe to.
+	Pairs    []pairs.AssetPair // Asset pairs to subscribe to, except for private channels.
+	Interval int               // Candle interval in minutes, for ChannelOhlc.
+	Depth    int               // Number of book levels, for ChannelBook.
+}
+
+// SystemStatus represents the status of the Kraken WebSocket API.
+type SystemStatus struct {
+	ConnectionID uint64 `json:"connectionID"`
+	Status       string `json:"status"`
+	Version      string `json:"version"`
+}
+
+// TickerUpdate is a message from the ticker channel.
+type TickerUpdate struct {
+	Pair   pairs.AssetPair
+	Ticker gokraken.TickerInfo
+}
+
+// OhlcUpdate is a message from the ohlc channel. It carries the candle for
+// the current interval, which is updated until EndTime.
+type OhlcUpdate struct {
+	Pair     pairs.AssetPair
+	Interval int
+	Ohlc     gokraken.OhlcData
+	EndTime  time.Time
+}
+
+// TradeUpdate is a message from the trade channel.
+type TradeUpdate struct {
+	Pair   pairs.AssetPair
+	Trades []gokraken.Trade
+}
+
+// SpreadUpdate is a message from the spread channel.
+type SpreadUpdate struct {
+	Pair   pairs.AssetPair
+	Spread gokraken.SpreadData
+}
+
+// BookUpdate is a message from the book channel. The first message after
+// subscribing is a snapshot of the book, later messages contain changed
+// levels. Levels with a zero volume have been removed.
+type BookUpdate struct {
+	Pair     pairs.AssetPair
+	Depth    int
+	Snapshot bool
+	Asks     []gokraken.DepthItem
+	Bids     []gokraken.DepthItem
+	Checksum uint32
+}
+
+// ApplyTo applies the update to an order book. Snapshots replace the contents
+// of the book and other updates are verified against their checksum. The
+// Resync function of the book should call ResyncBook, so that a mismatched
+// book is reloaded from a snapshot sequenced with the updates.
+func (u BookUpdate) ApplyTo(ctx context.Context, book *gokraken.OrderBook) error {
+	if u.Snapshot {
+		book.Load(gokraken.Depth{Asks: u.Asks, Bids: u.Bids})
+		return nil
+	}
+
+	return book.Update(ctx, u.Asks, u.Bids, u.Checksum)
+}
+
+// event is a generic Kraken WebSocket event message.
+type event struct {
+	Event        string            `json:"event"`
+	ReqID        int64             `json:"reqid,omitempty"`
+	Pair         []string          `json:"pair,omitempty"`
+	Subscription *subscriptionSpec `json:"subscription,omitempty"`
+}
+
+// subscriptionSpec describes a subscription in subscribe and unsubscribe
+// events.
+type subscriptionSpec struct {
+	Name     Channel `json:"name"`
+	Interval int     `json:"interval,omitempty"`
+	Depth    int     `json:"depth,omitempty"`
+	Token    string  `json:"token,omitempty"`
+}
+
+// subscriptionStatus is sent by Kraken in response to subscribe and
+// unsubscribe events.
+type subscriptionStatus struct {
+	ReqID        int64  `json:"reqid"`
+	Pair         string `json:"pair"`
+	Status       string `json:"status"`
+	ChannelName  string `json:"channelName"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// parseChannelName splits a channel name such as ohlc-5 or book-10 into the
+// channel and its interval or depth.
+func parseChannelName(name string) (Channel, int) {
+	parts := strings.SplitN(name, "-", 2)
+	if len(parts) != 2 {
+		return Channel(name), 0
+	}
+
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Channel(name), 0
+	}
+
+	return Channel(parts[0]), n
+}
+
+// decodeStrings decodes a JSON array of strings and numbers into strings.
+func decodeStrings(data []byte) ([]string, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, len(raw))
+	for i, r := range raw {
+		if len(r) > 0 && r[0] == '"' {
+			if err := json.Unmarshal(r, &strs[i]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		strs[i] = string(r)
+	}
+
+	return strs, nil
+}
+
+// decodeTicker decodes a ticker channel payload.
+func decodeTicker(data []byte) (ticker gokraken.TickerInfo, err error) {
+	var raw map[string]json.RawMessage
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+
+	fields := map[string]*[]string{
+		"a": &ticker.A,
+		"b": &ticker.B,
+		"c": &ticker.C,
+		"v": &ticker.V,
+		"p": &ticker.P,
+		"l": &ticker.L,
+		"h": &ticker.H,
+	}
+
+	for key, dst := range fields {
+		if *dst, err = decodeStrings(raw[key]); err != nil {
+			err = fmt.Errorf("could not decode ticker %s: %s", key, err)
+			return
+		}
+	}
+
+	if err = json.Unmarshal(raw["t"], &ticker.T); err != nil {
+		err = fmt.Errorf("could not decode ticker t: %s", err)
+		return
+	}
+
+	// The WebSocket API sends today's and the last 24 hours opening price.
+	open, err := decodeStrings(raw["o"])
+	if err != nil {
+		err = fmt.Errorf("could not decode ticker o: %s", err)
+		return
+	}
+
+	if len(open) > 0 {
+		ticker.O = open[0]
+	}
+
+	return
+}
+
+// decodeOhlc decodes an ohlc channel payload.
+func decodeOhlc(data []byte) (ohlc gokraken.OhlcData, end time.Time, err error) {
+	strs, err := decodeStrings(data)
+	if err != nil {
+		return
+	}
+
+	if len(strs) < 9 {
+		err = fmt.Errorf("expected 9 ohlc values, got %d", len(strs))
+		return
+	}
+
+	if ohlc.Timestamp, err = gokraken.ParseUnixTime(strs[0]); err != nil {
+		return
+	}
+
+	if end, err = gokraken.ParseUnixTime(strs[1]); err != nil {
+		return
+	}
+
+	err = gokraken.ParseDecimals(strs[2:], &ohlc.Open, &ohlc.High, &ohlc.Low, &ohlc.Close, &ohlc.Vwap, &ohlc.Volume)
+	if err != nil {
+		return
+	}
+
+	ohlc.Count, err = strconv.Atoi(strs[8])
+	return
+}
+
+// decodeTrades decodes a trade channel payload.
+func decodeTrades(data []byte) (trades []gokraken.Trade, err error) {
+	var raw []json.RawMessage
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+
+	for key, r := range raw {
+		var strs []string
+		if err = json.Unmarshal(r, &strs); err != nil {
+			return
+		}
+
+		if len(strs) < 6 {
+			err = fmt.Errorf("expected 6 values at trade=%d, got %d", key, len(strs))
+			return
+		}
+
+		var trade gokraken.Trade
+		if err = gokraken.ParseDecimals(strs, &trade.Price, &trade.Volume); err != nil {
+			return
+		}
+
+		if trade.Timestamp, err = gokraken.ParseUnixTime(strs[2]); err != nil {
+			return
+		}
+
+		switch strs[3] {
+		case "b":
+			trade.BuySell = gokraken.TradeBuy
+		case "s":
+			trade.BuySell = gokraken.TradeSell
+		}
+
+		switch strs[4] {
+		case "m":
+			trade.MarketLimit = gokraken.TradeMarket
+		case "l":
+			trade.MarketLimit = gokraken.TradeLimit
+		}
+
+		trade.Miscellaneous = strs[5]
+
+		trades = append(trades, trade)
+	}
+
+	return
+}
+
+// decodeSpread decodes a spread channel payload.
+func decodeSpread(data []byte) (spread gokraken.SpreadData, err error) {
+	var strs []string
+	if err = json.Unmarshal(data, &strs); err != nil {
+		return
+	}
+
+	if len(strs) < 3 {
+		err = fmt.Errorf("expected 3 spread values, got %d", len(strs))
+		return
+	}
+
+	if err = gokraken.ParseDecimals(strs, &spread.Bid, &spread.Ask); err != nil {
+		return
+	}
+
+	spread.Timestamp, err = gokraken.ParseUnixTime(strs[2])
+	return
+}
+
+// decodeBookLevels decodes price levels from a book channel payload.
+func decodeBookLevels(data []byte) (levels []gokraken.DepthItem, err error) {
+	var raw [][]string
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+
+	levels = make([]gokraken.DepthItem, len(raw))
+	for i, strs := range raw {
+		if len(strs) < 3 {
+			err = fmt.Errorf("expected 3 values at level=%d, got %d", i, len(strs))
+			return
+		}
+
+		if err = gokraken.ParseDecimals(strs, &levels[i].Price, &levels[i].Volume); err != nil {
+			return
+		}
+
+		if levels[i].Timestamp, err = gokraken.ParseUnixTime(strs[2]); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// decodeBook decodes the payloads of a book channel message into update.
+// Updates to both sides of the book may be sent as separate payloads.
+func decodeBook(payloads []json.RawMessage, update *BookUpdate) error {
+	for _, payload := range payloads {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &raw); err != nil {
+			return err
+		}
+
+		for key, data := range raw {
+			var err error
+			switch key {
+			case "as":
+				update.Snapshot = true
+				update.Asks, err = decodeBookLevels(data)
+			case "bs":
+				update.Snapshot = true
+				update.Bids, err = decodeBookLevels(data)
+			case "a":
+				update.Asks, err = decodeBookLevels(data)
+			case "b":
+				update.Bids, err = decodeBookLevels(data)
+			case "c":
+				var checksum string
+				if err = json.Unmarshal(data, &checksum); err != nil {
+					break
+				}
+
+				var c uint64
+				c, err = strconv.ParseUint(checksum, 10, 32)
+				update.Checksum = uint32(c)
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not decode book %s: %s", key, err)
+			}
+		}
+	}
+
+	return nil
+}
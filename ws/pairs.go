@@ -0,0 +1,21 @@
+package ws
+
+import (
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// PairName returns the WebSocket API name of an asset pair, e.g. XBT/USD for
+// pairs.XXBTZUSD, or its Kraken name if the wsname is not known.
+func PairName(pair pairs.AssetPair) string {
+	if info, ok := pair.Info(); ok && info.WSName != "" {
+		return info.WSName
+	}
+
+	return pair.String()
+}
+
+// FindPair returns the asset pair with the given WebSocket API name. Kraken
+// names and altnames are also resolved.
+func FindPair(name string) (pairs.AssetPair, bool) {
+	return pairs.Lookup(name)
+}
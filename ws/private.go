@@ -0,0 +1,464 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+)
+
+const (
+	// PrivateURL is the URL of the private Kraken WebSocket API.
+	PrivateURL = "wss://ws-auth.kraken.com"
+
+	// ChannelOwnTrades is the private channel for trades of the user.
+	ChannelOwnTrades Channel = "ownTrades"
+
+	// ChannelOpenOrders is the private channel for open orders of the user.
+	ChannelOpenOrders Channel = "openOrders"
+
+	// snapshotTrades is the number of trades in the first ownTrades message
+	// after subscribing, which is also the most resynced when no trade has
+	// been received.
+	snapshotTrades = 50
+)
+
+// OwnTradesUpdate is a message from the ownTrades channel. The first message
+// after subscribing contains the most recent trades, later messages contain
+// new trades. Trades are keyed by trade id.
+type OwnTradesUpdate struct {
+	Trades   map[string]gokraken.UserTrade
+	Sequence int64
+
+	// Resync is set on updates fetched from the REST API after messages were
+	// missed. It contains all the trades since the last trade received, or the
+	// most recent trades if none was received, and may arrive after updates
+	// received later.
+	Resync bool
+}
+
+// OpenOrdersUpdate is a message from the openOrders channel. The first
+// message after subscribing contains all open orders, later messages contain
+// new orders and the changed fields of existing orders, such as their status.
+// Orders are keyed by transaction id.
+type OpenOrdersUpdate struct {
+	Orders   map[string]gokraken.Order
+	Sequence int64
+
+	// Resync is set on updates fetched from the REST API after messages were
+	// missed. It contains all open orders, and may arrive after updates
+	// received later.
+	Resync bool
+}
+
+// ownTrade is a trade in an ownTrades channel message.
+type ownTrade struct {
+	OrderTxID string                `json:"ordertxid"`
+	Pair      string                `json:"pair"`
+	Time      string                `json:"time"`
+	Type      gokraken.TradeBuySell `json:"type"`
+	OrderType gokraken.OrderType    `json:"ordertype"`
+	Price     gokraken.Decimal      `json:"price"`
+	Cost      gokraken.Decimal      `json:"cost"`
+	Fee       gokraken.Decimal      `json:"fee"`
+	Vol       gokraken.Decimal      `json:"vol"`
+	Margin    gokraken.Decimal      `json:"margin"`
+}
+
+// openOrder is an order in an openOrders channel message. Updates to existing
+// orders only contain the changed fields.
+type openOrder struct {
+	RefID       string               `json:"refid"`
+	UserRef     int64                `json:"userref"`
+	Status      gokraken.OrderStatus `json:"status"`
+	OpenTime    string               `json:"opentm"`
+	StartTime   string               `json:"starttm"`
+	ExpireTime  string               `json:"expiretm"`
+	Description *struct {
+		Pair      string                `json:"pair"`
+		Close     string                `json:"close"`
+		Leverage  string                `json:"leverage"`
+		Order     string                `json:"order"`
+		OrderType gokraken.OrderType    `json:"ordertype"`
+		Price     string                `json:"price"`
+		Price2    string                `json:"price2"`
+		Type      gokraken.TradeBuySell `json:"type"`
+	} `json:"descr"`
+	Volume         gokraken.Decimal `json:"vol"`
+	VolumeExecuted gokraken.Decimal `json:"vol_exec"`
+	Cost           gokraken.Decimal `json:"cost"`
+	Fee            gokraken.Decimal `json:"fee"`
+	AvgPrice       gokraken.Decimal `json:"avg_price"`
+	StopPrice      gokraken.Decimal `json:"stopprice"`
+	LimitPrice     gokraken.Decimal `json:"limitprice"`
+	Misc           string           `json:"misc"`
+	OrderFlags     string           `json:"oflags"`
+	Reason         string           `json:"cancel_reason"`
+}
+
+// NewPrivate returns a new Client for the private Kraken WebSocket API. The
+// REST client must have API credentials with permission to access WebSockets.
+func NewPrivate(rest *gokraken.Kraken) *Client {
+	c := NewWithURL(PrivateURL)
+	c.REST = rest
+
+	return c
+}
+
+// OwnTrades returns the channel of own trades updates.
+func (c *Client) OwnTrades() <-chan OwnTradesUpdate {
+	return c.ownTrades
+}
+
+// OpenOrders returns the channel of open orders updates.
+func (c *Client) OpenOrders() <-chan OpenOrdersUpdate {
+	return c.openOrders
+}
+
+// private reports whether the channel requires authentication.
+func (ch Channel) private() bool {
+	return ch == ChannelOwnTrades || ch == ChannelOpenOrders
+}
+
+// authenticate obtains a token for subscribing to private channels.
+func (c *Client) authenticate(ctx context.Context) error {
+	res, err := c.REST.UserData.WebSocketsToken(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get websockets token: %s", err)
+	}
+
+	c.mu.Lock()
+	c.token = res.Token
+	c.mu.Unlock()
+
+	return nil
+}
+
+// reauthenticate obtains a new token before reconnecting, as tokens expire if
+// they are not used to subscribe within 15 minutes.
+func (c *Client) reauthenticate() error {
+	if c.REST == nil {
+		return nil
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return c.authenticate(ctx)
+}
+
+// context returns a context which is done when the client is closed.
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		select {
+		case <-c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// subscriptionEvent returns the subscribe or unsubscribe event for the
+// subscription, including the token for private channels.
+func (c *Client) subscriptionEvent(name string, sub Subscription, reqID int64) event {
+	e := sub.event(name, reqID)
+
+	if sub.Name.private() {
+		c.mu.Lock()
+		e.Subscription.Token = c.token
+		c.mu.Unlock()
+	}
+
+	return e
+}
+
+// markStale marks the private channels subscribed to as having missed
+// messages, so that they are resynced once the subscription is restored. The
+// client mutex must be held.
+func (c *Client) markStale() {
+	for key := range c.subs {
+		if key.name.private() {
+			c.stale[key.name] = true
+		}
+	}
+
+	c.seqs = make(map[Channel]int64)
+}
+
+// handlePrivate processes a private channel message.
+func (c *Client) handlePrivate(raw []json.RawMessage) error {
+	var channelName string
+	if err := json.Unmarshal(raw[1], &channelName); err != nil {
+		return fmt.Errorf("could not decode channel name: %s", err)
+	}
+
+	var meta struct {
+		Sequence int64 `json:"sequence"`
+	}
+	if err := json.Unmarshal(raw[2], &meta); err != nil {
+		return fmt.Errorf("could not decode sequence: %s", err)
+	}
+
+	name := Channel(channelName)
+
+	switch name {
+	case ChannelOwnTrades:
+		trades, err := decodeOwnTrades(raw[0])
+		if err != nil {
+			return fmt.Errorf("could not decode own trades: %s", err)
+		}
+
+		c.recordTrades(trades)
+
+		select {
+		case c.ownTrades <- OwnTradesUpdate{Trades: trades, Sequence: meta.Sequence}:
+		case <-c.done:
+		}
+	case ChannelOpenOrders:
+		orders, err := decodeOpenOrders(raw[0])
+		if err != nil {
+			return fmt.Errorf("could not decode open orders: %s", err)
+		}
+
+		select {
+		case c.openOrders <- OpenOrdersUpdate{Orders: orders, Sequence: meta.Sequence}:
+		case <-c.done:
+		}
+	default:
+		return fmt.Errorf("unknown channel %s", channelName)
+	}
+
+	// Resyncing makes REST calls, so is done in the background rather than
+	// holding up the messages of other channels.
+	if c.sequence(name, meta.Sequence) {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			if err := c.resync(name, meta.Sequence); err != nil {
+				c.reportError(err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// recordTrades records the time of the last trade received, from which trades
+// are resynced.
+func (c *Client) recordTrades(trades map[string]gokraken.UserTrade) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, trade := range trades {
+		if trade.Time.After(c.lastTrade) {
+			c.lastTrade = trade.Time
+		}
+	}
+}
+
+// sequence records the sequence number of a private channel message and
+// reports whether messages were missed before it.
+func (c *Client) sequence(name Channel, seq int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last, stale := c.seqs[name], c.stale[name]
+
+	c.seqs[name] = seq
+	delete(c.stale, name)
+
+	return stale || (last != 0 && seq != last+1)
+}
+
+// resync fetches the state of a private channel from the REST API after
+// messages were missed. Trades are paged back to the last trade received.
+func (c *Client) resync(name Channel, seq int64) error {
+	if c.REST == nil {
+		return errors.New("cannot resync without a rest client")
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	switch name {
+	case ChannelOwnTrades:
+		c.mu.Lock()
+		since := c.lastTrade
+		c.mu.Unlock()
+
+		var req gokraken.TradesHistoryRequest
+		if !since.IsZero() {
+			req.Start = &since
+		}
+
+		trades := make(map[string]gokraken.UserTrade)
+		it := c.REST.UserData.TradesHistoryIter(ctx, req)
+		for it.Next() {
+			trade := it.Trade()
+			if !trade.Time.After(since) || (since.IsZero() && len(trades) == snapshotTrades) {
+				break
+			}
+
+			trades[it.TxID()] = trade
+		}
+
+		c.recordTrades(trades)
+
+		if err := it.Err(); err != nil {
+			return fmt.Errorf("could not resync own trades: %s", err)
+		}
+
+		select {
+		case c.ownTrades <- OwnTradesUpdate{Trades: trades, Sequence: seq, Resync: true}:
+		case <-c.done:
+		}
+	case ChannelOpenOrders:
+		res, err := c.REST.UserData.OpenOrders(ctx, false, 0)
+		if err != nil {
+			return fmt.Errorf("could not resync open orders: %s", err)
+		}
+
+		for txid, order := range res.Open {
+			order.TransactionID = txid
+			res.Open[txid] = order
+		}
+
+		select {
+		case c.openOrders <- OpenOrdersUpdate{Orders: res.Open, Sequence: seq, Resync: true}:
+		case <-c.done:
+		}
+	}
+
+	return nil
+}
+
+// decodeOwnTrades decodes an ownTrades channel payload.
+func decodeOwnTrades(data []byte) (map[string]gokraken.UserTrade, error) {
+	var raw []map[string]ownTrade
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	trades := make(map[string]gokraken.UserTrade)
+	for _, m := range raw {
+		for id, t := range m {
+			trade := gokraken.UserTrade{
+				OrderTxid: t.OrderTxID,
+				Pair:      pairCode(t.Pair),
+				Type:      t.Type,
+				OrderType: t.OrderType,
+				Price:     t.Price,
+				Cost:      t.Cost,
+				Fee:       t.Fee,
+				Vol:       t.Vol,
+				Margin:    t.Margin,
+			}
+
+			var err error
+			if trade.Time, err = gokraken.ParseUnixTime(t.Time); err != nil {
+				return nil, fmt.Errorf("invalid time at trade=%s: %s", id, err)
+			}
+
+			trades[id] = trade
+		}
+	}
+
+	return trades, nil
+}
+
+// decodeOpenOrders decodes an openOrders channel payload.
+func decodeOpenOrders(data []byte) (map[string]gokraken.Order, error) {
+	var raw []map[string]openOrder
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	orders := make(map[string]gokraken.Order)
+	for _, m := range raw {
+		for txid, o := range m {
+			order := gokraken.Order{
+				TransactionID:  txid,
+				ReferenceID:    o.RefID,
+				UserRef:        o.UserRef,
+				Status:         o.Status,
+				Volume:         o.Volume,
+				VolumeExecuted: o.VolumeExecuted,
+				Cost:           o.Cost,
+				Fee:            o.Fee,
+				Price:          o.AvgPrice,
+				StopPrice:      o.StopPrice,
+				LimitPrice:     o.LimitPrice,
+				Misc:           gokraken.SplitList(o.Misc),
+				OrderFlags:     gokraken.ParseOrderFlags(o.OrderFlags),
+				Reason:         o.Reason,
+			}
+
+			if d := o.Description; d != nil {
+				order.Description = gokraken.OrderDescription{
+					Close:          d.Close,
+					Leverage:       d.Leverage,
+					Order:          d.Order,
+					OrderType:      d.OrderType,
+					PrimaryPrice:   d.Price,
+					SecondaryPrice: d.Price2,
+					Type:           d.Type,
+				}
+
+				if pair, ok := FindPair(d.Pair); ok {
+					order.Description.AssetPair = pair
+				}
+			}
+
+			err := parseOptionalTimes(
+				[]string{o.OpenTime, o.StartTime, o.ExpireTime},
+				&order.OpenTime, &order.StartTime, &order.ExpireTime,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time at order=%s: %s", txid, err)
+			}
+
+			orders[txid] = order
+		}
+	}
+
+	return orders, nil
+}
+
+// parseOptionalTimes parses strings into times, leaving the destinations of
+// empty strings and zero times unchanged.
+func parseOptionalTimes(strs []string, dsts ...*time.Time) error {
+	for i, dst := range dsts {
+		if strs[i] == "" {
+			continue
+		}
+
+		t, err := gokraken.ParseUnixTime(strs[i])
+		if err != nil {
+			return err
+		}
+
+		if t.UnixNano() != 0 {
+			*dst = t
+		}
+	}
+
+	return nil
+}
+
+// pairCode returns the REST API code of the pair with the given WebSocket API
+// name, or the name itself if the pair is unknown.
+func pairCode(name string) string {
+	if pair, ok := FindPair(name); ok {
+		return pair.String()
+	}
+
+	return name
+}
@@ -0,0 +1,313 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// privatePath is the path of a private REST resource.
+func privatePath(resource string) string {
+	return "/0/private/" + resource
+}
+
+const tokenResponse = `{"error":[],"result":{"token":"TOKEN","expires":900}}`
+
+// newPrivateClient returns a private client connected to the mock servers.
+func newPrivateClient(t *testing.T, s *mockServer, rest *httptest.Server) *Client {
+	k := gokraken.NewWithAuth("api_key", "cHJpdmF0ZV9rZXk=")
+	k.BaseURL = rest.URL
+
+	client := NewPrivate(k)
+	client.URL = s.wsURL()
+
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestClient_OwnTrades(t *testing.T) {
+	s := newMockServer(map[Channel][]string{
+		ChannelOwnTrades: {`[[{"TDLH43-DVQXD-2KHVYY":{"cost":"1000000.00000","fee":"1600.00000","margin":"0.00000","ordertxid":"TDLH43-DVQXD-2KHVYY","ordertype":"limit","pair":"XBT/EUR","postxid":"OGTT3Y-C6I3P-XRI6HX","price":"100000.00000","time":"1560516023.070651","type":"sell","vol":"1000000000.00000000"}}],"ownTrades",{"sequence":1}]`},
+	})
+	defer s.Close()
+
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(tokenResponse))
+	}))
+	defer rest.Close()
+
+	client := newPrivateClient(t, s, rest)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Subscribe(ctx, Subscription{Name: ChannelOwnTrades}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := OwnTradesUpdate{
+		Trades: map[string]gokraken.UserTrade{
+			"TDLH43-DVQXD-2KHVYY": {
+				OrderTxid: "TDLH43-DVQXD-2KHVYY",
+				Pair:      pairs.XXBTZEUR.String(),
+				Time:      time.Unix(1560516023, 70651000),
+				Type:      gokraken.TradeSell,
+				OrderType: gokraken.OrderTypeLimit,
+				Price:     dec("100000"),
+				Cost:      dec("1000000"),
+				Fee:       dec("1600"),
+				Vol:       dec("1000000000"),
+			},
+		},
+		Sequence: 1,
+	}
+
+	assert(expected, <-client.OwnTrades(), t)
+
+	events := s.subscribeEvents()
+	assert(1, len(events), t)
+
+	sub := events[0]["subscription"].(map[string]interface{})
+	assert("TOKEN", sub["token"], t)
+	assert(nil, events[0]["pair"], t)
+}
+
+func TestClient_OpenOrders(t *testing.T) {
+	s := newMockServer(map[Channel][]string{
+		ChannelOpenOrders: {
+			`[[{"OGTT3Y-C6I3P-XRI6HX":{"avg_price":"34.50000","cost":"0.00000","descr":{"close":null,"leverage":"0:1","order":"sell 10.00345345 XBT/EUR @ limit 34.50000 with 0:1 leverage","ordertype":"limit","pair":"XBT/EUR","price":"34.50000","price2":"0.00000","type":"sell"},"expiretm":"0.000000","fee":"0.00000","limitprice":"34.50000","misc":"","oflags":"fcib","opentm":"1560516023.070651","refid":"OKIVMP-5GVZN-Z2D2UA","starttm":"0.000000","status":"open","stopprice":"0.000000","userref":0,"vol":"10.00345345","vol_exec":"0.00000000"}}],"openOrders",{"sequence":1}]`,
+			`[[{"OGTT3Y-C6I3P-XRI6HX":{"status":"closed","cancel_reason":"User requested"}}],"openOrders",{"sequence":2}]`,
+		},
+	})
+	defer s.Close()
+
+	var openOrdersCalls int32
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if r.URL.Path == privatePath(gokraken.OpenOrdersResource) {
+			atomic.AddInt32(&openOrdersCalls, 1)
+		}
+
+		w.Write([]byte(tokenResponse))
+	}))
+	defer rest.Close()
+
+	client := newPrivateClient(t, s, rest)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Subscribe(ctx, Subscription{Name: ChannelOpenOrders}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []OpenOrdersUpdate{
+		{
+			Orders: map[string]gokraken.Order{
+				"OGTT3Y-C6I3P-XRI6HX": {
+					TransactionID: "OGTT3Y-C6I3P-XRI6HX",
+					ReferenceID:   "OKIVMP-5GVZN-Z2D2UA",
+					Status:        gokraken.OrderStatusOpen,
+					OpenTime:      time.Unix(1560516023, 70651000),
+					Description: gokraken.OrderDescription{
+						AssetPair:      pairs.XXBTZEUR,
+						Leverage:       "0:1",
+						Order:          "sell 10.00345345 XBT/EUR @ limit 34.50000 with 0:1 leverage",
+						OrderType:      gokraken.OrderTypeLimit,
+						PrimaryPrice:   "34.50000",
+						SecondaryPrice: "0.00000",
+						Type:           gokraken.TradeSell,
+					},
+					Volume:     dec("10.00345345"),
+					Price:      dec("34.5"),
+					LimitPrice: dec("34.5"),
+					OrderFlags: []gokraken.OrderFlag{gokraken.OrderFlagFcib},
+				},
+			},
+			Sequence: 1,
+		},
+		{
+			Orders: map[string]gokraken.Order{
+				"OGTT3Y-C6I3P-XRI6HX": {
+					TransactionID: "OGTT3Y-C6I3P-XRI6HX",
+					Status:        gokraken.OrderStatusClosed,
+					Reason:        "User requested",
+				},
+			},
+			Sequence: 2,
+		},
+	}
+
+	for _, e := range expected {
+		assert(e, <-client.OpenOrders(), t)
+	}
+
+	assert(int32(0), atomic.LoadInt32(&openOrdersCalls), t)
+}
+
+func TestClient_OpenOrdersGap(t *testing.T) {
+	s := newMockServer(map[Channel][]string{
+		ChannelOpenOrders: {
+			`[[],"openOrders",{"sequence":1}]`,
+			`[[],"openOrders",{"sequence":3}]`,
+		},
+	})
+	defer s.Close()
+
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Path {
+		case privatePath(gokraken.WebSocketsTokenResource):
+			w.Write([]byte(tokenResponse))
+		case privatePath(gokraken.OpenOrdersResource):
+			w.Write([]byte(`{"error":[],"result":{"open":{"OGTT3Y-C6I3P-XRI6HX":{"refid":"OKIVMP-5GVZN-Z2D2UA","userref":0,"status":"open","opentm":1560516023,"starttm":0,"expiretm":0,"vol":"10.00345345","vol_exec":"1.23","cost":"0","fee":"0","price":"0","limitprice":"0","misc":"","oflags":"fcib"}},"count":1}}`))
+		}
+	}))
+	defer rest.Close()
+
+	client := newPrivateClient(t, s, rest)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Subscribe(ctx, Subscription{Name: ChannelOpenOrders}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(int64(1), (<-client.OpenOrders()).Sequence, t)
+	assert(int64(3), (<-client.OpenOrders()).Sequence, t)
+
+	resync := <-client.OpenOrders()
+	assert(true, resync.Resync, t)
+	assert(int64(3), resync.Sequence, t)
+	assert("OGTT3Y-C6I3P-XRI6HX", resync.Orders["OGTT3Y-C6I3P-XRI6HX"].TransactionID, t)
+	assert(dec("1.23"), resync.Orders["OGTT3Y-C6I3P-XRI6HX"].VolumeExecuted, t)
+}
+
+func TestClient_OwnTradesGap(t *testing.T) {
+	s := newMockServer(map[Channel][]string{
+		ChannelOwnTrades: {
+			`[[{"TDLH43-DVQXD-2KHVYY":{"cost":"1000000.00000","fee":"1600.00000","margin":"0.00000","ordertxid":"TDLH43-DVQXD-2KHVYY","ordertype":"limit","pair":"XBT/EUR","price":"100000.00000","time":"1560516023.070651","type":"sell","vol":"10.00000000"}}],"ownTrades",{"sequence":1}]`,
+			`[[],"ownTrades",{"sequence":3}]`,
+		},
+	})
+	defer s.Close()
+
+	// The trade history holds 120 trades a second apart, newest first and
+	// paged 50 at a time. The 77 trades after the last trade received span
+	// two pages.
+	var tradesCalls int32
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if r.URL.Path != privatePath(gokraken.TradesHistoryResource) {
+			w.Write([]byte(tokenResponse))
+			return
+		}
+
+		atomic.AddInt32(&tradesCalls, 1)
+
+		b, _ := ioutil.ReadAll(r.Body)
+		body, _ := url.ParseQuery(string(b))
+		ofs, _ := strconv.Atoi(body.Get("ofs"))
+
+		var trades []string
+		for i := ofs; i < 120 && i < ofs+50; i++ {
+			trades = append(trades, fmt.Sprintf(`"T%03d":{"ordertxid":"OGTT3Y-C6I3P-XRI6HX","pair":"XXBTZEUR","time":%d,"type":"sell","ordertype":"limit","price":100000,"cost":1000000,"fee":1600,"vol":10,"margin":0,"misc":""}`, i, 1560516100-i))
+		}
+
+		fmt.Fprintf(w, `{"error":[],"result":{"trades":{%s},"count":120}}`, strings.Join(trades, ","))
+	}))
+	defer rest.Close()
+
+	client := newPrivateClient(t, s, rest)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Subscribe(ctx, Subscription{Name: ChannelOwnTrades}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(int64(1), (<-client.OwnTrades()).Sequence, t)
+	assert(int64(3), (<-client.OwnTrades()).Sequence, t)
+
+	resync := <-client.OwnTrades()
+	assert(true, resync.Resync, t)
+	assert(77, len(resync.Trades), t)
+	assert(time.Unix(1560516024, 0), resync.Trades["T076"].Time, t)
+	assert(int32(2), atomic.LoadInt32(&tradesCalls), t)
+}
+
+func TestClient_PrivateReconnect(t *testing.T) {
+	s := newMockServer(map[Channel][]string{
+		ChannelOwnTrades: {`[[{"TDLH43-DVQXD-2KHVYY":{"cost":"1000000.00000","fee":"1600.00000","margin":"0.00000","ordertxid":"TDLH43-DVQXD-2KHVYY","ordertype":"limit","pair":"XBT/EUR","price":"100000.00000","time":"1560516023.070651","type":"sell","vol":"10.00000000"}}],"ownTrades",{"sequence":1}]`},
+	})
+	defer s.Close()
+
+	var tokenCalls, tradesCalls int32
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Path {
+		case privatePath(gokraken.WebSocketsTokenResource):
+			atomic.AddInt32(&tokenCalls, 1)
+			w.Write([]byte(tokenResponse))
+		case privatePath(gokraken.TradesHistoryResource):
+			atomic.AddInt32(&tradesCalls, 1)
+			w.Write([]byte(`{"error":[],"result":{"trades":{"T000":{"ordertxid":"OGTT3Y-C6I3P-XRI6HX","pair":"XXBTZEUR","time":1560516100,"type":"sell","ordertype":"limit","price":100000,"cost":1000000,"fee":1600,"vol":10,"margin":0,"misc":""}},"count":1}}`))
+		}
+	}))
+	defer rest.Close()
+
+	client := newPrivateClient(t, s, rest)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Subscribe(ctx, Subscription{Name: ChannelOwnTrades}); err != nil {
+		t.Fatal(err)
+	}
+	<-client.OwnTrades()
+
+	// Drop the connection; messages may have been missed before the
+	// subscription is restored, so the trades are resynced.
+	s.drop()
+
+	assert(int64(1), (<-client.OwnTrades()).Sequence, t)
+
+	resync := <-client.OwnTrades()
+	assert(true, resync.Resync, t)
+	assert(time.Unix(1560516100, 0), resync.Trades["T000"].Time, t)
+
+	assert(int32(2), atomic.LoadInt32(&tokenCalls), t)
+	assert(int32(1), atomic.LoadInt32(&tradesCalls), t)
+}
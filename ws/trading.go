@@ -0,0 +1,225 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+)
+
+const (
+	// EventAddOrder is sent to Kraken to add an order.
+	EventAddOrder = "addOrder"
+
+	// EventEditOrder is sent to Kraken to edit an open order.
+	EventEditOrder = "editOrder"
+
+	// EventCancelOrder is sent to Kraken to cancel open orders.
+	EventCancelOrder = "cancelOrder"
+
+	// EventCancelAll is sent to Kraken to cancel all open orders.
+	EventCancelAll = "cancelAll"
+
+	// EventCancelAllOrdersAfter is sent to Kraken to cancel all open orders
+	// after a timeout, unless the timeout is extended or disabled.
+	EventCancelAllOrdersAfter = "cancelAllOrdersAfter"
+)
+
+// orderStatus is sent by Kraken in response to trading requests.
+type orderStatus struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"errorMessage"`
+	Description  string `json:"descr"`
+	TxID         string `json:"txid"`
+	OriginalTxID string `json:"originaltxid"`
+	Count        int    `json:"count"`
+}
+
+// AddOrder adds an order. The connection must be to the private WebSocket
+// API.
+func (c *Client) AddOrder(ctx context.Context, order gokraken.UserOrder) (*gokraken.AddOrderResponse, error) {
+	params := make(map[string]interface{})
+	for key, values := range order.Values() {
+		params[key] = values[0]
+	}
+	params["pair"] = PairName(order.Pair)
+
+	var status orderStatus
+	if err := c.call(ctx, EventAddOrder, params, &status); err != nil {
+		return nil, err
+	}
+
+	res := &gokraken.AddOrderResponse{
+		Description: gokraken.OrderDescription{Order: status.Description},
+	}
+
+	if status.TxID != "" {
+		res.TxIDs = []string{status.TxID}
+	}
+
+	return res, nil
+}
+
+// EditOrder edits an open order. Kraken cancels the order and replaces it
+// with a new order with a new transaction id. The WebSocket API does not
+// support a deadline, so an error is returned if the edit has one.
+func (c *Client) EditOrder(ctx context.Context, edit gokraken.EditOrderRequest) (*gokraken.EditOrderResponse, error) {
+	if _, err := edit.OrderID(); err != nil {
+		return nil, err
+	}
+
+	if !edit.Deadline.IsZero() {
+		return nil, errors.New("edit deadlines are not supported by the websocket api")
+	}
+
+	// The WebSocket API names the order and the new user reference id
+	// differently from the REST API.
+	names := map[string]string{"txid": "orderid", "userref": "newuserref"}
+
+	params := make(map[string]interface{})
+	for key, values := range edit.Values() {
+		if name, ok := names[key]; ok {
+			key = name
+		}
+		params[key] = values[0]
+	}
+	params["pair"] = PairName(edit.Pair)
+
+	var status orderStatus
+	if err := c.call(ctx, EventEditOrder, params, &status); err != nil {
+		return nil, err
+	}
+
+	return &gokraken.EditOrderResponse{
+		Description:  gokraken.OrderDescription{Order: status.Description},
+		TxID:         status.TxID,
+		OriginalTxID: status.OriginalTxID,
+		Status:       status.Status,
+	}, nil
+}
+
+// CancelOrderByTxID cancels one or more open orders by transaction id, like
+// Trading.CancelOrderByTxID. Kraken does not report how many orders were
+// cancelled over the WebSocket API, so the Count of the response is only set
+// if Kraken sends one; a nil error means that Kraken accepted the request.
+func (c *Client) CancelOrderByTxID(ctx context.Context, txids ...gokraken.TxID) (*gokraken.CancelOrderResponse, error) {
+	if len(txids) == 0 {
+		return nil, errors.New("no orders to cancel")
+	}
+
+	ids := make([]string, len(txids))
+	for i, txid := range txids {
+		if !txid.Valid() {
+			return nil, fmt.Errorf("invalid txid %q", string(txid))
+		}
+		ids[i] = string(txid)
+	}
+
+	return c.cancelOrder(ctx, ids)
+}
+
+// CancelOrderByUserRef cancels all open orders with the given user reference
+// id, like Trading.CancelOrderByUserRef.
+func (c *Client) CancelOrderByUserRef(ctx context.Context, userRef int64) (*gokraken.CancelOrderResponse, error) {
+	return c.cancelOrder(ctx, []string{strconv.FormatInt(userRef, 10)})
+}
+
+// cancelOrder cancels the open orders with the given transaction ids or user
+// reference ids.
+func (c *Client) cancelOrder(ctx context.Context, txids []string) (*gokraken.CancelOrderResponse, error) {
+	params := map[string]interface{}{
+		"txid": txids,
+	}
+
+	var status orderStatus
+	if err := c.call(ctx, EventCancelOrder, params, &status); err != nil {
+		return nil, err
+	}
+
+	return &gokraken.CancelOrderResponse{Count: status.Count}, nil
+}
+
+// CancelAll cancels all open orders.
+func (c *Client) CancelAll(ctx context.Context) (*gokraken.CancelOrderResponse, error) {
+	var status orderStatus
+	if err := c.call(ctx, EventCancelAll, make(map[string]interface{}), &status); err != nil {
+		return nil, err
+	}
+
+	return &gokraken.CancelOrderResponse{Count: status.Count}, nil
+}
+
+// CancelAllOrdersAfter cancels all open orders once the timeout elapses,
+// unless it is called again to extend the timeout. A zero timeout disables
+// the timer.
+func (c *Client) CancelAllOrdersAfter(ctx context.Context, timeout time.Duration) (res *gokraken.CancelAllOrdersAfterResponse, err error) {
+	params := map[string]interface{}{
+		"timeout": int(timeout / time.Second),
+	}
+
+	var status struct {
+		CurrentTime string `json:"currentTime"`
+		TriggerTime string `json:"triggerTime"`
+	}
+	if err = c.call(ctx, EventCancelAllOrdersAfter, params, &status); err != nil {
+		return
+	}
+
+	res = &gokraken.CancelAllOrdersAfterResponse{}
+	if res.CurrentTime, err = time.Parse(time.RFC3339, status.CurrentTime); err != nil {
+		return
+	}
+
+	// Kraken sends a trigger time of 0 when the timer is disabled.
+	if status.TriggerTime != "0" {
+		res.TriggerTime, err = time.Parse(time.RFC3339, status.TriggerTime)
+	}
+
+	return
+}
+
+// call sends a trading request and decodes its response into res. Errors
+// reported by Kraken are returned as a *gokraken.APIError.
+func (c *Client) call(ctx context.Context, eventName string, params map[string]interface{}, res interface{}) error {
+	reqID := c.nextReqID()
+
+	c.mu.Lock()
+	params["token"] = c.token
+	c.mu.Unlock()
+
+	params["event"] = eventName
+	params["reqid"] = reqID
+
+	responses := c.register(reqID, 1)
+	defer c.unregister(reqID)
+
+	if err := c.send(params); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrClosed
+	case msg := <-responses:
+		var status orderStatus
+		if err := json.Unmarshal(msg, &status); err != nil {
+			return fmt.Errorf("could not decode %s status: %s", eventName, err)
+		}
+
+		if status.Status == "error" {
+			return gokraken.ParseAPIError(status.ErrorMessage)
+		}
+
+		if err := json.Unmarshal(msg, res); err != nil {
+			return fmt.Errorf("could not decode %s status: %s", eventName, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danmrichards/gokraken"
+	"github.com/danmrichards/gokraken/pairs"
+)
+
+// lastEvent returns the last event received by the server.
+func (s *mockServer) lastEvent() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.events[len(s.events)-1]
+}
+
+func TestClient_Trading(t *testing.T) {
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(tokenResponse))
+	}))
+	defer rest.Close()
+
+	cases := []struct {
+		name     string
+		reply    string
+		call     func(ctx context.Context, c *Client) (interface{}, error)
+		event    map[string]interface{}
+		expected interface{}
+	}{
+		{
+			name:  EventAddOrder,
+			reply: `{"descr":"buy 0.01770000 XBTUSD @ limit 4000","event":"addOrderStatus","status":"ok","txid":"ONPNXH-KMKMU-F4MNLO"}`,
+			call: func(ctx context.Context, c *Client) (interface{}, error) {
+				return c.AddOrder(ctx, gokraken.UserOrder{
+					Pair:      pairs.XXBTZUSD,
+					Type:      gokraken.TradeBuy,
+					OrderType: gokraken.OrderTypeLimit,
+					Price:     gokraken.PriceAt(dec("4000")),
+					Volume:    dec("0.0177"),
+					OFlags:    []gokraken.OrderFlag{gokraken.OrderFlagPost},
+					UserRef:   42,
+				})
+			},
+			event: map[string]interface{}{
+				"event":     EventAddOrder,
+				"token":     "TOKEN",
+				"pair":      "XBT/USD",
+				"type":      "buy",
+				"ordertype": "limit",
+				"price":     "4000",
+				"volume":    "0.0177",
+				"oflags":    "post",
+				"userref":   "42",
+			},
+			expected: &gokraken.AddOrderResponse{
+				Description: gokraken.OrderDescription{Order: "buy 0.01770000 XBTUSD @ limit 4000"},
+				TxIDs:       []string{"ONPNXH-KMKMU-F4MNLO"},
+			},
+		},
+		{
+			name:  EventEditOrder,
+			reply: `{"descr":"order edited price = 9000.00000000","event":"editOrderStatus","originaltxid":"O65KZW-J4AW3-VFS74A","status":"ok","txid":"OTI672-HJFAO-XOIPPK"}`,
+			call: func(ctx context.Context, c *Client) (interface{}, error) {
+				return c.EditOrder(ctx, gokraken.EditOrderRequest{
+					TxID:       "O65KZW-J4AW3-VFS74A",
+					Pair:       pairs.XXBTZUSD,
+					Price:      gokraken.PriceAt(dec("9000")),
+					NewUserRef: 7,
+				})
+			},
+			event: map[string]interface{}{
+				"event":      EventEditOrder,
+				"token":      "TOKEN",
+				"orderid":    "O65KZW-J4AW3-VFS74A",
+				"pair":       "XBT/USD",
+				"price":      "9000",
+				"newuserref": "7",
+			},
+			expected: &gokraken.EditOrderResponse{
+				Description:  gokraken.OrderDescription{Order: "order edited price = 9000.00000000"},
+				TxID:         "OTI672-HJFAO-XOIPPK",
+				OriginalTxID: "O65KZW-J4AW3-VFS74A",
+				Status:       "ok",
+			},
+		},
+		{
+			name:  EventCancelOrder,
+			reply: `{"event":"cancelOrderStatus","status":"ok"}`,
+			call: func(ctx context.Context, c *Client) (interface{}, error) {
+				return c.CancelOrderByTxID(ctx, "OGTT3Y-C6I3P-XRI6HX", "OKIVMP-5GVZN-Z2D2UA")
+			},
+			event: map[string]interface{}{
+				"event": EventCancelOrder,
+				"token": "TOKEN",
+				"txid":  []interface{}{"OGTT3Y-C6I3P-XRI6HX", "OKIVMP-5GVZN-Z2D2UA"},
+			},
+			expected: &gokraken.CancelOrderResponse{},
+		},
+		{
+			name:  EventCancelOrder + " by userref",
+			reply: `{"event":"cancelOrderStatus","status":"ok"}`,
+			call: func(ctx context.Context, c *Client) (interface{}, error) {
+				return c.CancelOrderByUserRef(ctx, 42)
+			},
+			event: map[string]interface{}{
+				"event": EventCancelOrder,
+				"token": "TOKEN",
+				"txid":  []interface{}{"42"},
+			},
+			expected: &gokraken.CancelOrderResponse{},
+		},
+		{
+			name:  EventCancelAll,
+			reply: `{"count":3,"event":"cancelAllStatus","status":"ok"}`,
+			call: func(ctx context.Context, c *Client) (interface{}, error) {
+				return c.CancelAll(ctx)
+			},
+			event: map[string]interface{}{
+				"event": EventCancelAll,
+				"token": "TOKEN",
+			},
+			expected: &gokraken.CancelOrderResponse{Count: 3},
+		},
+		{
+			name:  EventCancelAllOrdersAfter,
+			reply: `{"currentTime":"2020-12-21T09:37:09Z","event":"cancelAllOrdersAfterStatus","status":"ok","triggerTime":"2020-12-21T09:38:09Z"}`,
+			call: func(ctx context.Context, c *Client) (interface{}, error) {
+				return c.CancelAllOrdersAfter(ctx, time.Minute)
+			},
+			event: map[string]interface{}{
+				"event":   EventCancelAllOrdersAfter,
+				"token":   "TOKEN",
+				"timeout": float64(60),
+			},
+			expected: &gokraken.CancelAllOrdersAfterResponse{
+				CurrentTime: time.Date(2020, 12, 21, 9, 37, 9, 0, time.UTC),
+				TriggerTime: time.Date(2020, 12, 21, 9, 38, 9, 0, time.UTC),
+			},
+		},
+		{
+			name:  "disable " + EventCancelAllOrdersAfter,
+			reply: `{"currentTime":"2020-12-21T09:37:09Z","event":"cancelAllOrdersAfterStatus","status":"ok","triggerTime":"0"}`,
+			call: func(ctx context.Context, c *Client) (interface{}, error) {
+				return c.CancelAllOrdersAfter(ctx, 0)
+			},
+			event: map[string]interface{}{
+				"event":   EventCancelAllOrdersAfter,
+				"token":   "TOKEN",
+				"timeout": float64(0),
+			},
+			expected: &gokraken.CancelAllOrdersAfterResponse{
+				CurrentTime: time.Date(2020, 12, 21, 9, 37, 9, 0, time.UTC),
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newMockServer(nil)
+			s.replies = map[string]string{
+				c.event["event"].(string): c.reply,
+			}
+			defer s.Close()
+
+			client := newPrivateClient(t, s, rest)
+			defer client.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			res, err := c.call(ctx, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert(c.expected, res, t)
+
+			event := s.lastEvent()
+			delete(event, "reqid")
+			assert(c.event, event, t)
+		})
+	}
+}
+
+func TestClient_AddOrderError(t *testing.T) {
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte(tokenResponse))
+	}))
+	defer rest.Close()
+
+	s := newMockServer(nil)
+	s.replies = map[string]string{
+		EventAddOrder: `{"errorMessage":"EOrder:Insufficient funds","event":"addOrderStatus","status":"error"}`,
+	}
+	defer s.Close()
+
+	client := newPrivateClient(t, s, rest)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.AddOrder(ctx, gokraken.UserOrder{
+		Pair:      pairs.XXBTZUSD,
+		Type:      gokraken.TradeBuy,
+		OrderType: gokraken.OrderTypeMarket,
+		Volume:    dec("1"),
+	})
+
+	if !errors.Is(err, gokraken.ErrInsufficientFunds) {
+		t.Fatalf("%s: expected insufficient funds, got %v", t.Name(), err)
+	}
+}
+
+func TestClient_EditOrderInvalid(t *testing.T) {
+	client := NewWithURL("ws://localhost")
+
+	_, err := client.EditOrder(context.Background(), gokraken.EditOrderRequest{Pair: pairs.XXBTZUSD})
+	assert(gokraken.ValidationError{{Field: "TxID", Message: "no order to edit, set TxID or UserRef"}}, err, t)
+
+	_, err = client.EditOrder(context.Background(), gokraken.EditOrderRequest{
+		UserRef:  42,
+		Pair:     pairs.XXBTZUSD,
+		Deadline: time.Now(),
+	})
+	assert("edit deadlines are not supported by the websocket api", err.Error(), t)
+}
+
+func TestClient_CancelOrderInvalid(t *testing.T) {
+	client := NewWithURL("ws://localhost")
+
+	_, err := client.CancelOrderByTxID(context.Background())
+	assert("no orders to cancel", err.Error(), t)
+
+	_, err = client.CancelOrderByTxID(context.Background(), "OGTT3Y-C6I3P-XRI6HX", "42")
+	assert(`invalid txid "42"`, err.Error(), t)
+}